@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var aplCmd = &cobra.Command{
+	Use:   "apl",
+	Short: "convert APL rotations between JSON and text",
+	Long: "convert an APL rotation between protojson (e.g. a .apl.json file) and the text syntax, in whichever direction applies: " +
+		"JSON input is written as text, and text input as JSON.",
+	Run: aplMain,
+}
+
+func init() {
+	aplCmd.Flags().StringVar(&infile, "infile", "", "location of the rotation, as APLRotation protojson or text")
+	aplCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
+	aplCmd.MarkFlagRequired("infile")
+}
+
+func aplMain(cmd *cobra.Command, args []string) {
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load rotation file %q: %v", infile, err)
+	}
+
+	var output string
+	if strings.HasPrefix(strings.TrimSpace(string(data)), "{") {
+		rotation := &proto.APLRotation{}
+		if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, rotation); err != nil {
+			log.Fatalf("failed to load rotation json: %s", err)
+		}
+		if output, err = apltext.Format(rotation); err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		rotation, err := apltext.Parse(string(data))
+		if err != nil {
+			log.Fatalf("%s:%s", infile, err)
+		}
+		json, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(rotation)
+		if err != nil {
+			log.Fatalf("failed to marshal rotation: %s", err)
+		}
+		output = string(json) + "\n"
+	}
+
+	if outfile == "" {
+		fmt.Print(output)
+	} else if err := os.WriteFile(outfile, []byte(output), 0666); err != nil {
+		log.Fatalf("failed to write output file: %s", err)
+	}
+}
@@ -4,13 +4,21 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/combatlog"
 	"github.com/wowsims/classic/sim/core"
 	"github.com/wowsims/classic/sim/core/proto"
 	"google.golang.org/protobuf/encoding/protojson"
 )
 
+var (
+	wowCombatLogFile string
+	replaySeed       int64
+	logFile          string
+)
+
 var simCmd = &cobra.Command{
 	Use:   "sim",
 	Short: "simulate items & settings",
@@ -21,6 +29,9 @@ func init() {
 	simCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format)")
 	simCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
 	simCmd.Flags().BoolVar(&verbose, "verbose", false, "print information during runtime")
+	simCmd.Flags().StringVar(&wowCombatLogFile, "wowcombatlog", "", "also write the first iteration to this file in WoWCombatLog.txt format")
+	simCmd.Flags().Int64Var(&replaySeed, "replayseed", 0, "replay only the iteration that ran with this seed (e.g. a min_seed or max_seed from a result) in debug mode")
+	simCmd.Flags().StringVar(&logFile, "logfile", "", "also write the debug logs of the sim to this file")
 	simCmd.MarkFlagRequired("infile")
 }
 
@@ -35,7 +46,17 @@ func simMain(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("failed to load input json file: %s", err)
 	}
+	if wowCombatLogFile != "" && input.SimOptions.CombatLogIterations == 0 {
+		input.SimOptions.CombatLogIterations = 1
+	}
+	if replaySeed != 0 {
+		if input.SimOptions == nil {
+			input.SimOptions = &proto.SimOptions{}
+		}
+		input.SimOptions.ReplaySeed = replaySeed
+	}
 
+	startTime := time.Now()
 	var output []byte
 	reporter := make(chan *proto.ProgressMetrics, 10)
 	core.RunRaidSimConcurrentAsync(input, reporter, "cmd-raid-sim")
@@ -51,6 +72,15 @@ func simMain(cmd *cobra.Command, args []string) {
 		}
 	}
 
+	if wowCombatLogFile != "" {
+		writeWoWCombatLog(input, finalResult, startTime)
+	}
+	if logFile != "" {
+		if err := os.WriteFile(logFile, []byte(finalResult.Logs), 0666); err != nil {
+			log.Fatalf("failed to write log file: %s", err)
+		}
+	}
+
 	output, err = protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(finalResult)
 	if err != nil {
 		log.Fatalf("failed to marshal final results: %s", err)
@@ -68,3 +98,22 @@ func simMain(cmd *cobra.Command, args []string) {
 		}
 	}
 }
+
+func writeWoWCombatLog(input *proto.RaidSimRequest, result *proto.RaidSimResult, startTime time.Time) {
+	if result.Error != nil {
+		log.Fatalf("sim failed, not writing combat log: %s", result.Error.Message)
+	}
+
+	file, err := os.Create(wowCombatLogFile)
+	if err != nil {
+		log.Fatalf("failed to create combat log file: %s", err)
+	}
+	defer file.Close()
+
+	if err := combatlog.WriteWoWCombatLog(file, input, result, 0, startTime); err != nil {
+		log.Fatalf("failed to write combat log file: %s", err)
+	}
+	if verbose {
+		fmt.Printf("Wrote combat log file: `%s` successfully.\n", wowCombatLogFile)
+	}
+}
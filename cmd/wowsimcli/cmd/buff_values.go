@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	buffValuesPlayerIndex int32
+	buffValuesAll         bool
+	buffValuesFields      []string
+)
+
+var buffValuesCmd = &cobra.Command{
+	Use:   "buffvalues",
+	Short: "simulate what each consumable, world buff and raid buff is worth",
+	Long: `simulate what each consumable, world buff and raid buff is worth for a player.
+
+Each option is toggled one at a time from the settings in the input file, so buffs that are already
+on show how much is lost without them. By default only the options that are on are toggled, use
+--field to try every value of other fields. Writes the BuffValuesResult as JSON and prints a table
+ranked by DPS.`,
+	Run: buffValuesMain,
+}
+
+func init() {
+	buffValuesCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format)")
+	buffValuesCmd.Flags().StringVar(&outfile, "outfile", "", "location of JSON output file. If not set, the JSON is written to stdout and the table to stderr")
+	buffValuesCmd.Flags().Int32Var(&buffValuesPlayerIndex, "player", 0, "index of the player, counting through all parties in order")
+	buffValuesCmd.Flags().BoolVar(&buffValuesAll, "all", false, "also print options that don't change DPS or TPS")
+	buffValuesCmd.Flags().StringArrayVar(&buffValuesFields, "field", nil, "category and field to try every value of, e.g. Consumes.flask, can be repeated")
+	buffValuesCmd.MarkFlagRequired("infile")
+}
+
+func buffValuesMain(cmd *cobra.Command, args []string) {
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", infile, err)
+	}
+	input := &proto.RaidSimRequest{}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.BuffValuesAsync(&proto.BuffValuesRequest{RaidSimRequest: input, PlayerIndex: buffValuesPlayerIndex, Fields: buffValuesFields}, reporter, "cmd-buff-values")
+
+	startTime := time.Now()
+	var result *proto.BuffValuesResult
+	for status := range reporter {
+		if status.FinalBuffValuesResult != nil {
+			result = status.FinalBuffValuesResult
+			break
+		}
+		fmt.Fprint(os.Stderr, formatProgress(status, startTime))
+	}
+
+	if result == nil {
+		log.Fatalf("buff values finished without a result")
+	}
+	if result.Error != nil {
+		log.Fatalf("Failed: %s", result.Error.Message)
+	}
+
+	output, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(result)
+	if err != nil {
+		log.Fatalf("failed to marshal final results: %s", err)
+	}
+	writeResults(output, formatBuffValues(result, buffValuesAll))
+}
+
+func formatBuffValues(result *proto.BuffValuesResult, all bool) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "Base: %s DPS, %s TPS\n\n", formatStdev(result.BaseDps.GetAvg(), result.BaseDps.GetStdev()), formatStdev(result.BaseTps.GetAvg(), result.BaseTps.GetStdev()))
+	fmt.Fprintf(sb, "%-16s %-40s %-32s %24s %24s\n", "Category", "Field", "Value", "DPS Delta", "TPS Delta")
+	for _, value := range result.Values {
+		if !all && value.DpsDelta.GetMean() == 0 && value.TpsDelta.GetMean() == 0 {
+			continue
+		}
+		fmt.Fprintf(sb, "%-16s %-40s %-32s %24s %24s\n", value.Category, value.Field, value.Value,
+			formatStdev(value.DpsDelta.GetMean(), value.DpsDelta.GetStdev()), formatStdev(value.TpsDelta.GetMean(), value.TpsDelta.GetStdev()))
+	}
+	return sb.String()
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"log"
 	"os"
-	"strconv"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -24,17 +23,18 @@ var (
 var bulkCmd = &cobra.Command{
 	Use:   "bulk",
 	Short: "bulk simulate item replacements and combinations",
-	Long:  "bulk simulate item replacements and combinations",
+	Long:  "bulk simulate item replacements and combinations. Takes a BulkSimRequest, or a RaidSimRequest together with a --replacefile.",
 	Run:   bulkSimMain,
 }
 
 func init() {
-	bulkCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format)")
+	bulkCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (BulkSimRequest in protojson format, or RaidSimRequest if --replacefile is set)")
 	bulkCmd.Flags().StringVar(&replacefile, "replacefile", "", "location of replacement items file. Writes a CSV result of the items replaced instead of JSON")
 	bulkCmd.Flags().StringVar(&outfile, "output", "", "location of output file, defaults to stdout")
+	bulkCmd.Flags().StringVar(&outfile, "outfile", "", "same as --output, like the other commands")
 	bulkCmd.Flags().BoolVar(&verbose, "verbose", false, "print information during runtime")
+	bulkCmd.Flags().IntVar(&topCombos, "top", 20, "number of combos to list in the ranked table, 0 for all")
 	bulkCmd.MarkFlagRequired("infile")
-	bulkCmd.MarkFlagRequired("replacefile")
 }
 
 func bulkSimMain(cmd *cobra.Command, args []string) {
@@ -42,6 +42,10 @@ func bulkSimMain(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatalf("failed to load input json file %q: %v", infile, err)
 	}
+	if replacefile == "" {
+		bulkSimRequestMain(data)
+		return
+	}
 	input := &proto.RaidSimRequest{}
 
 	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
@@ -124,26 +128,7 @@ func BulkSim(input *proto.RaidSimRequest, replaceFile string, verbose bool) stri
 					}
 					lastTotal = status.TotalSims
 				}
-				compl := status.CompletedIterations
-				if compl == 0 {
-					break
-				}
-				elapsed := time.Since(startTime)
-				perDone := float64(compl) / float64(status.TotalIterations)
-				totalTime := time.Duration(float64(elapsed) / perDone)
-				var timeEst string
-				if totalTime.Hours() > 48 {
-					// use days
-					timeEst = fmt.Sprintf("Estimated Time: %0.1f / %0.1f days", elapsed.Hours()/24, totalTime.Hours()/24)
-				} else if totalTime.Minutes() > 120 {
-					// use hours
-					timeEst = fmt.Sprintf("Estimated Time: %0.1f / %0.1f hours", elapsed.Hours(), totalTime.Hours())
-				} else {
-					timeEst = fmt.Sprintf("Estimated Time: %0.1f / %0.1f minutes", elapsed.Minutes(), totalTime.Minutes())
-				}
-				totalStr := strconv.Itoa(int(status.TotalIterations))
-				fmtStr := "%" + strconv.Itoa(len(totalStr)) + ".f"
-				fmt.Printf("Sim Progress: "+fmtStr+" / %d | %s  (completed %d / %d)\n", float64(compl), status.TotalIterations, timeEst, status.CompletedSims, status.TotalSims)
+				fmt.Print(formatProgress(status, startTime))
 			}
 		case <-c:
 
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var topCombos int
+
+// Runs a full BulkSimRequest, as sent by the bulk tab in the UI.
+func bulkSimRequestMain(data []byte) {
+	input := &proto.BulkSimRequest{}
+	err := protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.RunBulkSimAsync(input, reporter, "cmd-bulk-sim")
+
+	startTime := time.Now()
+	var result *proto.BulkSimResult
+	var lastTotal int32
+	for status := range reporter {
+		if status.FinalBulkResult != nil {
+			result = status.FinalBulkResult
+			break
+		}
+		if lastTotal > status.TotalSims {
+			fmt.Fprintf(os.Stderr, "Refining results, running the best combos with more iterations...\n")
+		}
+		lastTotal = status.TotalSims
+		fmt.Fprint(os.Stderr, formatProgress(status, startTime))
+	}
+
+	if result == nil {
+		log.Fatalf("bulk sim finished without a result")
+	}
+	if result.Error != nil {
+		log.Fatalf("Failed: %s", result.Error.Message)
+	}
+
+	output, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(result)
+	if err != nil {
+		log.Fatalf("failed to marshal final results: %s", err)
+	}
+	writeResults(output, formatBulkResults(result, topCombos))
+}
+
+// Lists the top combos by DPS, compared to the currently equipped gear. With common random numbers,
+// the delta is the paired per-iteration difference, shown with its standard error.
+func formatBulkResults(result *proto.BulkSimResult, top int) string {
+	combos := slices.Clone(result.Results)
+	slices.SortStableFunc(combos, func(a, b *proto.BulkComboResult) int {
+		return cmp.Compare(b.UnitMetrics.GetDps().GetAvg(), a.UnitMetrics.GetDps().GetAvg())
+	})
+	if top > 0 && len(combos) > top {
+		combos = combos[:top]
+	}
+
+	baseDps := result.EquippedGearResult.GetUnitMetrics().GetDps().GetAvg()
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%-4s %20s %20s %8s  %s\n", "Rank", "DPS", "Delta", "Delta %", "Changes")
+	fmt.Fprintf(sb, "%-4s %20s %20s %8s  %s\n", "-", formatStdev(baseDps, result.EquippedGearResult.GetUnitMetrics().GetDps().GetStdev()), "", "", "[EQUIPPED]")
+	for i, combo := range combos {
+		dps := combo.UnitMetrics.GetDps()
+		delta := dps.GetAvg() - baseDps
+		deltaStr := fmt.Sprintf("%+0.2f", delta)
+		if combo.DpsDelta != nil {
+			delta = combo.DpsDelta.Mean
+			deltaStr = fmt.Sprintf("%+0.2f +/- %0.2f", delta, combo.DpsDelta.Stderr)
+		}
+		deltaPercent := 0.0
+		if baseDps != 0 {
+			deltaPercent = delta / baseDps * 100
+		}
+		fmt.Fprintf(sb, "%-4d %20s %20s %+7.2f%%  %s\n", i+1, formatStdev(dps.GetAvg(), dps.GetStdev()), deltaStr, deltaPercent, comboChanges(combo))
+	}
+	return sb.String()
+}
+
+func comboChanges(combo *proto.BulkComboResult) string {
+	var changes []string
+	if combo.TalentLoadout != nil {
+		name := combo.TalentLoadout.Name
+		if name == "" {
+			name = combo.TalentLoadout.TalentsString
+		}
+		changes = append(changes, "Talents: "+name)
+	}
+	for _, item := range combo.ItemsAdded {
+		name := fmt.Sprintf("%d", item.Item.GetId())
+		if dbItem, ok := core.ItemsByID[item.Item.GetId()]; ok {
+			name = dbItem.Name
+		}
+		changes = append(changes, fmt.Sprintf("%s@%s", name, item.Slot.String()))
+	}
+	if len(changes) == 0 {
+		return "[BASE RESULT]"
+	}
+	return strings.Join(changes, "; ")
+}
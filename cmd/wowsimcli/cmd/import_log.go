@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/combatlog"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	importOptions combatlog.ImportOptions
+	compareFile   string
+	comparePlayer int
+)
+
+var importLogCmd = &cobra.Command{
+	Use:   "importlog",
+	Short: "build a sim request from a WoWCombatLog.txt fight",
+	Long: "build a RaidSimRequest skeleton from a WoWCombatLog.txt segment with one pull of a boss: fight duration, boss preset, " +
+		"and raid buffs and debuffs that were up during the fight. Writes the request as JSON and prints the observed casts per minute. " +
+		"With --compare, also sims the given request and compares its casts per minute to the log.",
+	Run: importLogMain,
+}
+
+func init() {
+	importLogCmd.Flags().StringVar(&infile, "infile", "WoWCombatLog.txt", "location of the combat log")
+	importLogCmd.Flags().StringVar(&outfile, "outfile", "", "location of JSON output file. If not set, the JSON is written to stdout and the report to stderr")
+	importLogCmd.Flags().StringVar(&importOptions.Player, "player", "", "name or GUID of the player, needed if the log has several players")
+	importLogCmd.Flags().StringVar(&importOptions.Boss, "boss", "", "name, NPC id or GUID of the boss, defaults to the creature the player hit most")
+	importLogCmd.Flags().Float64Var(&importOptions.UptimeThreshold, "uptime", 0.5, "minimum uptime (0-1) for a buff or debuff to be enabled in the request")
+	importLogCmd.Flags().StringVar(&compareFile, "compare", "", "RaidSimRequest in protojson format to sim and compare with the log")
+	importLogCmd.Flags().IntVar(&comparePlayer, "compare-player", 0, "raid index of the player to compare in the --compare request")
+	importLogCmd.MarkFlagRequired("infile")
+}
+
+func importLogMain(cmd *cobra.Command, args []string) {
+	file, err := os.Open(infile)
+	if err != nil {
+		log.Fatalf("failed to open combat log %q: %v", infile, err)
+	}
+	defer file.Close()
+
+	imp, err := combatlog.ReadWoWCombatLog(file, importOptions)
+	if err != nil {
+		log.Fatalf("failed to import combat log: %s", err)
+	}
+
+	output, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(imp.Request)
+	if err != nil {
+		log.Fatalf("failed to marshal request: %s", err)
+	}
+
+	report := &strings.Builder{}
+	target := imp.Request.Encounter.Targets[0]
+	preset := "no preset, using default target stats"
+	if imp.PresetTarget {
+		preset = "preset target"
+	}
+	fmt.Fprintf(report, "%s vs %s (NPC %d, %s), %.1fs\n\n", imp.Request.Raid.Parties[0].Players[0].Name, target.Name, target.Id, preset, imp.Duration)
+	fmt.Fprint(report, formatUptimes("Buff uptimes", imp.BuffUptimes))
+	fmt.Fprint(report, formatUptimes("Debuff uptimes", imp.DebuffUptimes))
+
+	if compareFile == "" {
+		fmt.Fprintf(report, "%-28s %10s\n", "Action", "CPM")
+		observed := imp.CastsPerMinute().Casts
+		names := sortedKeys(observed)
+		slices.SortStableFunc(names, func(a, b string) int { return cmp.Compare(observed[b], observed[a]) })
+		for _, name := range names {
+			fmt.Fprintf(report, "%-28s %10.2f\n", name, observed[name])
+		}
+	} else {
+		simmed := simCastsPerMinute(compareFile, comparePlayer)
+		if err := combatlog.WriteCastsComparison(report, imp.CastsPerMinute(), simmed); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	writeResults(output, report.String())
+}
+
+func formatUptimes(title string, uptimes map[int32]float64) string {
+	if len(uptimes) == 0 {
+		return ""
+	}
+	spellIDs := make([]int32, 0, len(uptimes))
+	for spellID := range uptimes {
+		spellIDs = append(spellIDs, spellID)
+	}
+	slices.SortFunc(spellIDs, func(a, b int32) int {
+		return cmp.Or(cmp.Compare(uptimes[b], uptimes[a]), cmp.Compare(a, b))
+	})
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%s:\n", title)
+	for _, spellID := range spellIDs {
+		fmt.Fprintf(sb, "  %-26s %9.1f%%\n", core.ActionID{SpellID: spellID}, uptimes[spellID]*100)
+	}
+	fmt.Fprintln(sb)
+	return sb.String()
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func simCastsPerMinute(requestFile string, raidIndex int) *proto.CastsTestResult {
+	data, err := os.ReadFile(requestFile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", requestFile, err)
+	}
+	input := &proto.RaidSimRequest{}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, input); err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.RunRaidSimConcurrentAsync(input, reporter, "cmd-import-log")
+
+	startTime := time.Now()
+	var result *proto.RaidSimResult
+	for status := range reporter {
+		if status.FinalRaidResult != nil {
+			result = status.FinalRaidResult
+			break
+		}
+		fmt.Fprint(os.Stderr, formatProgress(status, startTime))
+	}
+	if result == nil {
+		log.Fatalf("sim finished without a result")
+	}
+	if result.Error != nil {
+		log.Fatalf("Failed: %s", result.Error.Message)
+	}
+
+	casts, err := combatlog.SimCastsPerMinute(result, raidIndex)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return casts
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var optimizeAPLCmd = &cobra.Command{
+	Use:   "optimizeapl",
+	Short: "search the tunable constants of a rotation for the best values",
+	Long: `search the tunable constants of a rotation for the best values.
+
+Constants are marked as tunable with a range, e.g. const("10%", {min=0, max=50}) in the APL
+text syntax. Writes the APLOptimizerResult as JSON and prints the optimized rotation along with
+the DPS at points across each tunable's range.`,
+	Run: optimizeAPLMain,
+}
+
+func init() {
+	optimizeAPLCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (APLOptimizerRequest in protojson format)")
+	optimizeAPLCmd.Flags().StringVar(&outfile, "outfile", "", "location of JSON output file. If not set, the JSON is written to stdout and the table to stderr")
+	optimizeAPLCmd.MarkFlagRequired("infile")
+}
+
+func optimizeAPLMain(cmd *cobra.Command, args []string) {
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", infile, err)
+	}
+	input := &proto.APLOptimizerRequest{}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	result := core.OptimizeAPL(input)
+	if result.Error != nil {
+		log.Fatalf("Failed: %s", result.Error.Message)
+	}
+
+	output, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(result)
+	if err != nil {
+		log.Fatalf("failed to marshal final results: %s", err)
+	}
+	writeResults(output, formatAPLOptimizerResult(result))
+}
+
+func formatAPLOptimizerResult(result *proto.APLOptimizerResult) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "DPS %0.2f -> %0.2f after %d sims\n", result.InitialDps, result.BestDps, result.SimsRun)
+	for _, tunable := range result.Tunables {
+		fmt.Fprintf(sb, "\n%s: %s -> %s\n", tunable.Name, tunable.InitialVal, tunable.BestVal)
+		fmt.Fprintf(sb, "%12s %12s %20s\n", "Value", "DPS", "Delta")
+		for _, sample := range tunable.Sensitivity {
+			deltaStr := ""
+			if sample.DpsDelta != nil {
+				deltaStr = fmt.Sprintf("%+0.2f +/- %0.2f", sample.DpsDelta.Mean, sample.DpsDelta.Stderr)
+			}
+			fmt.Fprintf(sb, "%12s %12.2f %20s\n", sample.Val, sample.Dps, deltaStr)
+		}
+	}
+
+	if text, err := apltext.Format(result.Rotation); err == nil {
+		fmt.Fprintf(sb, "\n%s", text)
+	}
+	return sb.String()
+}
@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var optimizeGearCmd = &cobra.Command{
+	Use:   "optimizegear",
+	Short: "search candidate items and enchants for the best equipment sets",
+	Long: `search candidate items and enchants for the best equipment sets.
+
+Unlike bulk, which sims every combination, candidates are pruned by stat weights and combined by a
+guided search, so many more items can be considered. Writes the GearOptimizerResult as JSON and
+prints the best sets with the items they change.`,
+	Run: optimizeGearMain,
+}
+
+func init() {
+	optimizeGearCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (GearOptimizerRequest in protojson format)")
+	optimizeGearCmd.Flags().StringVar(&outfile, "outfile", "", "location of JSON output file. If not set, the JSON is written to stdout and the table to stderr")
+	optimizeGearCmd.MarkFlagRequired("infile")
+}
+
+func optimizeGearMain(cmd *cobra.Command, args []string) {
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", infile, err)
+	}
+	input := &proto.GearOptimizerRequest{}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.OptimizeGearAsync(input, reporter, "cmd-optimize-gear")
+
+	startTime := time.Now()
+	var result *proto.GearOptimizerResult
+	for status := range reporter {
+		if status.FinalGearResult != nil {
+			result = status.FinalGearResult
+			break
+		}
+		fmt.Fprint(os.Stderr, formatProgress(status, startTime))
+	}
+
+	if result == nil {
+		log.Fatalf("gear optimizer finished without a result")
+	}
+	if result.Error != nil {
+		log.Fatalf("Failed: %s", result.Error.Message)
+	}
+
+	output, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(result)
+	if err != nil {
+		log.Fatalf("failed to marshal final results: %s", err)
+	}
+	writeResults(output, formatGearOptimizerResult(result))
+}
+
+func formatGearOptimizerResult(result *proto.GearOptimizerResult) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "Equipped: %s DPS after %d sims\n", formatStdev(result.EquippedGearResult.GetUnitMetrics().GetDps().GetAvg(), result.EquippedGearResult.GetUnitMetrics().GetDps().GetStdev()), result.SimsRun)
+	for i, set := range result.Results {
+		dps := set.UnitMetrics.GetDps()
+		fmt.Fprintf(sb, "\n#%d: %s DPS (%+0.2f +/- %0.2f)\n", i+1, formatStdev(dps.GetAvg(), dps.GetStdev()), set.DpsDelta.GetMean(), set.DpsDelta.GetStderr())
+		for _, change := range set.ItemsChanged {
+			name := core.ItemsByID[change.Item.Id].Name
+			if change.Item.Id == 0 {
+				name = "(empty)"
+			}
+			fmt.Fprintf(sb, "  %-20s %s (%d)", strings.TrimPrefix(change.Slot.String(), "ItemSlot"), name, change.Item.Id)
+			if change.Item.Enchant != 0 {
+				fmt.Fprintf(sb, " enchant %d", change.Item.Enchant)
+			}
+			fmt.Fprintln(sb)
+		}
+	}
+	return sb.String()
+}
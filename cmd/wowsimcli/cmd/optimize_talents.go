@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var talentTreesFile string
+
+var optimizeTalentsCmd = &cobra.Command{
+	Use:   "optimizetalents",
+	Short: "search talent builds around the current talents for the best ones",
+	Long: `search talent builds around the current talents for the best ones.
+
+The layout of the class's talent trees is needed to tell valid builds apart. It can be given in the
+input file, or with --trees pointing at one of the files in ui/core/talents/trees. Writes the
+TalentOptimizerResult as JSON and prints the best builds.`,
+	Run: optimizeTalentsMain,
+}
+
+func init() {
+	optimizeTalentsCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (TalentOptimizerRequest in protojson format)")
+	optimizeTalentsCmd.Flags().StringVar(&outfile, "outfile", "", "location of JSON output file. If not set, the JSON is written to stdout and the table to stderr")
+	optimizeTalentsCmd.Flags().StringVar(&talentTreesFile, "trees", "", "location of the class's talent trees, e.g. ui/core/talents/trees/warlock.json")
+	optimizeTalentsCmd.MarkFlagRequired("infile")
+}
+
+func optimizeTalentsMain(cmd *cobra.Command, args []string) {
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", infile, err)
+	}
+	input := &proto.TalentOptimizerRequest{}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	if talentTreesFile != "" {
+		treesData, err := os.ReadFile(talentTreesFile)
+		if err != nil {
+			log.Fatalf("failed to load talent trees file %q: %v", talentTreesFile, err)
+		}
+		if input.Trees, err = core.TalentTreesFromJsonString(string(treesData)); err != nil {
+			log.Fatalf("failed to load talent trees file: %s", err)
+		}
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.OptimizeTalentsAsync(input, reporter, "cmd-optimize-talents")
+
+	startTime := time.Now()
+	var result *proto.TalentOptimizerResult
+	for status := range reporter {
+		if status.FinalTalentResult != nil {
+			result = status.FinalTalentResult
+			break
+		}
+		fmt.Fprint(os.Stderr, formatProgress(status, startTime))
+	}
+
+	if result == nil {
+		log.Fatalf("talent optimizer finished without a result")
+	}
+	if result.Error != nil {
+		log.Fatalf("Failed: %s", result.Error.Message)
+	}
+
+	output, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(result)
+	if err != nil {
+		log.Fatalf("failed to marshal final results: %s", err)
+	}
+	writeResults(output, formatTalentOptimizerResult(result))
+}
+
+func formatTalentOptimizerResult(result *proto.TalentOptimizerResult) string {
+	sb := &strings.Builder{}
+	current := result.CurrentTalentsResult
+	fmt.Fprintf(sb, "Current: %s %s DPS after %d sims\n\n", current.GetTalentsString(), formatStdev(current.GetUnitMetrics().GetDps().GetAvg(), current.GetUnitMetrics().GetDps().GetStdev()), result.SimsRun)
+	fmt.Fprintf(sb, "%-40s %20s %20s\n", "Talents", "DPS", "Delta")
+	for _, build := range result.Results {
+		dps := build.UnitMetrics.GetDps()
+		deltaStr := fmt.Sprintf("%+0.2f +/- %0.2f", build.DpsDelta.GetMean(), build.DpsDelta.GetStderr())
+		fmt.Fprintf(sb, "%-40s %20s %20s\n", build.TalentsString, formatStdev(dps.GetAvg(), dps.GetStdev()), deltaStr)
+	}
+	return sb.String()
+}
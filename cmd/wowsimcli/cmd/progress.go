@@ -0,0 +1,34 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+// Formats a progress update with an estimate of the total run time. Returns an empty string while
+// no iterations are done yet, as there is nothing to estimate from.
+func formatProgress(status *proto.ProgressMetrics, startTime time.Time) string {
+	compl := status.CompletedIterations
+	if compl == 0 {
+		return ""
+	}
+	elapsed := time.Since(startTime)
+	perDone := float64(compl) / float64(status.TotalIterations)
+	totalTime := time.Duration(float64(elapsed) / perDone)
+	var timeEst string
+	if totalTime.Hours() > 48 {
+		// use days
+		timeEst = fmt.Sprintf("Estimated Time: %0.1f / %0.1f days", elapsed.Hours()/24, totalTime.Hours()/24)
+	} else if totalTime.Minutes() > 120 {
+		// use hours
+		timeEst = fmt.Sprintf("Estimated Time: %0.1f / %0.1f hours", elapsed.Hours(), totalTime.Hours())
+	} else {
+		timeEst = fmt.Sprintf("Estimated Time: %0.1f / %0.1f minutes", elapsed.Minutes(), totalTime.Minutes())
+	}
+	totalStr := strconv.Itoa(int(status.TotalIterations))
+	fmtStr := "%" + strconv.Itoa(len(totalStr)) + ".f"
+	return fmt.Sprintf("Sim Progress: "+fmtStr+" / %d | %s  (completed %d / %d)\n", float64(compl), status.TotalIterations, timeEst, status.CompletedSims, status.TotalSims)
+}
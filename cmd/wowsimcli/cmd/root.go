@@ -17,6 +17,15 @@ func Execute(version string) {
 	rootCmd.AddCommand(newVersionCommand(version))
 	rootCmd.AddCommand(simCmd)
 	rootCmd.AddCommand(bulkCmd)
+	rootCmd.AddCommand(statWeightsCmd)
+	rootCmd.AddCommand(statScalingCmd)
+	rootCmd.AddCommand(sweepCmd)
+	rootCmd.AddCommand(importLogCmd)
+	rootCmd.AddCommand(aplCmd)
+	rootCmd.AddCommand(optimizeAPLCmd)
+	rootCmd.AddCommand(optimizeGearCmd)
+	rootCmd.AddCommand(optimizeTalentsCmd)
+	rootCmd.AddCommand(buffValuesCmd)
 	rootCmd.AddCommand(decodeLinkCmd)
 
 	if err := rootCmd.Execute(); err != nil {
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var (
+	scalingStat  string
+	scalingStep  float64
+	scalingSteps int32
+)
+
+var statScalingCmd = &cobra.Command{
+	Use:   "statscaling",
+	Short: "simulate DPS with increasing amounts of one stat",
+	Long: `simulate DPS with increasing amounts of one stat, e.g. to see where the hit cap is.
+
+Writes the StatScalingResult as JSON and prints a table of DPS and TPS per step. The stat, step
+size and number of steps can be set in the input file or overridden with flags.`,
+	Run: statScalingMain,
+}
+
+func init() {
+	statScalingCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (StatScalingRequest in protojson format)")
+	statScalingCmd.Flags().StringVar(&outfile, "outfile", "", "location of JSON output file. If not set, the JSON is written to stdout and the table to stderr")
+	statScalingCmd.Flags().StringVar(&scalingStat, "stat", "", "stat or pseudo stat to scale, e.g. SpellHit or StatSpellHit")
+	statScalingCmd.Flags().Float64Var(&scalingStep, "step", 0, "amount of the stat added per step")
+	statScalingCmd.Flags().Int32Var(&scalingSteps, "steps", 0, "number of steps after the current value")
+	statScalingCmd.MarkFlagRequired("infile")
+}
+
+func statScalingMain(cmd *cobra.Command, args []string) {
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", infile, err)
+	}
+	input := &proto.StatScalingRequest{}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	if scalingStat != "" {
+		if err := setScalingStat(input, scalingStat); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if scalingStep != 0 {
+		input.Step = scalingStep
+	}
+	if scalingSteps != 0 {
+		input.NumSteps = scalingSteps
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.StatScalingAsync(input, reporter, "cmd-stat-scaling")
+
+	startTime := time.Now()
+	var result *proto.StatScalingResult
+	for status := range reporter {
+		if status.FinalScalingResult != nil {
+			result = status.FinalScalingResult
+			break
+		}
+		fmt.Fprint(os.Stderr, formatProgress(status, startTime))
+	}
+
+	if result == nil {
+		log.Fatalf("stat scaling finished without a result")
+	}
+	if result.Error != nil {
+		log.Fatalf("Failed: %s", result.Error.Message)
+	}
+
+	output, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(result)
+	if err != nil {
+		log.Fatalf("failed to marshal final results: %s", err)
+	}
+	writeResults(output, formatStatScaling(input, result))
+}
+
+// Accepts stat names with or without their Stat or PseudoStat prefix.
+func setScalingStat(request *proto.StatScalingRequest, name string) error {
+	if stat, ok := proto.Stat_value["Stat"+strings.TrimPrefix(name, "Stat")]; ok {
+		request.UnitStat = &proto.StatScalingRequest_Stat{Stat: proto.Stat(stat)}
+		return nil
+	}
+	if pseudoStat, ok := proto.PseudoStat_value["PseudoStat"+strings.TrimPrefix(name, "PseudoStat")]; ok {
+		request.UnitStat = &proto.StatScalingRequest_PseudoStat{PseudoStat: proto.PseudoStat(pseudoStat)}
+		return nil
+	}
+	return fmt.Errorf("unknown stat %q", name)
+}
+
+// Amounts are added as bonus stats, before stat multipliers, so they're listed separately from the
+// final value of the stat the player starts with.
+func formatStatScaling(request *proto.StatScalingRequest, result *proto.StatScalingResult) string {
+	sb := &strings.Builder{}
+	if _, ok := request.UnitStat.(*proto.StatScalingRequest_Stat); ok {
+		fmt.Fprintf(sb, "Base value: %0.2f\n", result.BaseValue)
+	}
+	fmt.Fprintf(sb, "%10s %20s %20s %20s\n", "Added", "DPS", "Delta", "TPS")
+	for _, point := range result.Points {
+		deltaStr := ""
+		if point.DpsDelta != nil {
+			deltaStr = fmt.Sprintf("%+0.2f +/- %0.2f", point.DpsDelta.Mean, point.DpsDelta.Stderr)
+		}
+		fmt.Fprintf(sb, "%10.2f %20s %20s %20s\n", point.AmountAdded,
+			formatStdev(point.Dps.GetAvg(), point.Dps.GetStdev()), deltaStr, formatStdev(point.Tps.GetAvg(), point.Tps.GetStdev()))
+	}
+	return sb.String()
+}
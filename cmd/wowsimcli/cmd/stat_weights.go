@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/stats"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var weightsMetric string
+
+var statWeightsCmd = &cobra.Command{
+	Use:   "statweights",
+	Short: "calculate stat weights and EP values",
+	Long:  "calculate stat weights and EP values. Writes the StatWeightsResult as JSON and prints a table of EP values ranked from best to worst.",
+	Run:   statWeightsMain,
+}
+
+func init() {
+	statWeightsCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (StatWeightsRequest in protojson format)")
+	statWeightsCmd.Flags().StringVar(&outfile, "outfile", "", "location of JSON output file. If not set, the JSON is written to stdout and the table to stderr")
+	statWeightsCmd.Flags().StringVar(&weightsMetric, "metric", "dps", "metric to rank stats by in the table (dps, hps, tps, dtps, tmi, pdeath)")
+	statWeightsCmd.MarkFlagRequired("infile")
+}
+
+func statWeightsMain(cmd *cobra.Command, args []string) {
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", infile, err)
+	}
+	input := &proto.StatWeightsRequest{}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	// Checked up front so a typo doesn't only show up after a long run.
+	if _, err := statWeightValues(&proto.StatWeightsResult{}, weightsMetric); err != nil {
+		log.Fatal(err)
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.StatWeightsAsync(input, reporter, "cmd-stat-weights")
+
+	startTime := time.Now()
+	var result *proto.StatWeightsResult
+	for status := range reporter {
+		if status.FinalWeightResult != nil {
+			result = status.FinalWeightResult
+			break
+		}
+		fmt.Fprint(os.Stderr, formatProgress(status, startTime))
+	}
+
+	if result == nil {
+		log.Fatalf("stat weights finished without a result")
+	}
+	if result.Error != nil {
+		log.Fatalf("Failed: %s", result.Error.Message)
+	}
+
+	output, err := protojson.MarshalOptions{EmitUnpopulated: true}.Marshal(result)
+	if err != nil {
+		log.Fatalf("failed to marshal final results: %s", err)
+	}
+
+	values, _ := statWeightValues(result, weightsMetric)
+	table := formatStatWeights(input, values)
+	if len(result.RegressionFits) > 0 {
+		table += formatRegressionFits(result)
+	}
+	writeResults(output, table)
+}
+
+// Writes JSON output to --outfile, with the table on stdout. Without an output file, the JSON goes
+// to stdout and the table to stderr so the output can still be piped.
+func writeResults(output []byte, table string) {
+	if outfile == "" {
+		fmt.Println(string(output))
+		fmt.Fprint(os.Stderr, table)
+		return
+	}
+	if err := os.WriteFile(outfile, output, 0666); err != nil {
+		log.Fatalf("failed to write output file:: %s", err)
+	}
+	fmt.Print(table)
+}
+
+func statWeightValues(result *proto.StatWeightsResult, metric string) (*proto.StatWeightValues, error) {
+	switch strings.ToLower(metric) {
+	case "dps":
+		return result.Dps, nil
+	case "hps":
+		return result.Hps, nil
+	case "tps":
+		return result.Tps, nil
+	case "dtps":
+		return result.Dtps, nil
+	case "tmi":
+		return result.Tmi, nil
+	case "pdeath":
+		return result.PDeath, nil
+	}
+	return nil, fmt.Errorf("unknown stat weights metric %q", metric)
+}
+
+type statWeightRow struct {
+	name        string
+	weight      float64
+	weightStdev float64
+	ep          float64
+	epStdev     float64
+}
+
+// Lists the weighed stats with the highest EP first.
+func formatStatWeights(request *proto.StatWeightsRequest, values *proto.StatWeightValues) string {
+	var rows []statWeightRow
+	for _, stat := range request.StatsToWeigh {
+		rows = append(rows, statWeightRow{
+			name:        stats.Stat(stat).StatName(),
+			weight:      valueAt(values.GetWeights().GetStats(), int(stat)),
+			weightStdev: valueAt(values.GetWeightsStdev().GetStats(), int(stat)),
+			ep:          valueAt(values.GetEpValues().GetStats(), int(stat)),
+			epStdev:     valueAt(values.GetEpValuesStdev().GetStats(), int(stat)),
+		})
+	}
+	for _, pseudoStat := range request.PseudoStatsToWeigh {
+		rows = append(rows, statWeightRow{
+			name:        strings.TrimPrefix(pseudoStat.String(), "PseudoStat"),
+			weight:      valueAt(values.GetWeights().GetPseudoStats(), int(pseudoStat)),
+			weightStdev: valueAt(values.GetWeightsStdev().GetPseudoStats(), int(pseudoStat)),
+			ep:          valueAt(values.GetEpValues().GetPseudoStats(), int(pseudoStat)),
+			epStdev:     valueAt(values.GetEpValuesStdev().GetPseudoStats(), int(pseudoStat)),
+		})
+	}
+	slices.SortStableFunc(rows, func(a, b statWeightRow) int {
+		return cmp.Compare(b.ep, a.ep)
+	})
+
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "%-4s %-24s %20s %20s\n", "Rank", "Stat", "EP", "Weight")
+	for i, row := range rows {
+		fmt.Fprintf(sb, "%-4d %-24s %20s %20s\n", i+1, row.name, formatStdev(row.ep, row.epStdev), formatStdev(row.weight, row.weightStdev))
+	}
+	return sb.String()
+}
+
+// Lists the DPS fit of each stat when using regression, flagging stats whose weight isn't constant
+// within the sampled radius.
+func formatRegressionFits(result *proto.StatWeightsResult) string {
+	sb := &strings.Builder{}
+	fmt.Fprintf(sb, "\nRegression fit (R^2 %0.3f)\n", result.RegressionRSquared)
+	fmt.Fprintf(sb, "%-24s %24s %20s\n", "Stat", "DPS weight 95% CI", "Quadratic")
+	for _, fit := range result.RegressionFits {
+		unitStat := stats.UnitStatFromIdx(int(fit.UnitStat))
+		var name string
+		if unitStat.IsStat() {
+			name = stats.Stat(unitStat.StatIdx()).StatName()
+		} else {
+			name = strings.TrimPrefix(proto.PseudoStat(unitStat.PseudoStatIdx()).String(), "PseudoStat")
+		}
+		quadratic := fmt.Sprintf("%0.4f +/- %0.4f", fit.Quadratic, fit.QuadraticStderr)
+		if fit.Nonlinear {
+			quadratic += " (nonlinear)"
+		}
+		fmt.Fprintf(sb, "%-24s %24s %20s\n", name, fmt.Sprintf("[%0.3f, %0.3f]", fit.WeightCiLow, fit.WeightCiHigh), quadratic)
+	}
+	return sb.String()
+}
+
+func formatStdev(value float64, stdev float64) string {
+	return fmt.Sprintf("%0.2f +/- %0.2f", value, stdev)
+}
+
+func valueAt(values []float64, idx int) float64 {
+	if idx < 0 || idx >= len(values) {
+		return 0
+	}
+	return values[idx]
+}
@@ -0,0 +1,427 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protojson"
+	goproto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+var (
+	sweepParams []string
+	sweepFormat string
+	sweepPlayer int
+)
+
+var sweepCmd = &cobra.Command{
+	Use:   "sweep",
+	Short: "simulate a grid of encounter, player or sim option values",
+	Long: `simulate a grid of encounter, player or sim option values.
+
+Each --param takes a field path into the RaidSimRequest and the values to try, as
+  path=start:end[:step]  e.g. encounter.duration=60:300:60
+  path=a,b,c             e.g. player.race=RaceHuman,RaceGnome
+Paths use protobuf field names separated by dots, with indexes for repeated fields
+(raid.parties.0.players.0.distance_from_target). 'player' is short for the first player
+of the raid. Setting a repeated message field to a number resizes it, copying the last
+element, so encounter.targets=1:4 sweeps over the number of targets.
+
+Every combination of the given values is simmed, and DPS, TPS, DTPS and HPS of the chosen
+player are written per grid point.`,
+	Run: sweepMain,
+}
+
+func init() {
+	sweepCmd.Flags().StringVar(&infile, "infile", "input.json", "location of input file (RaidSimRequest in protojson format)")
+	sweepCmd.Flags().StringVar(&outfile, "outfile", "", "location of output file, defaults to stdout")
+	sweepCmd.Flags().StringArrayVar(&sweepParams, "param", nil, "field path and values to sweep over, can be repeated")
+	sweepCmd.Flags().StringVar(&sweepFormat, "format", "csv", "output format (csv or json)")
+	sweepCmd.Flags().IntVar(&sweepPlayer, "player", 0, "index of the player to report metrics for, counting through all parties in order")
+	sweepCmd.MarkFlagRequired("infile")
+	sweepCmd.MarkFlagRequired("param")
+}
+
+type sweepParam struct {
+	path   string
+	values []string
+}
+
+type sweepMetric struct {
+	Avg   float64 `json:"avg"`
+	Stdev float64 `json:"stdev"`
+}
+
+type sweepPoint struct {
+	Params map[string]string `json:"params"`
+	Dps    sweepMetric       `json:"dps"`
+	Tps    sweepMetric       `json:"tps"`
+	Dtps   sweepMetric       `json:"dtps"`
+	Hps    sweepMetric       `json:"hps"`
+	Error  string            `json:"error,omitempty"`
+
+	values []string
+}
+
+func sweepMain(cmd *cobra.Command, args []string) {
+	if sweepFormat != "csv" && sweepFormat != "json" {
+		log.Fatalf("unknown output format %q", sweepFormat)
+	}
+
+	data, err := os.ReadFile(infile)
+	if err != nil {
+		log.Fatalf("failed to load input json file %q: %v", infile, err)
+	}
+	input := &proto.RaidSimRequest{}
+
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(data, input)
+	if err != nil {
+		log.Fatalf("failed to load input json file: %s", err)
+	}
+
+	params := make([]sweepParam, len(sweepParams))
+	for i, arg := range sweepParams {
+		params[i], err = parseSweepParam(arg)
+		if err != nil {
+			log.Fatalf("invalid --param %q: %s", arg, err)
+		}
+	}
+	// Catch bad paths and values before spending time on the first sims.
+	requests, values, err := sweepRequests(input, params)
+	if err != nil {
+		log.Fatalf("invalid --param: %s", err)
+	}
+
+	points := make([]*sweepPoint, len(requests))
+	for i, request := range requests {
+		point := &sweepPoint{Params: map[string]string{}, values: values[i]}
+		for j, param := range params {
+			point.Params[param.path] = values[i][j]
+		}
+
+		fmt.Fprintf(os.Stderr, "Sweep point %d / %d: %s\n", i+1, len(requests), formatSweepValues(params, point.values))
+		fillSweepPoint(point, core.RunRaidSimConcurrent(request), sweepPlayer)
+		points[i] = point
+	}
+
+	var out io.Writer = os.Stdout
+	if outfile != "" {
+		file, err := os.Create(outfile)
+		if err != nil {
+			log.Fatalf("failed to create output file: %s", err)
+		}
+		defer file.Close()
+		out = file
+	}
+
+	if sweepFormat == "json" {
+		err = writeSweepJSON(out, points)
+	} else {
+		err = writeSweepCSV(out, params, points)
+	}
+	if err != nil {
+		log.Fatalf("failed to write output: %s", err)
+	}
+}
+
+// Builds the request and param values of every grid point. Params are applied in order, so a
+// param can index into a list resized by an earlier one.
+func sweepRequests(input *proto.RaidSimRequest, params []sweepParam) ([]*proto.RaidSimRequest, [][]string, error) {
+	numPoints := 1
+	for _, param := range params {
+		numPoints *= len(param.values)
+	}
+
+	requests := make([]*proto.RaidSimRequest, 0, numPoints)
+	values := make([][]string, 0, numPoints)
+	indexes := make([]int, len(params))
+	for len(requests) < numPoints {
+		request := goproto.Clone(input).(*proto.RaidSimRequest)
+		pointValues := make([]string, len(params))
+		for i, param := range params {
+			pointValues[i] = param.values[indexes[i]]
+			if err := setFieldByPath(request.ProtoReflect(), param.path, pointValues[i]); err != nil {
+				return nil, nil, fmt.Errorf("%s at %s: %w", param.path, formatSweepValues(params[:i+1], pointValues), err)
+			}
+		}
+		requests = append(requests, request)
+		values = append(values, pointValues)
+
+		// Advance the grid like an odometer, with the last param changing fastest.
+		for i := len(indexes) - 1; i >= 0; i-- {
+			indexes[i]++
+			if indexes[i] < len(params[i].values) {
+				break
+			}
+			indexes[i] = 0
+		}
+	}
+	return requests, values, nil
+}
+
+func formatSweepValues(params []sweepParam, values []string) string {
+	parts := make([]string, len(params))
+	for i, param := range params {
+		parts[i] = param.path + "=" + values[i]
+	}
+	return strings.Join(parts, ", ")
+}
+
+func fillSweepPoint(point *sweepPoint, result *proto.RaidSimResult, playerIdx int) {
+	if result.Error != nil {
+		point.Error = result.Error.Message
+		return
+	}
+
+	var players []*proto.UnitMetrics
+	for _, party := range result.RaidMetrics.GetParties() {
+		players = append(players, party.Players...)
+	}
+	if playerIdx < 0 || playerIdx >= len(players) {
+		point.Error = fmt.Sprintf("no player with index %d, found %d", playerIdx, len(players))
+		return
+	}
+
+	unit := players[playerIdx]
+	point.Dps = sweepMetric{Avg: unit.Dps.GetAvg(), Stdev: unit.Dps.GetStdev()}
+	point.Tps = sweepMetric{Avg: unit.Threat.GetAvg(), Stdev: unit.Threat.GetStdev()}
+	point.Dtps = sweepMetric{Avg: unit.Dtps.GetAvg(), Stdev: unit.Dtps.GetStdev()}
+	point.Hps = sweepMetric{Avg: unit.Hps.GetAvg(), Stdev: unit.Hps.GetStdev()}
+}
+
+func writeSweepJSON(out io.Writer, points []*sweepPoint) error {
+	encoder := json.NewEncoder(out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(points)
+}
+
+func writeSweepCSV(out io.Writer, params []sweepParam, points []*sweepPoint) error {
+	w := csv.NewWriter(out)
+	header := []string{}
+	for _, param := range params {
+		header = append(header, param.path)
+	}
+	header = append(header, "dps_avg", "dps_stdev", "tps_avg", "tps_stdev", "dtps_avg", "dtps_stdev", "hps_avg", "hps_stdev", "error")
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	formatFloat := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, point := range points {
+		row := append([]string{}, point.values...)
+		for _, metric := range []sweepMetric{point.Dps, point.Tps, point.Dtps, point.Hps} {
+			row = append(row, formatFloat(metric.Avg), formatFloat(metric.Stdev))
+		}
+		row = append(row, point.Error)
+		if err := w.Write(row); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+// Parses 'path=start:end[:step]' or 'path=a,b,c'.
+func parseSweepParam(arg string) (sweepParam, error) {
+	path, spec, ok := strings.Cut(arg, "=")
+	if !ok || path == "" || spec == "" {
+		return sweepParam{}, fmt.Errorf("expected path=values")
+	}
+
+	if !strings.Contains(spec, ":") {
+		return sweepParam{path: path, values: strings.Split(spec, ",")}, nil
+	}
+
+	bounds := strings.Split(spec, ":")
+	if len(bounds) > 3 {
+		return sweepParam{}, fmt.Errorf("expected start:end[:step]")
+	}
+	nums := []float64{0, 0, 1}
+	for i, bound := range bounds {
+		num, err := strconv.ParseFloat(bound, 64)
+		if err != nil {
+			return sweepParam{}, fmt.Errorf("invalid range bound %q", bound)
+		}
+		nums[i] = num
+	}
+	start, end, step := nums[0], nums[1], nums[2]
+	if step == 0 || (end-start)/step < 0 {
+		return sweepParam{}, fmt.Errorf("step %g never gets from %g to %g", step, start, end)
+	}
+
+	// Small epsilon so float steps like 0.1 don't miss the end value.
+	numValues := int(math.Floor((end-start)/step+1e-9)) + 1
+	values := make([]string, numValues)
+	for i := range values {
+		// Rounded so float steps give 0.3 rather than 0.30000000000000004.
+		value := math.Round((start+float64(i)*step)*1e9) / 1e9
+		values[i] = strconv.FormatFloat(value, 'f', -1, 64)
+	}
+	return sweepParam{path: path, values: values}, nil
+}
+
+// Sets the field at a dot separated path to value. Repeated fields are indexed with a number
+// segment, and setting a repeated message field itself resizes it.
+func setFieldByPath(msg protoreflect.Message, path string, value string) error {
+	segments := strings.Split(path, ".")
+	if segments[0] == "player" {
+		segments = append([]string{"raid", "parties", "0", "players", "0"}, segments[1:]...)
+	}
+
+	for i := 0; i < len(segments); i++ {
+		fields := msg.Descriptor().Fields()
+		fd := fields.ByName(protoreflect.Name(segments[i]))
+		if fd == nil {
+			fd = fields.ByJSONName(segments[i])
+		}
+		if fd == nil {
+			return fmt.Errorf("%s has no field %q", msg.Descriptor().Name(), segments[i])
+		}
+		if fd.IsMap() {
+			return fmt.Errorf("map field %q is not supported", fd.Name())
+		}
+
+		if fd.IsList() {
+			list := msg.Mutable(fd).List()
+			if i == len(segments)-1 {
+				if fd.Message() == nil {
+					return fmt.Errorf("repeated field %q needs an index", fd.Name())
+				}
+				return resizeList(list, value)
+			}
+
+			i++
+			idx, err := strconv.Atoi(segments[i])
+			if err != nil || idx < 0 || idx >= list.Len() {
+				return fmt.Errorf("invalid index %q for %q with %d elements", segments[i], fd.Name(), list.Len())
+			}
+			if fd.Message() == nil {
+				if i != len(segments)-1 {
+					return fmt.Errorf("%q has no fields", fd.Name())
+				}
+				v, err := parseFieldValue(fd, value)
+				if err != nil {
+					return err
+				}
+				list.Set(idx, v)
+				return nil
+			}
+			msg = list.Get(idx).Message()
+			continue
+		}
+
+		if fd.Message() != nil {
+			if i == len(segments)-1 {
+				return fmt.Errorf("%q is a message, not a value", fd.Name())
+			}
+			msg = msg.Mutable(fd).Message()
+			continue
+		}
+
+		if i != len(segments)-1 {
+			return fmt.Errorf("%q has no fields", fd.Name())
+		}
+		v, err := parseFieldValue(fd, value)
+		if err != nil {
+			return err
+		}
+		msg.Set(fd, v)
+		return nil
+	}
+	return fmt.Errorf("empty path")
+}
+
+// Truncates the list or grows it with copies of its last element.
+func resizeList(list protoreflect.List, value string) error {
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		return fmt.Errorf("invalid list size %q", value)
+	}
+	if size < list.Len() {
+		list.Truncate(size)
+		return nil
+	}
+	for list.Len() < size {
+		if list.Len() == 0 {
+			list.Append(list.NewElement())
+			continue
+		}
+		last := list.Get(list.Len() - 1).Message().Interface()
+		list.Append(protoreflect.ValueOfMessage(goproto.Clone(last).ProtoReflect()))
+	}
+	return nil
+}
+
+func parseFieldValue(fd protoreflect.FieldDescriptor, value string) (protoreflect.Value, error) {
+	invalid := func(err error) (protoreflect.Value, error) {
+		return protoreflect.Value{}, fmt.Errorf("invalid value %q for %q: %w", value, fd.Name(), err)
+	}
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfBool(v), nil
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfInt32(int32(v)), nil
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		v, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfInt64(v), nil
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		v, err := strconv.ParseUint(value, 10, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfUint32(uint32(v)), nil
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		v, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfUint64(v), nil
+	case protoreflect.FloatKind:
+		v, err := strconv.ParseFloat(value, 32)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfFloat32(float32(v)), nil
+	case protoreflect.DoubleKind:
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return invalid(err)
+		}
+		return protoreflect.ValueOfFloat64(v), nil
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(value), nil
+	case protoreflect.EnumKind:
+		if enumValue := fd.Enum().Values().ByName(protoreflect.Name(value)); enumValue != nil {
+			return protoreflect.ValueOfEnum(enumValue.Number()), nil
+		}
+		v, err := strconv.ParseInt(value, 10, 32)
+		if err != nil {
+			return invalid(fmt.Errorf("not a %s", fd.Enum().Name()))
+		}
+		return protoreflect.ValueOfEnum(protoreflect.EnumNumber(v)), nil
+	}
+	return invalid(fmt.Errorf("unsupported field type %s", fd.Kind()))
+}
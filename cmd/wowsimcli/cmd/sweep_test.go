@@ -0,0 +1,137 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	goproto "google.golang.org/protobuf/proto"
+)
+
+func sweepTestRequest() *proto.RaidSimRequest {
+	return &proto.RaidSimRequest{
+		Raid: &proto.Raid{
+			Parties: []*proto.Party{{Players: []*proto.Player{{Name: "Player"}}}},
+		},
+		Encounter: &proto.Encounter{
+			Duration: 60,
+			Targets:  []*proto.Target{{Level: 63}},
+		},
+	}
+}
+
+func TestParseSweepParam(t *testing.T) {
+	tests := []struct {
+		arg    string
+		values []string
+		err    string
+	}{
+		{arg: "encounter.duration=60:180:60", values: []string{"60", "120", "180"}},
+		{arg: "encounter.duration=180:60:-60", values: []string{"180", "120", "60"}},
+		{arg: "player.distance_from_target=0:0.3:0.1", values: []string{"0", "0.1", "0.2", "0.3"}},
+		{arg: "player.race=RaceHuman,RaceGnome", values: []string{"RaceHuman", "RaceGnome"}},
+		{arg: "encounter.duration", err: "expected path=values"},
+		{arg: "=1,2", err: "expected path=values"},
+		{arg: "encounter.duration=1:2:3:4", err: "expected start:end[:step]"},
+		{arg: "encounter.duration=a:2", err: "invalid range bound"},
+		{arg: "encounter.duration=60:180:-60", err: "never gets from"},
+		{arg: "encounter.duration=60:180:0", err: "never gets from"},
+	}
+	for _, test := range tests {
+		param, err := parseSweepParam(test.arg)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("parseSweepParam(%q): expected error %q, got %v", test.arg, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseSweepParam(%q): %s", test.arg, err)
+			continue
+		}
+		if !slices.Equal(param.values, test.values) {
+			t.Errorf("parseSweepParam(%q): expected %v, got %v", test.arg, test.values, param.values)
+		}
+	}
+}
+
+func TestSetFieldByPath(t *testing.T) {
+	tests := []struct {
+		path  string
+		value string
+		check func(*proto.RaidSimRequest) bool
+		err   string
+	}{
+		{path: "encounter.duration", value: "120", check: func(r *proto.RaidSimRequest) bool { return r.Encounter.Duration == 120 }},
+		{path: "encounter.useHealth", value: "true", check: func(r *proto.RaidSimRequest) bool { return r.Encounter.UseHealth }},
+		{path: "player.race", value: "RaceGnome", check: func(r *proto.RaidSimRequest) bool {
+			return r.Raid.Parties[0].Players[0].Race == proto.Race_RaceGnome
+		}},
+		{path: "player.race", value: "2", check: func(r *proto.RaidSimRequest) bool {
+			return r.Raid.Parties[0].Players[0].Race == proto.Race(2)
+		}},
+		{path: "encounter.targets.0.level", value: "62", check: func(r *proto.RaidSimRequest) bool { return r.Encounter.Targets[0].Level == 62 }},
+		{path: "encounter.targets.0.stats.0", value: "5", err: "invalid index"},
+		{path: "encounter.targets", value: "3", check: func(r *proto.RaidSimRequest) bool {
+			return len(r.Encounter.Targets) == 3 && r.Encounter.Targets[2].Level == 63
+		}},
+		{path: "encounter.targets", value: "0", check: func(r *proto.RaidSimRequest) bool { return len(r.Encounter.Targets) == 0 }},
+		{path: "encounter.targets.1.level", value: "62", err: "invalid index"},
+		{path: "encounter.targets.-1.level", value: "62", err: "invalid index"},
+		{path: "encounter.targets.first.level", value: "62", err: "invalid index"},
+		{path: "encounter.targets", value: "-1", err: "invalid list size"},
+		{path: "encounter.target_stats", value: "1", err: "has no field"},
+		{path: "player.nope", value: "1", err: "has no field"},
+		{path: "encounter.duration", value: "long", err: "invalid value"},
+		{path: "encounter.use_health", value: "maybe", err: "invalid value"},
+		{path: "player.race", value: "RaceMurloc", err: "not a Race"},
+		{path: "encounter", value: "1", err: "is a message"},
+		{path: "encounter.duration.seconds", value: "1", err: "has no fields"},
+		{path: "encounter.targets.0.stats", value: "1", err: "needs an index"},
+	}
+	for _, test := range tests {
+		request := sweepTestRequest()
+		err := setFieldByPath(request.ProtoReflect(), test.path, test.value)
+		if test.err != "" {
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("setFieldByPath(%q, %q): expected error %q, got %v", test.path, test.value, test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("setFieldByPath(%q, %q): %s", test.path, test.value, err)
+			continue
+		}
+		if !test.check(request) {
+			t.Errorf("setFieldByPath(%q, %q): unexpected request %v", test.path, test.value, request)
+		}
+	}
+}
+
+func TestSweepRequests(t *testing.T) {
+	input := sweepTestRequest()
+	params := []sweepParam{
+		{path: "encounter.targets", values: []string{"2", "3"}},
+		{path: "encounter.targets.1.level", values: []string{"60", "61", "62"}},
+	}
+	requests, values, err := sweepRequests(input, params)
+	if err != nil {
+		t.Fatalf("Expected the index into the resized targets to be valid, got %s", err)
+	}
+	if len(requests) != 6 || !slices.Equal(values[5], []string{"3", "62"}) {
+		t.Fatalf("Expected 6 grid points ending with [3 62], got %v", values)
+	}
+	last := requests[5].Encounter
+	if len(last.Targets) != 3 || last.Targets[1].Level != 62 {
+		t.Fatalf("Expected the last point to have 3 targets with the second at level 62, got %v", last)
+	}
+	if !goproto.Equal(input, sweepTestRequest()) {
+		t.Fatalf("Expected the input request to be unchanged")
+	}
+
+	params[0].values = []string{"1", "2"}
+	if _, _, err := sweepRequests(input, params); err == nil || !strings.Contains(err.Error(), "encounter.targets=1") {
+		t.Fatalf("Expected an error for the point with a single target, got %v", err)
+	}
+}
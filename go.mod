@@ -5,12 +5,15 @@ go 1.23.0
 toolchain go1.23.4
 
 require (
+	connectrpc.com/connect v1.18.1
 	github.com/google/go-cmp v0.6.0
 	github.com/google/uuid v1.6.0
 	github.com/pkg/browser v0.0.0-20240102092130-5ac0b6a4141c
 	github.com/spf13/cobra v1.9.1
 	github.com/tailscale/hujson v0.0.0-20250605163823-992244df8c5a
+	go.etcd.io/bbolt v1.4.0
 	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b
+	golang.org/x/net v0.41.0
 	google.golang.org/protobuf v1.36.6
 )
 
@@ -18,4 +21,5 @@ require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/spf13/pflag v1.0.6 // indirect
 	golang.org/x/sys v0.33.0 // indirect
+	golang.org/x/text v0.26.0 // indirect
 )
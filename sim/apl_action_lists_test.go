@@ -0,0 +1,108 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func TestAPLVariablesAndActionLists(t *testing.T) {
+	rotation, err := apltext.Parse(`
+set_variable("cursed", dot_is_active(spell(11713, rank=6)))
+call_action_list("dots") if not variable("cursed")
+call_action_list("missing")
+run_action_list("filler")
+# Never reached, since the filler list is always run.
+cast_spell(spell(25311, rank=7))
+
+list dots:
+cast_spell(spell(11713, rank=6))
+call_action_list("loop")
+
+list filler:
+cast_spell(spell(25307, rank=9))
+
+list loop:
+call_action_list("dots")
+wait(variable("unknown"))
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := combatLogTestRequest(10, 0)
+	request.Raid.Parties[0].Players[0].Rotation = rotation
+
+	statsResult := core.ComputeStats(&proto.ComputeStatsRequest{Raid: request.Raid, Encounter: request.Encounter})
+	if statsResult.ErrorResult != "" {
+		t.Fatalf("Failed to compute stats: %s", statsResult.ErrorResult)
+	}
+	rotationStats := statsResult.RaidStats.Parties[0].Players[0].RotationStats
+	for _, tc := range []struct {
+		warnings []string
+		expected string
+	}{
+		{warnings: rotationStats.PriorityList[2].Warnings, expected: "No action list with name: 'missing'"},
+		{warnings: rotationStats.ActionLists[0].Items[1].Warnings, expected: "Action list 'dots' enters itself through 'loop' -> 'dots'"},
+		{warnings: rotationStats.ActionLists[2].Items[0].Warnings, expected: "Action list 'loop' enters itself through 'dots' -> 'loop'"},
+		{warnings: rotationStats.ActionLists[2].Items[1].Warnings, expected: "No variable with name: 'unknown'"},
+	} {
+		if len(tc.warnings) != 1 || !strings.HasPrefix(tc.warnings[0], tc.expected) {
+			t.Errorf("Expected warning %q, got %v", tc.expected, tc.warnings)
+		}
+	}
+
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+	casts := make(map[int32]int32)
+	for _, action := range result.RaidMetrics.Parties[0].Players[0].Actions {
+		for _, target := range action.Targets {
+			casts[action.Id.GetSpellId()] += target.Casts
+		}
+	}
+	if casts[11713] == 0 || casts[25307] == 0 {
+		t.Fatalf("Expected both the dots and filler lists to be used, got casts %v", casts)
+	}
+	if casts[25311] != 0 {
+		t.Fatalf("Expected no actions after run_action_list, got %d casts", casts[25311])
+	}
+}
+
+func TestAPLCounterVariable(t *testing.T) {
+	// The counter changes every time it's set, so it's only set once per action pass.
+	rotation, err := apltext.Parse(`
+set_variable("count", variable("count") + 1)
+cast_spell(spell(25311, rank=7)) if variable("count") == 1
+cast_spell(spell(25307, rank=9))
+
+list init:
+set_variable("count", 0)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := combatLogTestRequest(1, 0)
+	request.Raid.Parties[0].Players[0].Rotation = rotation
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+	casts := make(map[int32]int32)
+	for _, action := range result.RaidMetrics.Parties[0].Players[0].Actions {
+		for _, target := range action.Targets {
+			casts[action.Id.GetSpellId()] += target.Casts
+		}
+	}
+	if casts[25311] != 1 {
+		t.Fatalf("Expected Corruption to be cast only on the first action pass, got %d casts", casts[25311])
+	}
+	if casts[25307] == 0 {
+		t.Fatalf("Expected Shadow Bolt to be cast on later action passes")
+	}
+}
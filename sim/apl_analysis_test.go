@@ -0,0 +1,91 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func aplStats(t *testing.T, player *proto.Player) *proto.APLStats {
+	request := combatLogTestRequest(1, 0)
+	request.Raid.Parties[0].Players[0] = player
+	result := core.ComputeStats(&proto.ComputeStatsRequest{Raid: request.Raid, Encounter: request.Encounter})
+	if result.ErrorResult != "" {
+		t.Fatalf("Failed to compute stats: %s", result.ErrorResult)
+	}
+	return result.RaidStats.Parties[0].Players[0].RotationStats
+}
+
+func aplDiagnostics(t *testing.T, player *proto.Player) []*proto.APLDiagnostic {
+	return aplStats(t, player).Diagnostics
+}
+
+func TestAPLDiagnostics(t *testing.T) {
+	rotation, err := apltext.Parse(`
+prepull -1s: cast_spell(spell(11713, rank=6))
+prepull 1s: cast_spell(spell(11713, rank=6))
+
+cast_spell(spell(25307, rank=9)) if current_mana_percent > remaining_time
+cast_spell(spell(25307, rank=9)) if remaining_time < 1.5 and current_mana_percent > 50%
+cast_spell(12345) if spell_is_known(12345)
+cast_spell(spell(11713, rank=6)) if aura_is_active(99999) or current_time > 10s
+cast_spell(12345)
+run_action_list("filler") if true
+hidden cast_spell(12345)
+cast_spell(spell(25307, rank=9))
+
+list filler:
+cast_spell(spell(25307, rank=9)) if dot_is_active(12345)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type location struct {
+		kind       proto.APLDiagnostic_Kind
+		prepull    bool
+		actionList string
+		itemIndex  int32
+	}
+	expected := []location{
+		{kind: proto.APLDiagnostic_KindPrepullAfterStart, prepull: true, itemIndex: 1},
+		{kind: proto.APLDiagnostic_KindMismatchedTypes, itemIndex: 0},
+		{kind: proto.APLDiagnostic_KindUnreachableAction, itemIndex: 2},
+		{kind: proto.APLDiagnostic_KindMissingAura, itemIndex: 3},
+		{kind: proto.APLDiagnostic_KindUnknownSpell, itemIndex: 4},
+		{kind: proto.APLDiagnostic_KindUnreachableAction, itemIndex: 7},
+		{kind: proto.APLDiagnostic_KindUnknownSpell, actionList: "filler", itemIndex: 0},
+	}
+
+	player := combatLogTestRequest(1, 0).Raid.Parties[0].Players[0]
+	player.Rotation = rotation
+	stats := aplStats(t, player)
+	diagnostics := stats.Diagnostics
+	if len(diagnostics) != len(expected) {
+		t.Fatalf("Expected %d diagnostics, got %v", len(expected), diagnostics)
+	}
+	for i, diagnostic := range diagnostics {
+		actual := location{kind: diagnostic.Kind, prepull: diagnostic.Prepull, actionList: diagnostic.ActionList, itemIndex: diagnostic.ItemIndex}
+		if actual != expected[i] || diagnostic.Message == "" {
+			t.Errorf("Expected diagnostic %v, got %v", expected[i], diagnostic)
+		}
+	}
+	// The late prepull action is only reported by its diagnostic.
+	if warnings := stats.PrepullActions[1].Warnings; len(warnings) != 0 {
+		t.Errorf("Expected no warnings for the late prepull action, got %v", warnings)
+	}
+}
+
+func TestAPLDiagnosticsPresetRotation(t *testing.T) {
+	// The preset is shared by talent builds, so Amplify Curse is unknown with Demonic Sacrifice
+	// talents. Everything else should be clean.
+	player := combatLogTestRequest(1, 0).Raid.Parties[0].Players[0]
+	player.TalentsString = "25002-2050300152201-52500051020001"
+	player.Consumes = &proto.Consumes{DefaultPotion: proto.Potions_MajorManaPotion, DefaultConjured: proto.Conjured_ConjuredDemonicRune}
+	diagnostics := aplDiagnostics(t, player)
+	if len(diagnostics) != 1 || diagnostics[0].Kind != proto.APLDiagnostic_KindUnknownSpell || !diagnostics[0].Prepull || diagnostics[0].ItemIndex != 1 {
+		t.Fatalf("Expected only the Amplify Curse diagnostic for the preset rotation, got %v", diagnostics)
+	}
+}
@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func TestAPLOptimizer(t *testing.T) {
+	rotation, err := apltext.Parse(`
+cast_spell(spell(11689, rank=6)) if current_mana_percent < const("10%", {min=0, max=40, step=10, name="life tap"})
+cast_spell(spell(11713, rank=6)) if not dot_is_active(spell(11713, rank=6)) and remaining_time > const("6s", {max=20, step=5})
+cast_spell(spell(25307, rank=9))
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := combatLogTestRequest(50, 0)
+	request.Raid.Parties[0].Players[0].Rotation = rotation
+
+	result := core.OptimizeAPL(&proto.APLOptimizerRequest{
+		RaidSimRequest:    request,
+		MaxRounds:         1,
+		SensitivityPoints: 3,
+	})
+	if result.Error != nil {
+		t.Fatalf("Optimizer failed: %s", result.Error.Message)
+	}
+	if result.BestDps < result.InitialDps || result.SimsRun < 2 {
+		t.Fatalf("Expected the best DPS to be at least the initial DPS, got %v", result)
+	}
+	if len(result.Tunables) != 2 || result.Tunables[0].Name != "life tap" || result.Tunables[1].Name != "priority list #2" {
+		t.Fatalf("Expected 2 named tunables, got %v", result.Tunables)
+	}
+	// Initial values are snapped to their step before they're simmed.
+	if result.Tunables[0].InitialVal != "10%" || result.Tunables[1].InitialVal != "5s" {
+		t.Fatalf("Expected the simmed initial values 10%% and 5s, got %v", result.Tunables)
+	}
+
+	bestVals := []string{
+		result.Rotation.PriorityList[0].Action.Condition.GetCmp().Rhs.GetConst().Val,
+		result.Rotation.PriorityList[1].Action.Condition.GetAnd().Vals[1].GetCmp().Rhs.GetConst().Val,
+	}
+	for i, tunable := range result.Tunables {
+		if tunable.BestVal != bestVals[i] {
+			t.Errorf("Expected rotation to use the best value %s, got %s", tunable.BestVal, bestVals[i])
+		}
+		if len(tunable.Sensitivity) < 3 {
+			t.Errorf("Expected at least 3 sensitivity samples, got %v", tunable.Sensitivity)
+		}
+		for _, sample := range tunable.Sensitivity {
+			if sample.DpsDelta == nil || sample.DpsDelta.Iterations != 50 {
+				t.Errorf("Expected paired deltas over all iterations, got %v", sample)
+			}
+			if sample.Val == tunable.BestVal && (sample.Dps != result.BestDps || sample.DpsDelta.Mean != 0) {
+				t.Errorf("Expected the best value to have the best DPS, got %v", sample)
+			}
+		}
+	}
+	if request.Raid.Parties[0].Players[0].Rotation.PriorityList[0].Action.Condition.GetCmp().Rhs.GetConst().Val != "10%" {
+		t.Errorf("Expected the request to be left unchanged")
+	}
+
+	// Without tunable constants there's nothing to optimize.
+	request = combatLogTestRequest(10, 0)
+	result = core.OptimizeAPL(&proto.APLOptimizerRequest{RaidSimRequest: request})
+	if result.Error == nil || result.Error.Message != "rotation has no tunable constants" {
+		t.Fatalf("Expected an error without tunable constants, got %v", result.Error)
+	}
+}
@@ -0,0 +1,51 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func TestAPLTrace(t *testing.T) {
+	request := combatLogTestRequest(20, 0)
+	if result := core.RunRaidSim(request); len(result.RaidMetrics.Parties[0].Players[0].AplItems) != 0 {
+		t.Fatalf("Expected no APL trace unless enabled")
+	}
+
+	// Items using spells the player doesn't know aren't part of the rotation and aren't traced, so use
+	// the talents the preset is written for, where every priority list item is known.
+	request.Raid.Parties[0].Players[0].TalentsString = "25002-2050300152201-52500051020001"
+	request.Raid.Parties[0].Players[0].Consumes = &proto.Consumes{DefaultPotion: proto.Potions_MajorManaPotion, DefaultConjured: proto.Conjured_ConjuredDemonicRune}
+	request.SimOptions.AplTrace = true
+	for _, result := range []*proto.RaidSimResult{core.RunRaidSim(request), core.RunRaidSimConcurrent(request)} {
+		if result.Error != nil {
+			t.Fatalf("Sim failed: %s", result.Error.Message)
+		}
+		player := result.RaidMetrics.Parties[0].Players[0]
+
+		numItems := len(request.Raid.Parties[0].Players[0].Rotation.PriorityList)
+		if len(player.AplItems) != numItems {
+			t.Fatalf("Expected metrics for %d items, got %d", numItems, len(player.AplItems))
+		}
+		for _, item := range player.AplItems {
+			if item.Evaluations == 0 || item.ConditionTrue > item.Evaluations || item.ConditionTrue != item.NotReady+item.Executions {
+				t.Fatalf("Inconsistent metrics for item %d: %v", item.ItemIndex, item)
+			}
+		}
+
+		// The last item is the Shadow Bolt filler, which is only cast by the rotation.
+		var casts int32
+		for _, action := range player.Actions {
+			if action.Id.GetSpellId() == 25307 {
+				for _, target := range action.Targets {
+					casts += target.Casts
+				}
+			}
+		}
+		filler := player.AplItems[numItems-1]
+		if filler.ItemIndex != int32(numItems-1) || filler.Executions == 0 || filler.Executions != int64(casts) {
+			t.Fatalf("Expected the filler to be executed once per Shadow Bolt cast (%d), got %v", casts, filler)
+		}
+	}
+}
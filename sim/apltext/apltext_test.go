@@ -0,0 +1,171 @@
+package apltext
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+func TestRoundTripUIRotations(t *testing.T) {
+	files, err := filepath.Glob("../../ui/*/apls/*.apl.json")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("No APL files found: %v", err)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rotation := core.APLRotationFromJsonString(string(data))
+
+		text, err := Format(rotation)
+		if err != nil {
+			t.Fatalf("%s: %s", file, err)
+		}
+		parsed, err := Parse(text)
+		if err != nil {
+			t.Fatalf("%s: %s\n%s", file, err, text)
+		}
+		if !googleProto.Equal(rotation, parsed) {
+			t.Fatalf("%s doesn't round-trip:\n%s", file, text)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	rotation, err := Parse(`
+define Fireball = spell(10151, rank=12)
+define Ignite = 12654
+
+prepull -1.5s: cast_spell(Fireball)
+
+# Filler.
+# Keep Ignite rolling.
+cast_spell(Fireball) if dot_remaining_time(Ignite) < 2s and current_mana_percent > 0.3 # trailing comments are ignored
+hidden cast_spell(item(12662)) if not (aura_is_active(Ignite, current_target) or current_time >= 10s - 2 * gcd_time_to_ready)
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fireball := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 10151}, Rank: 12}
+	ignite := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 12654}}
+	constValue := func(val string) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_Const{Const: &proto.APLValueConst{Val: val}}}
+	}
+	cmp := func(op proto.APLValueCompare_ComparisonOperator, lhs, rhs *proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_Cmp{Cmp: &proto.APLValueCompare{Op: op, Lhs: lhs, Rhs: rhs}}}
+	}
+	math := func(op proto.APLValueMath_MathOperator, lhs, rhs *proto.APLValue) *proto.APLValue {
+		return &proto.APLValue{Value: &proto.APLValue_Math{Math: &proto.APLValueMath{Op: op, Lhs: lhs, Rhs: rhs}}}
+	}
+
+	expected := &proto.APLRotation{
+		Type: proto.APLRotation_TypeAPL,
+		PrepullActions: []*proto.APLPrepullAction{{
+			DoAtValue: constValue("-1.5s"),
+			Action:    &proto.APLAction{Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{SpellId: fireball}}},
+		}},
+		PriorityList: []*proto.APLListItem{
+			{
+				Notes: "Filler.\nKeep Ignite rolling.",
+				Action: &proto.APLAction{
+					Condition: &proto.APLValue{Value: &proto.APLValue_And{And: &proto.APLValueAnd{Vals: []*proto.APLValue{
+						cmp(proto.APLValueCompare_OpLt,
+							&proto.APLValue{Value: &proto.APLValue_DotRemainingTime{DotRemainingTime: &proto.APLValueDotRemainingTime{SpellId: ignite}}},
+							constValue("2s")),
+						cmp(proto.APLValueCompare_OpGt,
+							&proto.APLValue{Value: &proto.APLValue_CurrentManaPercent{CurrentManaPercent: &proto.APLValueCurrentManaPercent{}}},
+							constValue("0.3")),
+					}}}},
+					Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{SpellId: fireball}},
+				},
+			},
+			{
+				Hide: true,
+				Action: &proto.APLAction{
+					Condition: &proto.APLValue{Value: &proto.APLValue_Not{Not: &proto.APLValueNot{Val: &proto.APLValue{Value: &proto.APLValue_Or{Or: &proto.APLValueOr{Vals: []*proto.APLValue{
+						{Value: &proto.APLValue_AuraIsActive{AuraIsActive: &proto.APLValueAuraIsActive{
+							AuraId:     ignite,
+							SourceUnit: &proto.UnitReference{Type: proto.UnitReference_CurrentTarget},
+						}}},
+						cmp(proto.APLValueCompare_OpGe,
+							&proto.APLValue{Value: &proto.APLValue_CurrentTime{CurrentTime: &proto.APLValueCurrentTime{}}},
+							math(proto.APLValueMath_OpSub,
+								constValue("10s"),
+								math(proto.APLValueMath_OpMul,
+									constValue("2"),
+									&proto.APLValue{Value: &proto.APLValue_GcdTimeToReady{GcdTimeToReady: &proto.APLValueGCDTimeToReady{}}}))),
+					}}}}}}},
+					Action: &proto.APLAction_CastSpell{CastSpell: &proto.APLActionCastSpell{
+						SpellId: &proto.ActionID{RawId: &proto.ActionID_ItemId{ItemId: 12662}},
+					}},
+				},
+			},
+		},
+	}
+	if !googleProto.Equal(rotation, expected) {
+		t.Fatalf("Unexpected rotation: %v", rotation)
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for _, text := range []string{
+		// Nesting that needs parentheses to round-trip.
+		"wait(max(1s, 2s - (1s - 0.5s)) * (1 + 2)) if (current_time > 1s and gcd_is_ready) and (not gcd_is_ready or true)",
+		// Operators with missing operands.
+		"wait_until(and(gcd_is_ready)) if not() or cmp(lhs=1, rhs=2) or {}",
+		// Nested actions, named arguments and messages without a short syntax.
+		`sequence("opener", cast_spell(1) if gcd_is_ready, channel_spell(15407, interrupt_if=spell_can_cast(2), allow_recast=true))`,
+		`sequence(actions=[activate_aura(other(OtherActionPotion, tag=1)), cancel_aura({rank=2})])`,
+		`cast_spell(1, pet(owner=player(1))) if "not a number" == -1s`,
+		`cat_optimal_rotation_action(min_combos_for_rip=5, max_wait_time=0.5, use_shred_trick=true)`,
+		`{} if is_execute_phase(E20)`,
+		// Action lists, including names that need quotes.
+		"call_action_list(\"aoe\")\n\nlist aoe:\nset_variable(\"x\", variable(\"y\") + 1)\n\nlist \"not an ident\":\nrun_action_list(\"aoe\")",
+		// Tunable constants, which keep their range.
+		`cast_spell(1) if current_mana_percent < const("10%", {max=50, step=5})`,
+	} {
+		rotation, err := Parse(text)
+		if err != nil {
+			t.Fatalf("Failed to parse %q: %s", text, err)
+		}
+		formatted, err := Format(rotation)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if formatted != text+"\n" {
+			t.Fatalf("Expected %q, got %q", text, formatted)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		text  string
+		error string
+	}{
+		{text: "cast_spell(Fireball)", error: `1:12: unknown action ID "Fireball"`},
+		{text: "\n  cast_spel(1)", error: `2:3: unknown action "cast_spel"`},
+		{text: "cast_spell(1) if 1 < 2 < 3", error: "1:24: comparisons can't be chained"},
+		{text: "cast_spell(1) if current_time >", error: "1:32: expected a value, got end of input"},
+		{text: "cast_spell(1, foo=2)", error: `1:15: unknown argument "foo" for APLActionCastSpell`},
+		{text: "cast_spell(spell_id=1, target)", error: "1:24: positional argument after named arguments"},
+		{text: "wait_until(\n  gcd_is_ready,\n  gcd_is_ready)", error: "3:3: too many arguments"},
+		{text: "cast_spell(1) wait(1s)", error: `1:15: expected end of line, got "wait"`},
+		{text: "is_execute_phase(E50)", error: `1:1: unknown action "is_execute_phase"`},
+		{text: "wait(is_execute_phase(E50))", error: "1:23: expected one of Unknown, E20, E25, E35"},
+		{text: `wait("1s)`, error: "1:6: unterminated string"},
+	} {
+		_, err := Parse(tc.text)
+		if err == nil || !strings.HasPrefix(err.Error(), tc.error) {
+			t.Fatalf("Expected error %q for %q, got %v", tc.error, tc.text, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package apltext
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokNewline
+	tokComment // Text is the comment without the leading '#'.
+	tokIdent
+	tokNumber // Numbers keep their unit suffix, e.g. 1.5s, 200ms or 10%.
+	tokString // Text is the unquoted string.
+	tokPunct  // Operators and delimiters.
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+	col  int
+
+	// Whether this comment is the only thing on its line.
+	fullLine bool
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of input"
+	case tokNewline:
+		return "end of line"
+	case tokString:
+		return strconv.Quote(t.text)
+	default:
+		return fmt.Sprintf("%q", t.text)
+	}
+}
+
+// Multi-character operators must come before their prefixes.
+var punctuation = []string{"==", "!=", "<=", ">=", "<", ">", "+", "-", "*", "/", "(", ")", "[", "]", "{", "}", ",", "=", ":"}
+
+// Splits text into tokens. Newlines inside brackets are skipped, so long expressions can be
+// wrapped over several lines.
+func lex(text string) ([]token, error) {
+	var tokens []token
+	line, col := 1, 1
+	depth := 0
+	lineHasTokens := false
+
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := token{line: line, col: col}
+		advance := func(n int) {
+			i += n
+			col += n
+		}
+
+		switch {
+		case r == '\n':
+			if depth == 0 {
+				start.kind = tokNewline
+				tokens = append(tokens, start)
+			}
+			i++
+			line++
+			col = 1
+			lineHasTokens = false
+			continue
+		case unicode.IsSpace(r):
+			advance(1)
+			continue
+		case r == '#':
+			end := i
+			for end < len(runes) && runes[end] != '\n' {
+				end++
+			}
+			start.kind = tokComment
+			start.text = strings.TrimRight(string(runes[i+1:end]), "\r")
+			start.fullLine = !lineHasTokens && depth == 0
+			if depth == 0 {
+				tokens = append(tokens, start)
+			}
+			advance(end - i)
+			continue
+		case isIdentStart(r):
+			end := i
+			for end < len(runes) && isIdentPart(runes[end]) {
+				end++
+			}
+			start.kind = tokIdent
+			start.text = string(runes[i:end])
+			advance(end - i)
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1])):
+			end := scanNumber(runes, i)
+			start.kind = tokNumber
+			start.text = string(runes[i:end])
+			advance(end - i)
+		case r == '"':
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' && runes[end] != '\n' {
+				if runes[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(runes) || runes[end] != '"' {
+				return nil, &ParseError{Line: line, Col: col, Msg: "unterminated string"}
+			}
+			unquoted, err := strconv.Unquote(string(runes[i : end+1]))
+			if err != nil {
+				return nil, &ParseError{Line: line, Col: col, Msg: "invalid string: " + err.Error()}
+			}
+			start.kind = tokString
+			start.text = unquoted
+			advance(end + 1 - i)
+		default:
+			op := ""
+			for _, p := range punctuation {
+				if strings.HasPrefix(string(runes[i:min(i+2, len(runes))]), p) {
+					op = p
+					break
+				}
+			}
+			if op == "" {
+				return nil, &ParseError{Line: line, Col: col, Msg: fmt.Sprintf("unexpected character %q", r)}
+			}
+			switch op {
+			case "(", "[", "{":
+				depth++
+			case ")", "]", "}":
+				depth = max(depth-1, 0)
+			}
+			start.kind = tokPunct
+			start.text = op
+			advance(len(op))
+		}
+
+		tokens = append(tokens, start)
+		lineHasTokens = true
+	}
+
+	tokens = append(tokens, token{kind: tokEOF, line: line, col: col})
+	return tokens, nil
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
+
+// Returns the end of the number starting at runes[i], including any unit suffix.
+func scanNumber(runes []rune, i int) int {
+	for i < len(runes) && unicode.IsDigit(runes[i]) {
+		i++
+	}
+	if i+1 < len(runes) && runes[i] == '.' && unicode.IsDigit(runes[i+1]) {
+		i++
+		for i < len(runes) && unicode.IsDigit(runes[i]) {
+			i++
+		}
+	}
+	for i < len(runes) && (unicode.IsLetter(runes[i]) || runes[i] == '%') {
+		i++
+	}
+	return i
+}
@@ -0,0 +1,627 @@
+// Package apltext converts APL rotations between proto.APLRotation and a compact text syntax
+// modeled after SimulationCraft action lists, e.g.
+//
+//	define Immolate = spell(25309, rank=8)
+//
+//	prepull -1.5s: cast_spell(Immolate)
+//
+//	# Keep Immolate up.
+//	cast_spell(Immolate) if dot_remaining_time(Immolate) < 2s and current_mana_percent > 30%
+//	hidden cast_spell(25307)
+//	call_action_list("aoe") if number_targets > 2
+//
+//	list aoe:
+//	cast_spell(11678)
+//
+// Actions and values are written with the names of their fields in the APLAction and APLValue
+// oneofs, followed by their arguments. Arguments can be given by position, in field number
+// order, or by name. Conditions use the usual operators (and, or, not, comparisons and
+// arithmetic), and any message can also be written field by field in braces, e.g. {tag=1}.
+// Items after a list header belong to that named action list instead of the main priority list.
+package apltext
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	googleProto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// A syntax error, with the 1-based position where it was found.
+type ParseError struct {
+	Line int
+	Col  int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%d:%d: %s", e.Line, e.Col, e.Msg)
+}
+
+var (
+	actionDesc   = (&proto.APLAction{}).ProtoReflect().Descriptor()
+	valueDesc    = (&proto.APLValue{}).ProtoReflect().Descriptor()
+	actionIDDesc = (&proto.ActionID{}).ProtoReflect().Descriptor()
+	unitDesc     = (&proto.UnitReference{}).ProtoReflect().Descriptor()
+
+	actionOneof = actionDesc.Oneofs().ByName("action")
+	valueOneof  = valueDesc.Oneofs().ByName("value")
+	rawIDOneof  = actionIDDesc.Oneofs().ByName("raw_id")
+)
+
+// Keywords that can't be used as the names in a define.
+var keywords = []string{"define", "prepull", "list", "hidden", "if", "and", "or", "not", "true", "false"}
+
+type parser struct {
+	tokens  []token
+	pos     int
+	defines map[string]*proto.ActionID
+}
+
+// Parses a rotation in the text syntax. Comment lines directly above an action become its notes.
+func Parse(text string) (rotation *proto.APLRotation, err error) {
+	tokens, err := lex(text)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			parseErr, ok := r.(*ParseError)
+			if !ok {
+				panic(r)
+			}
+			rotation, err = nil, parseErr
+		}
+	}()
+
+	p := &parser{tokens: tokens, defines: make(map[string]*proto.ActionID)}
+	return p.parseRotation(), nil
+}
+
+func (p *parser) parseRotation() *proto.APLRotation {
+	rotation := &proto.APLRotation{Type: proto.APLRotation_TypeAPL}
+	var notes []string
+	atLineStart := true
+
+	// Items go to the main priority list until the first list header.
+	items := &rotation.PriorityList
+
+	for {
+		tok := p.peek()
+		switch tok.kind {
+		case tokEOF:
+			return rotation
+		case tokNewline:
+			// A blank line separates comments from the next action.
+			if atLineStart {
+				notes = nil
+			}
+			atLineStart = true
+			p.next()
+			continue
+		case tokComment:
+			if tok.fullLine {
+				notes = append(notes, strings.TrimPrefix(tok.text, " "))
+			}
+			atLineStart = false
+			p.next()
+			continue
+		}
+
+		atLineStart = false
+		hide := p.acceptIdent("hidden")
+		switch {
+		case !hide && p.acceptIdent("define"):
+			p.parseDefine()
+		case !hide && p.acceptIdent("list"):
+			list := &proto.APLActionList{}
+			if tok := p.next(); tok.kind == tokIdent || tok.kind == tokString {
+				list.Name = tok.text
+			} else {
+				p.fail(tok, "expected a list name, got %s", tok)
+			}
+			p.expectPunct(":")
+			rotation.ActionLists = append(rotation.ActionLists, list)
+			items = &list.Items
+		case p.acceptIdent("prepull"):
+			prepull := &proto.APLPrepullAction{Hide: hide}
+			if !p.acceptPunct(":") {
+				prepull.DoAtValue = p.parseValue()
+				p.expectPunct(":")
+			}
+			prepull.Action = p.parseAction()
+			rotation.PrepullActions = append(rotation.PrepullActions, prepull)
+		default:
+			*items = append(*items, &proto.APLListItem{
+				Hide:   hide,
+				Notes:  strings.Join(notes, "\n"),
+				Action: p.parseAction(),
+			})
+		}
+		notes = nil
+
+		if tok := p.peek(); tok.kind == tokComment {
+			p.next()
+		}
+		if tok := p.peek(); tok.kind != tokNewline && tok.kind != tokEOF {
+			p.fail(tok, "expected end of line, got %s", tok)
+		}
+	}
+}
+
+func (p *parser) parseDefine() {
+	name := p.expect(tokIdent)
+	if slices.Contains(keywords, name.text) {
+		p.fail(name, "can't define keyword %q", name.text)
+	}
+	if _, ok := p.defines[name.text]; ok {
+		p.fail(name, "%q is already defined", name.text)
+	}
+	p.expectPunct("=")
+	p.defines[name.text] = p.parseActionID()
+}
+
+// Parses an action, with an optional condition.
+func (p *parser) parseAction() *proto.APLAction {
+	var action *proto.APLAction
+	if tok := p.peek(); tok.kind == tokPunct && tok.text == "{" {
+		action = p.parseBraces(actionDesc, (&proto.APLAction{}).ProtoReflect()).Interface().(*proto.APLAction)
+	} else {
+		name := p.expect(tokIdent)
+		fd := actionOneof.Fields().ByName(protoreflect.Name(name.text))
+		if fd == nil {
+			p.fail(name, "unknown action %q", name.text)
+		}
+		action = &proto.APLAction{}
+		m := action.ProtoReflect()
+		m.Set(fd, protoreflect.ValueOfMessage(p.parseCall(m.NewField(fd).Message())))
+	}
+
+	if tok := p.peek(); p.acceptIdent("if") {
+		if action.Condition != nil {
+			p.fail(tok, "action already has a condition")
+		}
+		action.Condition = p.parseValue()
+	}
+	return action
+}
+
+// Parses a value expression: or < and < not < comparisons < + - < * / < operands.
+func (p *parser) parseValue() *proto.APLValue {
+	return p.parseOr()
+}
+
+func (p *parser) parseOr() *proto.APLValue {
+	vals := []*proto.APLValue{p.parseAnd()}
+	for p.acceptIdent("or") {
+		vals = append(vals, p.parseAnd())
+	}
+	if len(vals) == 1 {
+		return vals[0]
+	}
+	return &proto.APLValue{Value: &proto.APLValue_Or{Or: &proto.APLValueOr{Vals: vals}}}
+}
+
+func (p *parser) parseAnd() *proto.APLValue {
+	vals := []*proto.APLValue{p.parseNot()}
+	for p.acceptIdent("and") {
+		vals = append(vals, p.parseNot())
+	}
+	if len(vals) == 1 {
+		return vals[0]
+	}
+	return &proto.APLValue{Value: &proto.APLValue_And{And: &proto.APLValueAnd{Vals: vals}}}
+}
+
+func (p *parser) parseNot() *proto.APLValue {
+	// not(...) is parsed as a call, so that not() round-trips.
+	if tok := p.peek(); tok.kind == tokIdent && tok.text == "not" && !p.peekPunct(1, "(") {
+		p.next()
+		return &proto.APLValue{Value: &proto.APLValue_Not{Not: &proto.APLValueNot{Val: p.parseNot()}}}
+	}
+	return p.parseComparison()
+}
+
+var comparisonOps = map[string]proto.APLValueCompare_ComparisonOperator{
+	"==": proto.APLValueCompare_OpEq,
+	"!=": proto.APLValueCompare_OpNe,
+	"<":  proto.APLValueCompare_OpLt,
+	"<=": proto.APLValueCompare_OpLe,
+	">":  proto.APLValueCompare_OpGt,
+	">=": proto.APLValueCompare_OpGe,
+}
+
+func (p *parser) parseComparison() *proto.APLValue {
+	lhs := p.parseSum()
+	tok := p.peek()
+	op, ok := comparisonOps[tok.text]
+	if tok.kind != tokPunct || !ok {
+		return lhs
+	}
+	p.next()
+	rhs := p.parseSum()
+	if next := p.peek(); next.kind == tokPunct && comparisonOps[next.text] != 0 {
+		p.fail(next, "comparisons can't be chained, use parentheses")
+	}
+	return &proto.APLValue{Value: &proto.APLValue_Cmp{Cmp: &proto.APLValueCompare{Op: op, Lhs: lhs, Rhs: rhs}}}
+}
+
+var mathOps = map[string]proto.APLValueMath_MathOperator{
+	"+": proto.APLValueMath_OpAdd,
+	"-": proto.APLValueMath_OpSub,
+	"*": proto.APLValueMath_OpMul,
+	"/": proto.APLValueMath_OpDiv,
+}
+
+func (p *parser) parseSum() *proto.APLValue {
+	return p.parseMath(p.parseProduct, "+", "-")
+}
+
+func (p *parser) parseProduct() *proto.APLValue {
+	return p.parseMath(p.parseOperand, "*", "/")
+}
+
+func (p *parser) parseMath(operand func() *proto.APLValue, ops ...string) *proto.APLValue {
+	lhs := operand()
+	for {
+		tok := p.peek()
+		if tok.kind != tokPunct || !slices.Contains(ops, tok.text) {
+			return lhs
+		}
+		p.next()
+		lhs = &proto.APLValue{Value: &proto.APLValue_Math{Math: &proto.APLValueMath{Op: mathOps[tok.text], Lhs: lhs, Rhs: operand()}}}
+	}
+}
+
+func (p *parser) parseOperand() *proto.APLValue {
+	tok := p.next()
+	switch tok.kind {
+	case tokNumber:
+		return constValue(tok.text)
+	case tokString:
+		return constValue(tok.text)
+	case tokPunct:
+		switch tok.text {
+		case "-":
+			if num := p.peek(); num.kind == tokNumber {
+				p.next()
+				return constValue("-" + num.text)
+			}
+		case "(":
+			value := p.parseValue()
+			p.expectPunct(")")
+			return value
+		case "{":
+			p.pos--
+			return p.parseBraces(valueDesc, (&proto.APLValue{}).ProtoReflect()).Interface().(*proto.APLValue)
+		}
+	case tokIdent:
+		if tok.text == "true" || tok.text == "false" {
+			return constValue(tok.text)
+		}
+		fd := valueOneof.Fields().ByName(protoreflect.Name(tok.text))
+		if fd == nil {
+			p.fail(tok, "unknown value %q", tok.text)
+		}
+		value := &proto.APLValue{}
+		m := value.ProtoReflect()
+		m.Set(fd, protoreflect.ValueOfMessage(p.parseCall(m.NewField(fd).Message())))
+		return value
+	}
+	p.fail(tok, "expected a value, got %s", tok)
+	return nil
+}
+
+func constValue(val string) *proto.APLValue {
+	return &proto.APLValue{Value: &proto.APLValue_Const{Const: &proto.APLValueConst{Val: val}}}
+}
+
+// Parses an optional argument list into m.
+func (p *parser) parseCall(m protoreflect.Message) protoreflect.Message {
+	if p.acceptPunct("(") {
+		p.parseArgs(m, positionalFields(m.Descriptor()), ")")
+	}
+	return m
+}
+
+// Parses a message written field by field, e.g. {spell_id=1, tag=2}.
+func (p *parser) parseBraces(desc protoreflect.MessageDescriptor, m protoreflect.Message) protoreflect.Message {
+	p.expectPunct("{")
+	p.parseArgs(m, nil, "}")
+	return m
+}
+
+// Parses comma-separated arguments up to the closing delimiter. Positional arguments fill the
+// given fields in order, and a repeated field takes all remaining positional arguments.
+func (p *parser) parseArgs(m protoreflect.Message, positional []protoreflect.FieldDescriptor, closing string) {
+	seen := make(map[protoreflect.FieldNumber]bool)
+	named := false
+	for !p.acceptPunct(closing) {
+		tok := p.peek()
+		var fd protoreflect.FieldDescriptor
+		if tok.kind == tokIdent && p.peekPunct(1, "=") {
+			p.next()
+			p.next()
+			fd = m.Descriptor().Fields().ByName(protoreflect.Name(tok.text))
+			if fd == nil {
+				p.fail(tok, "unknown argument %q for %s", tok.text, m.Descriptor().Name())
+			}
+			named = true
+		} else {
+			if named {
+				p.fail(tok, "positional argument after named arguments")
+			}
+			if len(positional) == 0 {
+				p.fail(tok, "too many arguments for %s", m.Descriptor().Name())
+			}
+			fd = positional[0]
+			if !fd.IsList() {
+				positional = positional[1:]
+			}
+		}
+
+		if seen[fd.Number()] && !(fd.IsList() && !named) {
+			p.fail(tok, "duplicate argument %q", fd.Name())
+		}
+		seen[fd.Number()] = true
+
+		if fd.IsList() {
+			list := m.Mutable(fd).List()
+			if named && p.acceptPunct("[") {
+				for !p.acceptPunct("]") {
+					list.Append(p.parseField(m, fd))
+					if !p.acceptPunct(",") {
+						p.expectPunct("]")
+						break
+					}
+				}
+			} else {
+				list.Append(p.parseField(m, fd))
+			}
+		} else {
+			m.Set(fd, p.parseField(m, fd))
+		}
+
+		if !p.acceptPunct(",") {
+			p.expectPunct(closing)
+			break
+		}
+	}
+}
+
+// Parses a single value for fd, or a single element if fd is repeated.
+func (p *parser) parseField(m protoreflect.Message, fd protoreflect.FieldDescriptor) protoreflect.Value {
+	tok := p.peek()
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		var sub protoreflect.Message
+		if fd.IsList() {
+			sub = m.NewField(fd).List().NewElement().Message()
+		} else {
+			sub = m.NewField(fd).Message()
+		}
+		return protoreflect.ValueOfMessage(p.parseMessage(sub))
+	case protoreflect.BoolKind:
+		p.next()
+		if tok.kind == tokIdent && (tok.text == "true" || tok.text == "false") {
+			return protoreflect.ValueOfBool(tok.text == "true")
+		}
+		p.fail(tok, "expected true or false, got %s", tok)
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(p.expect(tokString).text)
+	case protoreflect.EnumKind:
+		p.next()
+		if tok.kind == tokIdent {
+			if ev := fd.Enum().Values().ByName(protoreflect.Name(tok.text)); ev != nil {
+				return protoreflect.ValueOfEnum(ev.Number())
+			}
+		} else if tok.kind == tokNumber {
+			if n, err := strconv.ParseInt(tok.text, 10, 32); err == nil {
+				return protoreflect.ValueOfEnum(protoreflect.EnumNumber(n))
+			}
+		}
+		p.fail(tok, "expected one of %s, got %s", strings.Join(enumNames(fd.Enum()), ", "), tok)
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		text := p.parseNumber()
+		if f, err := strconv.ParseFloat(text, 64); err == nil {
+			if fd.Kind() == protoreflect.FloatKind {
+				return protoreflect.ValueOfFloat32(float32(f))
+			}
+			return protoreflect.ValueOfFloat64(f)
+		}
+		p.fail(tok, "expected a number, got %s", tok)
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(p.parseInt(tok, 32)))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(p.parseInt(tok, 64))
+	}
+	p.fail(tok, "unsupported argument type %s", fd.Kind())
+	return protoreflect.Value{}
+}
+
+func (p *parser) parseNumber() string {
+	sign := ""
+	if p.acceptPunct("-") {
+		sign = "-"
+	}
+	return sign + p.expect(tokNumber).text
+}
+
+func (p *parser) parseInt(tok token, bitSize int) int64 {
+	n, err := strconv.ParseInt(p.parseNumber(), 10, bitSize)
+	if err != nil {
+		p.fail(tok, "expected an integer, got %s", tok)
+	}
+	return n
+}
+
+// Parses a message-typed argument, using the short syntax for the types that have one.
+func (p *parser) parseMessage(m protoreflect.Message) protoreflect.Message {
+	if tok := p.peek(); tok.kind == tokPunct && tok.text == "{" {
+		return p.parseBraces(m.Descriptor(), m)
+	}
+	switch m.Descriptor().FullName() {
+	case valueDesc.FullName():
+		return p.parseValue().ProtoReflect()
+	case actionDesc.FullName():
+		return p.parseAction().ProtoReflect()
+	case actionIDDesc.FullName():
+		return p.parseActionID().ProtoReflect()
+	case unitDesc.FullName():
+		return p.parseUnit().ProtoReflect()
+	}
+	return p.parseBraces(m.Descriptor(), m)
+}
+
+// Parses a spell id, a defined name, spell(id, ...), item(id, ...), other(name, ...) or {...}.
+func (p *parser) parseActionID() *proto.ActionID {
+	actionID := &proto.ActionID{}
+	m := actionID.ProtoReflect()
+
+	tok := p.peek()
+	switch tok.kind {
+	case tokNumber:
+		actionID.RawId = &proto.ActionID_SpellId{SpellId: int32(p.parseInt(tok, 32))}
+		return actionID
+	case tokPunct:
+		if tok.text == "{" {
+			p.parseBraces(actionIDDesc, m)
+			return actionID
+		}
+	case tokIdent:
+		p.next()
+		if fd := rawIDOneof.Fields().ByName(protoreflect.Name(tok.text + "_id")); fd != nil && p.acceptPunct("(") {
+			p.parseArgs(m, []protoreflect.FieldDescriptor{fd}, ")")
+			if !m.Has(fd) {
+				p.fail(tok, "missing %s", fd.Name())
+			}
+			return actionID
+		}
+		if defined, ok := p.defines[tok.text]; ok {
+			return googleProto.Clone(defined).(*proto.ActionID)
+		}
+		p.fail(tok, "unknown action ID %q, declare it first with \"define %s = <spell id>\"", tok.text, tok.text)
+	}
+	p.fail(tok, "expected an action ID, got %s", tok)
+	return nil
+}
+
+// Parses a unit type in snake case, e.g. current_target, with an optional index and owner.
+func (p *parser) parseUnit() *proto.UnitReference {
+	unit := &proto.UnitReference{}
+	m := unit.ProtoReflect()
+
+	tok := p.expect(tokIdent)
+	typeField := unitDesc.Fields().ByName("type")
+	values := typeField.Enum().Values()
+	for i := 0; i < values.Len(); i++ {
+		if snakeCase(string(values.Get(i).Name())) == tok.text {
+			unit.Type = proto.UnitReference_Type(values.Get(i).Number())
+			if p.acceptPunct("(") {
+				p.parseArgs(m, []protoreflect.FieldDescriptor{unitDesc.Fields().ByName("index")}, ")")
+			}
+			return unit
+		}
+	}
+	p.fail(tok, "unknown unit %q", tok.text)
+	return nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekPunct(offset int, text string) bool {
+	if p.pos+offset >= len(p.tokens) {
+		return false
+	}
+	tok := p.tokens[p.pos+offset]
+	return tok.kind == tokPunct && tok.text == text
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) acceptIdent(text string) bool {
+	if tok := p.peek(); tok.kind == tokIdent && tok.text == text {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) acceptPunct(text string) bool {
+	if p.peekPunct(0, text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(kind tokenKind) token {
+	tok := p.next()
+	if tok.kind != kind {
+		names := map[tokenKind]string{tokIdent: "a name", tokNumber: "a number", tokString: "a quoted string"}
+		p.fail(tok, "expected %s, got %s", names[kind], tok)
+	}
+	return tok
+}
+
+func (p *parser) expectPunct(text string) {
+	if tok := p.next(); tok.kind != tokPunct || tok.text != text {
+		p.fail(tok, "expected %q, got %s", text, tok)
+	}
+}
+
+func (p *parser) fail(tok token, format string, args ...any) {
+	panic(&ParseError{Line: tok.line, Col: tok.col, Msg: fmt.Sprintf(format, args...)})
+}
+
+// The fields that can be given by position: fields outside of oneofs in field number order, up to
+// the first boolean or numeric field, since those read better with their names.
+func positionalFields(desc protoreflect.MessageDescriptor) []protoreflect.FieldDescriptor {
+	fields := slices.DeleteFunc(sortedFields(desc.Fields()), func(fd protoreflect.FieldDescriptor) bool {
+		return fd.ContainingOneof() != nil
+	})
+	for i, fd := range fields {
+		switch fd.Kind() {
+		case protoreflect.MessageKind, protoreflect.StringKind, protoreflect.EnumKind:
+		default:
+			return fields[:i]
+		}
+	}
+	return fields
+}
+
+func enumNames(desc protoreflect.EnumDescriptor) []string {
+	names := make([]string, desc.Values().Len())
+	for i := range names {
+		names[i] = string(desc.Values().Get(i).Name())
+	}
+	return names
+}
+
+// Converts a CamelCase name to snake_case, e.g. CurrentTarget to current_target.
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if 'A' <= r && r <= 'Z' {
+			if i > 0 {
+				sb.WriteByte('_')
+			}
+			r += 'a' - 'A'
+		}
+		sb.WriteRune(r)
+	}
+	return sb.String()
+}
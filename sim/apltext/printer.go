@@ -0,0 +1,315 @@
+package apltext
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Precedence of value operators, from loosest to tightest.
+const (
+	precLowest = iota
+	precOr
+	precAnd
+	precNot
+	precComparison
+	precSum
+	precProduct
+	precOperand
+)
+
+var comparisonSymbols = invert(comparisonOps)
+var mathSymbols = invert(mathOps)
+
+// Formats a rotation in the text syntax, such that Parse gives back the same rotation.
+func Format(rotation *proto.APLRotation) (string, error) {
+	switch rotation.Type {
+	case proto.APLRotation_TypeUnknown, proto.APLRotation_TypeAPL:
+	default:
+		return "", fmt.Errorf("only APL rotations can be formatted, got %s", rotation.Type)
+	}
+
+	var sb strings.Builder
+	for _, prepull := range rotation.PrepullActions {
+		if prepull.Hide {
+			sb.WriteString("hidden ")
+		}
+		sb.WriteString("prepull")
+		if prepull.DoAtValue != nil {
+			sb.WriteString(" " + FormatValue(prepull.DoAtValue))
+		}
+		sb.WriteString(": " + FormatAction(prepull.Action) + "\n")
+	}
+
+	if len(rotation.PrepullActions) > 0 && len(rotation.PriorityList) > 0 {
+		sb.WriteString("\n")
+	}
+	formatItems(&sb, rotation.PriorityList)
+
+	for _, list := range rotation.ActionLists {
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		name := list.Name
+		if !isIdent(name) {
+			name = strconv.Quote(name)
+		}
+		sb.WriteString("list " + name + ":\n")
+		formatItems(&sb, list.Items)
+	}
+	return sb.String(), nil
+}
+
+func formatItems(sb *strings.Builder, items []*proto.APLListItem) {
+	for _, item := range items {
+		if item.Notes != "" {
+			for _, line := range strings.Split(item.Notes, "\n") {
+				if line == "" {
+					sb.WriteString("#\n")
+				} else {
+					sb.WriteString("# " + line + "\n")
+				}
+			}
+		}
+		if item.Hide {
+			sb.WriteString("hidden ")
+		}
+		sb.WriteString(FormatAction(item.Action) + "\n")
+	}
+}
+
+// Whether name can be written without quotes.
+func isIdent(name string) bool {
+	tokens, err := lex(name)
+	return err == nil && len(tokens) == 2 && tokens[0].kind == tokIdent && tokens[0].text == name && !slices.Contains(keywords, name)
+}
+
+// Formats a single action, including its condition.
+func FormatAction(action *proto.APLAction) string {
+	if action == nil {
+		return "{}"
+	}
+
+	m := action.ProtoReflect()
+	text := "{}"
+	if fd := m.WhichOneof(actionOneof); fd != nil {
+		text = formatCall(fd, m.Get(fd).Message())
+	}
+	if action.Condition != nil {
+		text += " if " + FormatValue(action.Condition)
+	}
+	return text
+}
+
+// Formats a single value expression.
+func FormatValue(value *proto.APLValue) string {
+	return formatValue(value, precLowest)
+}
+
+// Formats value, adding parentheses if its operator binds looser than prec.
+func formatValue(value *proto.APLValue, prec int) string {
+	text, valuePrec := formatOperator(value)
+	if text == "" {
+		m := value.ProtoReflect()
+		if fd := m.WhichOneof(valueOneof); fd != nil {
+			text = formatCall(fd, m.Get(fd).Message())
+		} else {
+			text = "{}"
+		}
+		valuePrec = precOperand
+	}
+
+	if valuePrec < prec {
+		return "(" + text + ")"
+	}
+	return text
+}
+
+// Formats operators with their infix syntax. Returns an empty string for values that don't have
+// one, including operators with missing operands, which are formatted as calls instead.
+func formatOperator(value *proto.APLValue) (string, int) {
+	switch v := value.Value.(type) {
+	case *proto.APLValue_Const:
+		// Tunable constants keep their range, so they're formatted as calls.
+		if v.Const.Tunable == nil {
+			return formatConst(v.Const.Val), precOperand
+		}
+	case *proto.APLValue_Or:
+		return formatJoined(v.Or.Vals, " or ", precOr)
+	case *proto.APLValue_And:
+		return formatJoined(v.And.Vals, " and ", precAnd)
+	case *proto.APLValue_Not:
+		if v.Not.Val != nil {
+			return "not " + formatValue(v.Not.Val, precNot), precNot
+		}
+	case *proto.APLValue_Cmp:
+		if symbol, ok := comparisonSymbols[v.Cmp.Op]; ok && v.Cmp.Lhs != nil && v.Cmp.Rhs != nil {
+			// Comparisons don't chain, so operands that are comparisons always need parentheses.
+			return formatValue(v.Cmp.Lhs, precSum) + " " + symbol + " " + formatValue(v.Cmp.Rhs, precSum), precComparison
+		}
+	case *proto.APLValue_Math:
+		if symbol, ok := mathSymbols[v.Math.Op]; ok && v.Math.Lhs != nil && v.Math.Rhs != nil {
+			prec := precSum
+			if symbol == "*" || symbol == "/" {
+				prec = precProduct
+			}
+			return formatValue(v.Math.Lhs, prec) + " " + symbol + " " + formatValue(v.Math.Rhs, prec+1), prec
+		}
+	}
+	return "", 0
+}
+
+func formatJoined(vals []*proto.APLValue, sep string, prec int) (string, int) {
+	if len(vals) < 2 {
+		return "", 0
+	}
+	parts := make([]string, len(vals))
+	for i, val := range vals {
+		// Nested operators of the same kind need parentheses, as they'd be flattened otherwise.
+		parts[i] = formatValue(val, prec+1)
+	}
+	return strings.Join(parts, sep), prec
+}
+
+// Constants are written as is if they read back as a single number or boolean, and quoted
+// otherwise.
+func formatConst(val string) string {
+	if val == "true" || val == "false" {
+		return val
+	}
+	tokens, err := lex(strings.TrimPrefix(val, "-"))
+	if err == nil && len(tokens) == 2 && tokens[0].kind == tokNumber && tokens[0].text == strings.TrimPrefix(val, "-") {
+		return val
+	}
+	return strconv.Quote(val)
+}
+
+// Formats an action or value message as name(args), or just name if it has no arguments.
+func formatCall(fd protoreflect.FieldDescriptor, m protoreflect.Message) string {
+	args := formatArgs(m, positionalFields(m.Descriptor()), "(", ")")
+	if args == "" && slices.Contains(keywords, string(fd.Name())) {
+		// Operators without operands, e.g. not().
+		args = "()"
+	}
+	return string(fd.Name()) + args
+}
+
+// Formats the set fields of m. Leading fields of positional are written without names, and the
+// rest with them. Returns an empty string if there are no positional fields set and the
+// delimiters are parentheses.
+func formatArgs(m protoreflect.Message, positional []protoreflect.FieldDescriptor, opening string, closing string) string {
+	var args []string
+	printed := make(map[protoreflect.FieldNumber]bool)
+	for _, fd := range positional {
+		if !m.Has(fd) {
+			break
+		}
+		printed[fd.Number()] = true
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			for i := 0; i < list.Len(); i++ {
+				args = append(args, formatField(fd, list.Get(i)))
+			}
+			break
+		}
+		args = append(args, formatField(fd, m.Get(fd)))
+	}
+
+	fields := m.Descriptor().Fields()
+	for _, fd := range sortedFields(fields) {
+		if printed[fd.Number()] || !m.Has(fd) {
+			continue
+		}
+		if fd.IsList() {
+			list := m.Get(fd).List()
+			elems := make([]string, list.Len())
+			for i := range elems {
+				elems[i] = formatField(fd, list.Get(i))
+			}
+			args = append(args, string(fd.Name())+"=["+strings.Join(elems, ", ")+"]")
+		} else {
+			args = append(args, string(fd.Name())+"="+formatField(fd, m.Get(fd)))
+		}
+	}
+
+	if len(args) == 0 && opening == "(" {
+		return ""
+	}
+	return opening + strings.Join(args, ", ") + closing
+}
+
+// Formats a single value of fd, or a single element if fd is repeated.
+func formatField(fd protoreflect.FieldDescriptor, v protoreflect.Value) string {
+	switch fd.Kind() {
+	case protoreflect.MessageKind:
+		return formatMessage(v.Message())
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name())
+		}
+		return strconv.Itoa(int(v.Enum()))
+	case protoreflect.StringKind:
+		return strconv.Quote(v.String())
+	case protoreflect.FloatKind:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 32)
+	case protoreflect.DoubleKind:
+		return strconv.FormatFloat(v.Float(), 'f', -1, 64)
+	default:
+		return v.String()
+	}
+}
+
+// Formats a message-typed argument, using the short syntax for the types that have one.
+func formatMessage(m protoreflect.Message) string {
+	switch msg := m.Interface().(type) {
+	case *proto.APLValue:
+		return FormatValue(msg)
+	case *proto.APLAction:
+		return FormatAction(msg)
+	case *proto.ActionID:
+		return formatActionID(msg)
+	case *proto.UnitReference:
+		typeName := proto.UnitReference_Type_name[int32(msg.Type)]
+		if typeName == "" {
+			break
+		}
+		// The type is given by the name, so only the index and owner are arguments.
+		args := &proto.UnitReference{Index: msg.Index, Owner: msg.Owner}
+		return snakeCase(typeName) + formatArgs(args.ProtoReflect(), []protoreflect.FieldDescriptor{unitDesc.Fields().ByName("index")}, "(", ")")
+	}
+	return formatArgs(m, nil, "{", "}")
+}
+
+func formatActionID(actionID *proto.ActionID) string {
+	m := actionID.ProtoReflect()
+	fd := m.WhichOneof(rawIDOneof)
+	if fd == nil {
+		return formatArgs(m, nil, "{", "}")
+	}
+	if actionID.GetSpellId() != 0 && actionID.Tag == 0 && actionID.Rank == 0 {
+		return strconv.Itoa(int(actionID.GetSpellId()))
+	}
+	name := strings.TrimSuffix(string(fd.Name()), "_id")
+	return name + formatArgs(m, []protoreflect.FieldDescriptor{fd}, "(", ")")
+}
+
+func sortedFields(fields protoreflect.FieldDescriptors) []protoreflect.FieldDescriptor {
+	sorted := make([]protoreflect.FieldDescriptor, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		sorted = append(sorted, fields.Get(i))
+	}
+	slices.SortFunc(sorted, func(a, b protoreflect.FieldDescriptor) int { return int(a.Number() - b.Number()) })
+	return sorted
+}
+
+func invert[K comparable, V comparable](m map[K]V) map[V]K {
+	inverted := make(map[V]K, len(m))
+	for k, v := range m {
+		inverted[v] = k
+	}
+	return inverted
+}
@@ -0,0 +1,82 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func TestBuffValues(t *testing.T) {
+	request := combatLogTestRequest(100, 0)
+	result := core.BuffValues(&proto.BuffValuesRequest{
+		RaidSimRequest: request,
+		Fields:         []string{"Consumes.flask", "IndividualBuffs.songflower_serenade", "IndividualBuffs.sayges_fortune", "RaidBuffs.arcane_brilliance"},
+	})
+	if result.Error != nil {
+		t.Fatalf("Buff values failed: %s", result.Error.Message)
+	}
+	if len(result.BaseDps.AllValues) != 0 {
+		t.Fatalf("Expected per-iteration values to be cleared")
+	}
+
+	values := make(map[string]*proto.BuffValue)
+	for i, value := range result.Values {
+		if i > 0 && value.DpsDelta.Mean > result.Values[i-1].DpsDelta.Mean {
+			t.Fatalf("Values aren't sorted by DPS delta")
+		}
+		if value.DpsDelta.Iterations != 100 || value.TpsDelta.Iterations != 100 {
+			t.Fatalf("Expected paired deltas over 100 iterations, got %v and %v", value.DpsDelta, value.TpsDelta)
+		}
+		values[value.Category+"."+value.Field+"="+value.Value] = value
+	}
+
+	for _, key := range []string{
+		"Consumes.flask=FlaskOfSupremePower",
+		"IndividualBuffs.songflower_serenade=true",
+	} {
+		if value, ok := values[key]; !ok {
+			t.Fatalf("Missing buff value for %s", key)
+		} else if value.DpsDelta.Mean <= 0 {
+			t.Fatalf("Expected %s to add DPS, got %v", key, value.DpsDelta)
+		}
+	}
+	// The raid buffs are all on already.
+	if value, ok := values["RaidBuffs.arcane_brilliance=false"]; !ok || value.DpsDelta.Mean >= 0 {
+		t.Fatalf("Expected removing Arcane Brilliance to lose DPS, got %v", value)
+	}
+	// Values of buffs that do nothing for a warlock are exactly zero, as all runs use the same random numbers.
+	if value := values["IndividualBuffs.sayges_fortune=SaygesStamina"]; math.Abs(value.DpsDelta.Mean) > 1e-9 {
+		t.Fatalf("Expected Sayge's Stamina to not change DPS, got %v", value.DpsDelta)
+	}
+	// Every other flask and Sayge's Fortune, along with both bools.
+	if len(values) != len(proto.Flask_name)-1+len(proto.SaygesFortune_name)-1+2 {
+		t.Fatalf("Expected only the chosen fields to be toggled, got %d values", len(values))
+	}
+
+	// By default, only what the player already has is turned off.
+	request.Raid.Parties[0].Players[0].Consumes = &proto.Consumes{Flask: proto.Flask_FlaskOfSupremePower}
+	result = core.BuffValues(&proto.BuffValuesRequest{RaidSimRequest: request})
+	if result.Error != nil {
+		t.Fatalf("Buff values failed: %s", result.Error.Message)
+	}
+	defaults := make(map[string]string)
+	for _, value := range result.Values {
+		if _, ok := defaults[value.Category+"."+value.Field]; ok {
+			t.Fatalf("Expected each field to only be turned off, got %v", value)
+		}
+		defaults[value.Category+"."+value.Field] = value.Value
+	}
+	if defaults["Consumes.flask"] != "FlaskUnknown" || defaults["RaidBuffs.arcane_brilliance"] != "false" {
+		t.Fatalf("Expected the flask and Arcane Brilliance to be turned off, got %v", defaults)
+	}
+	if _, ok := defaults["IndividualBuffs.sayges_fortune"]; ok {
+		t.Fatalf("Expected buffs the player doesn't have to be skipped, got %v", defaults)
+	}
+
+	result = core.BuffValues(&proto.BuffValuesRequest{RaidSimRequest: request, Fields: []string{"Consumes.flaks"}})
+	if result.Error == nil || result.Error.Message != `unknown buff field "Consumes.flaks"` {
+		t.Fatalf("Expected an error for an unknown field, got %v", result.Error)
+	}
+}
@@ -0,0 +1,105 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func combatLogTestRequest(iterations int32, combatLogIterations int32) *proto.RaidSimRequest {
+	return &proto.RaidSimRequest{
+		Raid: core.SinglePlayerRaidProto(
+			&proto.Player{
+				Name:      "Player",
+				Race:      proto.Race_RaceOrc,
+				Class:     proto.Class_ClassWarlock,
+				Equipment: core.GetGearSet("../ui/warlock/gear_sets", "mc").GearSet,
+				Rotation:  core.GetAplRotation("../ui/warlock/apls", "rotation").Rotation,
+				Spec: &proto.Player_Warlock{
+					Warlock: &proto.Warlock{
+						Options: &proto.WarlockOptions{
+							Armor:  proto.WarlockOptions_DemonArmor,
+							Summon: proto.WarlockOptions_Imp,
+						},
+					},
+				},
+			},
+			core.FullPartyBuffs,
+			core.FullRaidBuffs,
+			core.FullDebuffs,
+		),
+		Encounter: &proto.Encounter{
+			Duration: 60,
+			Targets:  []*proto.Target{StandardTarget},
+		},
+		SimOptions: &proto.SimOptions{
+			Iterations:          iterations,
+			RandomSeed:          101,
+			CombatLogIterations: combatLogIterations,
+		},
+	}
+}
+
+func TestCombatLog(t *testing.T) {
+	result := core.RunRaidSim(combatLogTestRequest(5, 2))
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	iterations := map[int32]bool{}
+	var numCasts, numHits, numAuras, numResources int
+	for _, event := range result.CombatLog {
+		iterations[event.Iteration] = true
+		switch event.Event.(type) {
+		case *proto.CombatLogEvent_CastComplete:
+			numCasts++
+			if event.Source == nil {
+				t.Fatalf("Cast without a source: %v", event)
+			}
+		case *proto.CombatLogEvent_Damage:
+			numHits++
+			if event.Target.GetType() != proto.UnitReference_Target {
+				t.Fatalf("Expected damage to land on the target: %v", event)
+			}
+		case *proto.CombatLogEvent_Aura:
+			numAuras++
+		case *proto.CombatLogEvent_Resource:
+			numResources++
+		}
+	}
+
+	if len(iterations) != 2 || !iterations[0] || !iterations[1] {
+		t.Fatalf("Expected events for iterations 0 and 1, got %v", iterations)
+	}
+	if numCasts == 0 || numHits == 0 || numAuras == 0 || numResources == 0 {
+		t.Fatalf("Missing event types: %d casts, %d hits, %d auras, %d resources", numCasts, numHits, numAuras, numResources)
+	}
+
+	result = core.RunRaidSim(combatLogTestRequest(5, 0))
+	if len(result.CombatLog) != 0 {
+		t.Fatalf("Expected no combat log when disabled, got %d events", len(result.CombatLog))
+	}
+}
+
+func TestCombatLogConcurrent(t *testing.T) {
+	const iterations = 20
+	result := core.RunRaidSimConcurrent(combatLogTestRequest(iterations, iterations))
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	// Each split numbers its own iterations, which should be mapped back to the overall ones.
+	seen := map[int32]bool{}
+	for _, event := range result.CombatLog {
+		seen[event.Iteration] = true
+	}
+	for i := int32(0); i < iterations; i++ {
+		if !seen[i] {
+			t.Fatalf("No events for iteration %d", i)
+		}
+	}
+	if len(seen) != iterations {
+		t.Fatalf("Expected events for %d iterations, got %d", iterations, len(seen))
+	}
+}
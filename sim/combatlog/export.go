@@ -0,0 +1,351 @@
+// Package combatlog converts between the sim's structured combat log (proto.CombatLogEvent) and
+// the WoWCombatLog.txt format written by the game client, so sim runs can be compared with real
+// fights in the same analysis tools.
+package combatlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+// Server id used in generated GUIDs. Only needs to be consistent within a log.
+const serverID = 4395
+
+// Unit flags as written by the client: affiliation, reaction, control and type.
+const (
+	flagsPlayer  = 0x511  // Mine, friendly, player controlled, player.
+	flagsPet     = 0x1111 // Mine, friendly, player controlled, pet.
+	flagsHostile = 0xa48  // Outsider, hostile, npc controlled, npc.
+	flagsNone    = 0x80000000
+)
+
+// Power types used by SPELL_ENERGIZE.
+var powerTypes = map[proto.ResourceType]int{
+	proto.ResourceType_ResourceTypeMana:   0,
+	proto.ResourceType_ResourceTypeRage:   1,
+	proto.ResourceType_ResourceTypeFocus:  2,
+	proto.ResourceType_ResourceTypeEnergy: 3,
+}
+
+type unitInfo struct {
+	guid  string
+	name  string
+	flags int
+}
+
+var noUnit = unitInfo{guid: "0000000000000000", name: "nil", flags: flagsNone}
+
+type exporter struct {
+	w     *bufio.Writer
+	start time.Time
+
+	units      map[string]unitInfo
+	spellNames map[int32]string
+	auraStacks map[string]int32
+}
+
+// WriteWoWCombatLog writes the combat log events of one iteration as WoWCombatLog.txt lines.
+// The result must come from a sim run with SimOptions.CombatLogIterations covering the iteration.
+// Event timestamps are offset from start, which is the time of the pull.
+func WriteWoWCombatLog(w io.Writer, request *proto.RaidSimRequest, result *proto.RaidSimResult, iteration int32, start time.Time) error {
+	if len(result.CombatLog) == 0 {
+		return fmt.Errorf("result has no combat log events, set SimOptions.CombatLogIterations to record them")
+	}
+
+	e := &exporter{
+		w:          bufio.NewWriter(w),
+		start:      start,
+		units:      map[string]unitInfo{},
+		spellNames: map[int32]string{},
+		auraStacks: map[string]int32{},
+	}
+	e.registerUnits(request, result)
+
+	// Spells have no names in the sim, so borrow them from buffs with the same id where possible.
+	// Debuff labels are often made unique per caster, so they don't make good names.
+	for _, event := range result.CombatLog {
+		if aura := event.GetAura(); aura != nil && event.ActionId.GetSpellId() != 0 && event.Target.GetType() != proto.UnitReference_Target {
+			e.spellNames[event.ActionId.GetSpellId()] = aura.Label
+		}
+	}
+
+	fmt.Fprintf(e.w, "%s  COMBAT_LOG_VERSION,9,ADVANCED_LOG_ENABLED,0,BUILD_VERSION,1.15.5,PROJECT_ID,2\n", e.timestamp(0))
+	found := false
+	for _, event := range result.CombatLog {
+		if event.Iteration != iteration {
+			continue
+		}
+		found = true
+		e.writeEvent(event)
+	}
+	if !found {
+		return fmt.Errorf("no combat log events for iteration %d", iteration)
+	}
+	return e.w.Flush()
+}
+
+func unitKey(ref *proto.UnitReference) string {
+	if ref == nil {
+		return ""
+	}
+	key := fmt.Sprintf("%s-%d", ref.Type, ref.Index)
+	if ref.Owner != nil {
+		key = unitKey(ref.Owner) + "/" + key
+	}
+	return key
+}
+
+func (e *exporter) registerUnits(request *proto.RaidSimRequest, result *proto.RaidSimResult) {
+	for partyIdx, party := range request.Raid.GetParties() {
+		for playerIdx, player := range party.Players {
+			raidIndex := int32(partyIdx*5 + playerIdx)
+			playerRef := &proto.UnitReference{Type: proto.UnitReference_Player, Index: raidIndex}
+			name := player.Name
+			if name == "" {
+				name = fmt.Sprintf("Player %d", raidIndex+1)
+			}
+			e.units[unitKey(playerRef)] = unitInfo{
+				guid:  fmt.Sprintf("Player-%d-%08X", serverID, raidIndex+1),
+				name:  name,
+				flags: flagsPlayer,
+			}
+
+			if partyIdx >= len(result.RaidMetrics.GetParties()) || playerIdx >= len(result.RaidMetrics.Parties[partyIdx].Players) {
+				continue
+			}
+			for petIdx, pet := range result.RaidMetrics.Parties[partyIdx].Players[playerIdx].Pets {
+				petRef := &proto.UnitReference{Type: proto.UnitReference_Pet, Index: int32(petIdx), Owner: playerRef}
+				e.units[unitKey(petRef)] = unitInfo{
+					guid:  fmt.Sprintf("Pet-0-%d-0-0-0-%010X", serverID, (raidIndex+1)<<8|int32(petIdx+1)),
+					name:  pet.Name,
+					flags: flagsPet,
+				}
+			}
+		}
+	}
+
+	for i, target := range request.Encounter.GetTargets() {
+		name := target.Name
+		if i < len(result.EncounterMetrics.GetTargets()) && result.EncounterMetrics.Targets[i].Name != "" {
+			name = result.EncounterMetrics.Targets[i].Name
+		}
+		ref := &proto.UnitReference{Type: proto.UnitReference_Target, Index: int32(i)}
+		e.units[unitKey(ref)] = unitInfo{
+			guid:  fmt.Sprintf("Creature-0-%d-0-0-%d-%010X", serverID, target.Id, i+1),
+			name:  name,
+			flags: flagsHostile,
+		}
+	}
+}
+
+func (e *exporter) unit(ref *proto.UnitReference) unitInfo {
+	if info, ok := e.units[unitKey(ref)]; ok {
+		return info
+	}
+	return noUnit
+}
+
+func (e *exporter) timestamp(seconds float64) string {
+	return e.start.Add(time.Duration(seconds * float64(time.Second))).Format("1/2 15:04:05.000")
+}
+
+func (e *exporter) spellPrefix(actionID *proto.ActionID, school int32) string {
+	spellID := actionID.GetSpellId()
+	name, ok := e.spellNames[spellID]
+	if !ok {
+		name = fmt.Sprintf("Spell %d", spellID)
+	}
+	return fmt.Sprintf("%d,%s,0x%x", spellID, strconv.Quote(name), gameSchoolMask(school))
+}
+
+// The sim orders its spell school bits differently from the game client.
+var gameSchools = map[core.SpellSchool]int{
+	core.SpellSchoolPhysical: 0x1,
+	core.SpellSchoolHoly:     0x2,
+	core.SpellSchoolFire:     0x4,
+	core.SpellSchoolNature:   0x8,
+	core.SpellSchoolFrost:    0x10,
+	core.SpellSchoolShadow:   0x20,
+	core.SpellSchoolArcane:   0x40,
+}
+
+func gameSchoolMask(simSchool int32) int {
+	mask := 0
+	for school, gameSchool := range gameSchools {
+		if core.SpellSchool(simSchool).Matches(school) {
+			mask |= gameSchool
+		}
+	}
+	return mask
+}
+
+// Writes one line, with source and dest unit fields followed by the given parameters.
+func (e *exporter) writeLine(event *proto.CombatLogEvent, eventType string, source unitInfo, dest unitInfo, params ...string) {
+	fmt.Fprintf(e.w, "%s  %s,%s,%s,0x%x,0x0,%s,%s,0x%x,0x0", e.timestamp(event.Timestamp), eventType,
+		source.guid, strconv.Quote(source.name), source.flags, dest.guid, strconv.Quote(dest.name), dest.flags)
+	for _, param := range params {
+		e.w.WriteString(",")
+		e.w.WriteString(param)
+	}
+	e.w.WriteString("\n")
+}
+
+func (e *exporter) writeEvent(event *proto.CombatLogEvent) {
+	source := e.unit(event.Source)
+	dest := e.unit(event.Target)
+	actionID := event.ActionId
+
+	switch ev := event.Event.(type) {
+	case *proto.CombatLogEvent_CastStart:
+		if actionID.GetSpellId() != 0 {
+			e.writeLine(event, "SPELL_CAST_START", source, dest, e.spellPrefix(actionID, 0))
+		}
+	case *proto.CombatLogEvent_CastComplete:
+		if actionID.GetSpellId() != 0 {
+			e.writeLine(event, "SPELL_CAST_SUCCESS", source, dest, e.spellPrefix(actionID, 0))
+		}
+	case *proto.CombatLogEvent_Damage:
+		e.writeDamage(event, ev.Damage, source, dest)
+	case *proto.CombatLogEvent_Healing:
+		if actionID.GetSpellId() == 0 {
+			return
+		}
+		eventType := "SPELL_HEAL"
+		if ev.Healing.Periodic {
+			eventType = "SPELL_PERIODIC_HEAL"
+		}
+		e.writeLine(event, eventType, source, dest, e.spellPrefix(actionID, ev.Healing.SpellSchool),
+			formatAmount(ev.Healing.Amount), "0", "0", formatBool(ev.Healing.Outcome == proto.HitOutcome_HitOutcomeCrit))
+	case *proto.CombatLogEvent_Aura:
+		e.writeAura(event, ev.Aura, dest)
+	case *proto.CombatLogEvent_Resource:
+		resource := ev.Resource
+		powerType, ok := powerTypes[resource.Type]
+		if !ok || resource.Amount <= 0 || actionID.GetSpellId() == 0 {
+			return
+		}
+		e.writeLine(event, "SPELL_ENERGIZE", source, source, e.spellPrefix(actionID, 0),
+			formatAmount(resource.ActualAmount), formatAmount(resource.Amount-resource.ActualAmount), strconv.Itoa(powerType))
+	}
+}
+
+func (e *exporter) writeDamage(event *proto.CombatLogEvent, hit *proto.CombatLogSpellHit, source unitInfo, dest unitInfo) {
+	actionID := event.ActionId
+
+	var prefix, params []string
+	isOffHand := false
+	switch {
+	case actionID.GetOtherId() == proto.OtherAction_OtherActionAttack:
+		prefix = []string{"SWING"}
+		isOffHand = actionID.Tag == 2
+	case actionID.GetOtherId() == proto.OtherAction_OtherActionShoot:
+		prefix = []string{"RANGE"}
+		params = []string{fmt.Sprintf("75,%s,0x1", strconv.Quote("Auto Shot"))}
+	case actionID.GetSpellId() != 0:
+		prefix = []string{"SPELL"}
+		if hit.Periodic {
+			prefix = append(prefix, "PERIODIC")
+		}
+		params = []string{e.spellPrefix(actionID, hit.SpellSchool)}
+	default:
+		return
+	}
+
+	if missType := missType(hit); missType != "" {
+		eventType := strings.Join(append(prefix, "MISSED"), "_")
+		e.writeLine(event, eventType, source, dest, append(params, missType, formatBool(isOffHand))...)
+		return
+	}
+
+	resisted := 0.0
+	if hit.ResistedQuarters > 0 {
+		resisted = hit.Amount * float64(hit.ResistedQuarters) / float64(4-hit.ResistedQuarters)
+	}
+	eventType := strings.Join(append(prefix, "DAMAGE"), "_")
+	e.writeLine(event, eventType, source, dest, append(params,
+		formatAmount(hit.Amount),
+		"-1", // Overkill
+		strconv.Itoa(max(gameSchoolMask(hit.SpellSchool), 1)),
+		formatAmount(resisted),
+		"0", // Blocked
+		"0", // Absorbed
+		formatBool(hit.Outcome == proto.HitOutcome_HitOutcomeCrit || hit.Outcome == proto.HitOutcome_HitOutcomeBlockedCrit),
+		formatBool(hit.Outcome == proto.HitOutcome_HitOutcomeGlance),
+		formatBool(hit.Outcome == proto.HitOutcome_HitOutcomeCrush),
+		formatBool(isOffHand),
+	)...)
+}
+
+func (e *exporter) writeAura(event *proto.CombatLogEvent, aura *proto.CombatLogAura, dest unitInfo) {
+	actionID := event.ActionId
+	if actionID.GetSpellId() == 0 {
+		return
+	}
+
+	auraType := "BUFF"
+	if dest.flags == flagsHostile {
+		auraType = "DEBUFF"
+	}
+
+	// Auras have no caster in the sim, so buffs are attributed to their owner.
+	source := noUnit
+	if auraType == "BUFF" {
+		source = dest
+	}
+
+	stacksKey := unitKey(event.Target) + "/" + aura.Label
+	params := []string{e.spellPrefix(actionID, 0), auraType}
+	switch aura.Type {
+	case proto.CombatLogAura_Gained:
+		e.auraStacks[stacksKey] = 0
+		e.writeLine(event, "SPELL_AURA_APPLIED", source, dest, params...)
+	case proto.CombatLogAura_Faded:
+		delete(e.auraStacks, stacksKey)
+		e.writeLine(event, "SPELL_AURA_REMOVED", source, dest, params...)
+	case proto.CombatLogAura_Refreshed:
+		e.writeLine(event, "SPELL_AURA_REFRESH", source, dest, params...)
+	case proto.CombatLogAura_StacksChanged:
+		oldStacks := e.auraStacks[stacksKey]
+		e.auraStacks[stacksKey] = aura.Stacks
+		// The first stack is part of SPELL_AURA_APPLIED, and the last one of SPELL_AURA_REMOVED.
+		if aura.Stacks > oldStacks && aura.Stacks > 1 {
+			e.writeLine(event, "SPELL_AURA_APPLIED_DOSE", source, dest, append(params, strconv.Itoa(int(aura.Stacks)))...)
+		} else if aura.Stacks < oldStacks && aura.Stacks > 0 {
+			e.writeLine(event, "SPELL_AURA_REMOVED_DOSE", source, dest, append(params, strconv.Itoa(int(aura.Stacks)))...)
+		}
+	}
+}
+
+func missType(hit *proto.CombatLogSpellHit) string {
+	switch hit.Outcome {
+	case proto.HitOutcome_HitOutcomeMiss:
+		if hit.SpellSchool != int32(core.SpellSchoolPhysical) {
+			return "RESIST"
+		}
+		return "MISS"
+	case proto.HitOutcome_HitOutcomeDodge:
+		return "DODGE"
+	case proto.HitOutcome_HitOutcomeParry:
+		return "PARRY"
+	}
+	return ""
+}
+
+func formatAmount(amount float64) string {
+	return strconv.Itoa(int(math.Round(amount)))
+}
+
+func formatBool(b bool) string {
+	if b {
+		return "1"
+	}
+	return "nil"
+}
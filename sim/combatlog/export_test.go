@@ -0,0 +1,101 @@
+package combatlog
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+var (
+	player = &proto.UnitReference{Type: proto.UnitReference_Player, Index: 0}
+	pet    = &proto.UnitReference{Type: proto.UnitReference_Pet, Index: 0, Owner: player}
+	boss   = &proto.UnitReference{Type: proto.UnitReference_Target, Index: 0}
+)
+
+func testRequest() *proto.RaidSimRequest {
+	return &proto.RaidSimRequest{
+		Raid: core.SinglePlayerRaidProto(&proto.Player{Name: "Sim"}, nil, nil, nil),
+		Encounter: &proto.Encounter{
+			Targets: []*proto.Target{{Id: 11502, Name: "Ragnaros"}},
+		},
+	}
+}
+
+func testResult(events ...*proto.CombatLogEvent) *proto.RaidSimResult {
+	return &proto.RaidSimResult{
+		RaidMetrics: &proto.RaidMetrics{
+			Parties: []*proto.PartyMetrics{{
+				Players: []*proto.UnitMetrics{{Name: "Sim", Pets: []*proto.UnitMetrics{{Name: "Imp"}}}},
+			}},
+		},
+		CombatLog: events,
+	}
+}
+
+func TestWriteWoWCombatLog(t *testing.T) {
+	shadowBolt := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 25307}}
+	meleeOH := &proto.ActionID{RawId: &proto.ActionID_OtherId{OtherId: proto.OtherAction_OtherActionAttack}, Tag: 2}
+	shadowVuln := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 17800}}
+	corruption := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 25311}}
+	shadowTrance := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 17941}}
+
+	result := testResult(
+		&proto.CombatLogEvent{Timestamp: 0.5, Source: player, Target: boss, ActionId: shadowBolt,
+			Event: &proto.CombatLogEvent_CastStart{CastStart: &proto.CombatLogCastStart{CastTime: 2.5}}},
+		&proto.CombatLogEvent{Timestamp: 3, Source: player, Target: boss, ActionId: shadowBolt,
+			Event: &proto.CombatLogEvent_Damage{Damage: &proto.CombatLogSpellHit{
+				Outcome: proto.HitOutcome_HitOutcomeCrit, ResistedQuarters: 1, Amount: 1500, SpellSchool: int32(core.SpellSchoolShadow)}}},
+		&proto.CombatLogEvent{Timestamp: 3, Target: boss, ActionId: shadowVuln,
+			Event: &proto.CombatLogEvent_Aura{Aura: &proto.CombatLogAura{Type: proto.CombatLogAura_Gained, Label: "Shadow Vulnerability"}}},
+		&proto.CombatLogEvent{Timestamp: 3, Target: boss, ActionId: shadowVuln,
+			Event: &proto.CombatLogEvent_Aura{Aura: &proto.CombatLogAura{Type: proto.CombatLogAura_StacksChanged, Label: "Shadow Vulnerability", Stacks: 1}}},
+		&proto.CombatLogEvent{Timestamp: 4, Target: boss, ActionId: shadowVuln,
+			Event: &proto.CombatLogEvent_Aura{Aura: &proto.CombatLogAura{Type: proto.CombatLogAura_StacksChanged, Label: "Shadow Vulnerability", Stacks: 2}}},
+		&proto.CombatLogEvent{Timestamp: 5, Source: pet, Target: boss, ActionId: meleeOH,
+			Event: &proto.CombatLogEvent_Damage{Damage: &proto.CombatLogSpellHit{
+				Outcome: proto.HitOutcome_HitOutcomeDodge, SpellSchool: int32(core.SpellSchoolPhysical), Swing: true}}},
+		&proto.CombatLogEvent{Timestamp: 6, Source: player, Target: boss, ActionId: corruption,
+			Event: &proto.CombatLogEvent_Damage{Damage: &proto.CombatLogSpellHit{
+				Outcome: proto.HitOutcome_HitOutcomeHit, Amount: 200, SpellSchool: int32(core.SpellSchoolShadow), Periodic: true}}},
+		&proto.CombatLogEvent{Timestamp: 6, Target: player, ActionId: shadowTrance,
+			Event: &proto.CombatLogEvent_Aura{Aura: &proto.CombatLogAura{Type: proto.CombatLogAura_Faded, Label: "Shadow Trance"}}},
+		// Other iterations are left out.
+		&proto.CombatLogEvent{Iteration: 1, Timestamp: 1, Source: player, Target: boss, ActionId: shadowBolt,
+			Event: &proto.CombatLogEvent_CastComplete{CastComplete: &proto.CombatLogCastComplete{}}},
+	)
+
+	sb := &strings.Builder{}
+	start := time.Date(2024, 4, 7, 20, 0, 0, 0, time.UTC)
+	if err := WriteWoWCombatLog(sb, testRequest(), result, 0, start); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		`4/7 20:00:00.000  COMBAT_LOG_VERSION,9,ADVANCED_LOG_ENABLED,0,BUILD_VERSION,1.15.5,PROJECT_ID,2`,
+		`4/7 20:00:00.500  SPELL_CAST_START,Player-4395-00000001,"Sim",0x511,0x0,Creature-0-4395-0-0-11502-0000000001,"Ragnaros",0xa48,0x0,25307,"Spell 25307",0x0`,
+		`4/7 20:00:03.000  SPELL_DAMAGE,Player-4395-00000001,"Sim",0x511,0x0,Creature-0-4395-0-0-11502-0000000001,"Ragnaros",0xa48,0x0,25307,"Spell 25307",0x20,1500,-1,32,500,0,0,1,nil,nil,nil`,
+		`4/7 20:00:03.000  SPELL_AURA_APPLIED,0000000000000000,"nil",0x80000000,0x0,Creature-0-4395-0-0-11502-0000000001,"Ragnaros",0xa48,0x0,17800,"Spell 17800",0x0,DEBUFF`,
+		`4/7 20:00:04.000  SPELL_AURA_APPLIED_DOSE,0000000000000000,"nil",0x80000000,0x0,Creature-0-4395-0-0-11502-0000000001,"Ragnaros",0xa48,0x0,17800,"Spell 17800",0x0,DEBUFF,2`,
+		`4/7 20:00:05.000  SWING_MISSED,Pet-0-4395-0-0-0-0000000101,"Imp",0x1111,0x0,Creature-0-4395-0-0-11502-0000000001,"Ragnaros",0xa48,0x0,DODGE,1`,
+		`4/7 20:00:06.000  SPELL_PERIODIC_DAMAGE,Player-4395-00000001,"Sim",0x511,0x0,Creature-0-4395-0-0-11502-0000000001,"Ragnaros",0xa48,0x0,25311,"Spell 25311",0x20,200,-1,32,0,0,0,nil,nil,nil,nil`,
+		`4/7 20:00:06.000  SPELL_AURA_REMOVED,Player-4395-00000001,"Sim",0x511,0x0,Player-4395-00000001,"Sim",0x511,0x0,17941,"Shadow Trance",0x0,BUFF`,
+	}, "\n") + "\n"
+
+	if got := sb.String(); got != want {
+		t.Fatalf("Unexpected combat log.\nGot:\n%s\nWant:\n%s", got, want)
+	}
+}
+
+func TestWriteWoWCombatLogMissingIteration(t *testing.T) {
+	if err := WriteWoWCombatLog(&strings.Builder{}, testRequest(), testResult(), 0, time.Now()); err == nil {
+		t.Fatalf("Expected an error without combat log events")
+	}
+
+	result := testResult(&proto.CombatLogEvent{Iteration: 0, Source: player})
+	if err := WriteWoWCombatLog(&strings.Builder{}, testRequest(), result, 3, time.Now()); err == nil {
+		t.Fatalf("Expected an error for an iteration without events")
+	}
+}
@@ -0,0 +1,523 @@
+package combatlog
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+type ImportOptions struct {
+	// Name or GUID of the player to import. May be left empty if the log has only one player.
+	Player string
+
+	// Name, NPC id or GUID of the boss. Defaults to the creature the player hit most often.
+	Boss string
+
+	// Minimum uptime, between 0 and 1, for a buff or debuff to be enabled in the request.
+	// Defaults to 0.5.
+	UptimeThreshold float64
+}
+
+// Import is what could be learned about a fight from a WoWCombatLog.txt segment.
+type Import struct {
+	// Request skeleton with the fight duration, the boss and the observed raid buffs and debuffs.
+	// The player still needs a class, gear and rotation before it can be simmed.
+	Request *proto.RaidSimRequest
+
+	// Time from the first hit between the player and the boss until the boss died, or until the
+	// last hit if it didn't, in seconds.
+	Duration float64
+
+	// Successful casts by the player during the fight, keyed like the sim's cast test results.
+	Casts *proto.CastsTestResult
+
+	// Fraction of the fight each aura was active, keyed by spell id. Buffs are taken from the
+	// player, debuffs from the boss.
+	BuffUptimes   map[int32]float64
+	DebuffUptimes map[int32]float64
+
+	// Whether the boss NPC id matched a preset target registered with core.AddPresetTarget.
+	PresetTarget bool
+}
+
+// CastsPerMinute returns the imported cast counts divided by the fight duration in minutes.
+func (imp *Import) CastsPerMinute() *proto.CastsTestResult {
+	return perMinute(imp.Casts.Casts, imp.Duration)
+}
+
+type logLine struct {
+	time      time.Time
+	eventType string
+	fields    []string
+}
+
+func (l *logLine) source() string     { return l.field(0) }
+func (l *logLine) sourceName() string { return l.field(1) }
+func (l *logLine) dest() string       { return l.field(4) }
+func (l *logLine) destName() string   { return l.field(5) }
+
+func (l *logLine) field(i int) string {
+	if i < len(l.fields) {
+		return l.fields[i]
+	}
+	return ""
+}
+
+// Spell id of SPELL_* and RANGE_* events.
+func (l *logLine) spellID() int32 {
+	id, _ := strconv.Atoi(l.field(8))
+	return int32(id)
+}
+
+// ReadWoWCombatLog imports a fight between one player and one boss from WoWCombatLog.txt lines.
+// The log should hold a single pull; cut it down to the relevant segment first if it doesn't.
+func ReadWoWCombatLog(r io.Reader, options ImportOptions) (*Import, error) {
+	if options.UptimeThreshold == 0 {
+		options.UptimeThreshold = 0.5
+	}
+
+	var lines []*logLine
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	lineNumber := 0
+	var prevTime time.Time
+	for scanner.Scan() {
+		lineNumber++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		line, err := parseLine(text)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		// Timestamps have no year, so logs running past midnight on new year's eve jump backwards.
+		for !prevTime.IsZero() && prevTime.Sub(line.time) > 12*time.Hour {
+			line.time = line.time.AddDate(1, 0, 0)
+		}
+		prevTime = line.time
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	playerGUID, playerName, err := findPlayer(lines, options.Player)
+	if err != nil {
+		return nil, err
+	}
+	bossGUID, bossName, err := findBoss(lines, playerGUID, options.Boss)
+	if err != nil {
+		return nil, err
+	}
+
+	var start, end time.Time
+	for _, line := range lines {
+		if !isHit(line.eventType) {
+			continue
+		}
+		if (line.source() == playerGUID && line.dest() == bossGUID) || (line.source() == bossGUID && line.dest() == playerGUID) {
+			if start.IsZero() {
+				start = line.time
+			}
+			end = line.time
+		}
+		if line.eventType == "UNIT_DIED" && line.dest() == bossGUID && !start.IsZero() {
+			end = line.time
+			break
+		}
+	}
+	duration := end.Sub(start).Seconds()
+	if duration <= 0 {
+		return nil, fmt.Errorf("no fight between %s and %s found", playerName, bossName)
+	}
+
+	imp := &Import{
+		Duration:      duration,
+		Casts:         &proto.CastsTestResult{Casts: map[string]float64{}},
+		BuffUptimes:   map[int32]float64{},
+		DebuffUptimes: map[int32]float64{},
+	}
+
+	buffs := newUptimeTracker()
+	debuffs := newUptimeTracker()
+	for _, line := range lines {
+		inFight := !line.time.Before(start) && !line.time.After(end)
+		switch {
+		case line.eventType == "SPELL_CAST_SUCCESS" && line.source() == playerGUID && inFight:
+			imp.Casts.Casts[core.ActionID{SpellID: line.spellID()}.String()]++
+		case strings.HasPrefix(line.eventType, "SPELL_AURA_") && line.dest() == playerGUID:
+			buffs.add(line)
+		case strings.HasPrefix(line.eventType, "SPELL_AURA_") && line.dest() == bossGUID:
+			debuffs.add(line)
+		}
+	}
+	buffs.uptimes(start, end, imp.BuffUptimes)
+	debuffs.uptimes(start, end, imp.DebuffUptimes)
+
+	raidBuffs := &proto.RaidBuffs{}
+	for spellID, uptime := range imp.BuffUptimes {
+		if setBuff, ok := raidBuffsBySpell[spellID]; ok && uptime >= options.UptimeThreshold {
+			setBuff(raidBuffs)
+		}
+	}
+	debuffProto := &proto.Debuffs{}
+	for spellID, uptime := range imp.DebuffUptimes {
+		if setDebuff, ok := debuffsBySpell[spellID]; ok && uptime >= options.UptimeThreshold {
+			setDebuff(debuffProto)
+		}
+	}
+
+	npcID := npcIDFromGUID(bossGUID)
+	var target *proto.Target
+	if preset := core.GetPresetTargetWithID(npcID); preset != nil && npcID != 0 {
+		target = googleProto.Clone(preset.Config).(*proto.Target)
+		imp.PresetTarget = true
+	} else {
+		target = googleProto.Clone(core.NewDefaultTarget()).(*proto.Target)
+		target.Id = npcID
+		target.Name = bossName
+	}
+
+	imp.Request = &proto.RaidSimRequest{
+		Raid: core.SinglePlayerRaidProto(&proto.Player{Name: playerName}, &proto.PartyBuffs{}, raidBuffs, debuffProto),
+		Encounter: &proto.Encounter{
+			Duration: duration,
+			Targets:  []*proto.Target{target},
+		},
+		SimOptions: &proto.SimOptions{
+			Iterations: 3000,
+		},
+	}
+	return imp, nil
+}
+
+// Accepts both the "4/7 20:00:00.000" timestamps of older clients and the
+// "4/7/2024 20:00:00.0000-4" ones written since 2024.
+func parseLine(text string) (*logLine, error) {
+	timestamp, rest, ok := strings.Cut(text, "  ")
+	if !ok {
+		return nil, fmt.Errorf("missing timestamp")
+	}
+
+	var t time.Time
+	var err error
+	if strings.Count(timestamp, "/") == 2 {
+		if i := strings.LastIndexAny(timestamp, "+-"); i > strings.Index(timestamp, " ") {
+			timestamp = timestamp[:i]
+		}
+		t, err = time.Parse("1/2/2006 15:04:05.999999999", timestamp)
+	} else {
+		t, err = time.Parse("1/2 15:04:05.999999999", timestamp)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp %q", timestamp)
+	}
+
+	fields := splitFields(rest)
+	return &logLine{time: t, eventType: fields[0], fields: fields[1:]}, nil
+}
+
+// Splits comma separated fields, keeping commas inside quoted names and unquoting them.
+func splitFields(text string) []string {
+	var fields []string
+	var sb strings.Builder
+	quoted := false
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '"':
+			quoted = !quoted
+		case c == '\\' && quoted && i+1 < len(text):
+			i++
+			sb.WriteByte(text[i])
+		case c == ',' && !quoted:
+			fields = append(fields, sb.String())
+			sb.Reset()
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return append(fields, sb.String())
+}
+
+func isHit(eventType string) bool {
+	return strings.HasSuffix(eventType, "_DAMAGE") || strings.HasSuffix(eventType, "_MISSED") || eventType == "UNIT_DIED"
+}
+
+// Names in cross-realm logs carry a realm suffix, which can be left out.
+func nameMatches(logName string, name string) bool {
+	return strings.EqualFold(logName, name) || strings.EqualFold(strings.SplitN(logName, "-", 2)[0], name)
+}
+
+func findPlayer(lines []*logLine, player string) (string, string, error) {
+	names := map[string]string{}
+	for _, line := range lines {
+		if strings.HasPrefix(line.source(), "Player-") {
+			names[line.source()] = line.sourceName()
+		}
+	}
+
+	var matches []string
+	for guid, name := range names {
+		if player == "" || guid == player || nameMatches(name, player) {
+			matches = append(matches, guid)
+		}
+	}
+	switch {
+	case len(matches) == 1:
+		return matches[0], strings.SplitN(names[matches[0]], "-", 2)[0], nil
+	case len(matches) == 0 && player != "":
+		return "", "", fmt.Errorf("no player %q in combat log", player)
+	case len(matches) == 0:
+		return "", "", fmt.Errorf("no players in combat log")
+	}
+	all := make([]string, 0, len(matches))
+	for _, guid := range matches {
+		all = append(all, names[guid])
+	}
+	slices.Sort(all)
+	return "", "", fmt.Errorf("combat log has several players, choose one of: %s", strings.Join(all, ", "))
+}
+
+func findBoss(lines []*logLine, playerGUID string, boss string) (string, string, error) {
+	names := map[string]string{}
+	hits := map[string]int{}
+	for _, line := range lines {
+		if !strings.HasPrefix(line.dest(), "Creature-") {
+			continue
+		}
+		guid := line.dest()
+		names[guid] = line.destName()
+		if boss != "" {
+			if guid == boss || nameMatches(line.destName(), boss) || strconv.Itoa(int(npcIDFromGUID(guid))) == boss {
+				return guid, line.destName(), nil
+			}
+			continue
+		}
+		if line.source() == playerGUID && isHit(line.eventType) {
+			hits[guid]++
+		}
+	}
+	if boss != "" {
+		return "", "", fmt.Errorf("no boss %q in combat log", boss)
+	}
+
+	bossGUID := ""
+	for guid, count := range hits {
+		if bossGUID == "" || count > hits[bossGUID] || (count == hits[bossGUID] && guid < bossGUID) {
+			bossGUID = guid
+		}
+	}
+	if bossGUID == "" {
+		return "", "", fmt.Errorf("player didn't hit any creature")
+	}
+	return bossGUID, names[bossGUID], nil
+}
+
+// Creature GUIDs look like Creature-0-<server>-<instance>-<zone>-<npc id>-<spawn uid>.
+func npcIDFromGUID(guid string) int32 {
+	parts := strings.Split(guid, "-")
+	if len(parts) != 7 {
+		return 0
+	}
+	id, _ := strconv.Atoi(parts[5])
+	return int32(id)
+}
+
+type interval struct {
+	start time.Time
+	end   time.Time
+}
+
+// Tracks when auras were active on a unit. Auras already active when the log starts only show up
+// when they are refreshed or removed, and are counted from the start of the log.
+type uptimeTracker struct {
+	active    map[int32]time.Time
+	intervals map[int32][]interval
+}
+
+func newUptimeTracker() *uptimeTracker {
+	return &uptimeTracker{
+		active:    map[int32]time.Time{},
+		intervals: map[int32][]interval{},
+	}
+}
+
+func (ut *uptimeTracker) add(line *logLine) {
+	spellID := line.spellID()
+	gainedAt, isActive := ut.active[spellID]
+	switch line.eventType {
+	case "SPELL_AURA_APPLIED":
+		if !isActive {
+			ut.active[spellID] = line.time
+		}
+	case "SPELL_AURA_REMOVED":
+		if !isActive {
+			gainedAt = time.Time{}
+		}
+		ut.intervals[spellID] = append(ut.intervals[spellID], interval{start: gainedAt, end: line.time})
+		delete(ut.active, spellID)
+	default:
+		if !isActive {
+			ut.active[spellID] = time.Time{}
+		}
+	}
+}
+
+func (ut *uptimeTracker) uptimes(start time.Time, end time.Time, uptimes map[int32]float64) {
+	for spellID, gainedAt := range ut.active {
+		ut.intervals[spellID] = append(ut.intervals[spellID], interval{start: gainedAt, end: end})
+	}
+	duration := end.Sub(start).Seconds()
+	for spellID, intervals := range ut.intervals {
+		active := 0.0
+		for _, iv := range intervals {
+			ivStart := iv.start
+			if ivStart.IsZero() || ivStart.Before(start) {
+				ivStart = start
+			}
+			ivEnd := iv.end
+			if ivEnd.After(end) {
+				ivEnd = end
+			}
+			active += max(ivEnd.Sub(ivStart).Seconds(), 0)
+		}
+		if active > 0 {
+			uptimes[spellID] = min(active/duration, 1)
+		}
+	}
+}
+
+// Max rank spell ids of the auras behind each raid buff option.
+var raidBuffsBySpell = map[int32]func(*proto.RaidBuffs){
+	9885:  func(b *proto.RaidBuffs) { b.GiftOfTheWild = proto.TristateEffect_TristateEffectRegular }, // Mark of the Wild
+	21850: func(b *proto.RaidBuffs) { b.GiftOfTheWild = proto.TristateEffect_TristateEffectRegular },
+	10938: func(b *proto.RaidBuffs) { b.PowerWordFortitude = proto.TristateEffect_TristateEffectRegular },
+	21564: func(b *proto.RaidBuffs) { b.PowerWordFortitude = proto.TristateEffect_TristateEffectRegular }, // Prayer of Fortitude
+	11767: func(b *proto.RaidBuffs) { b.BloodPact = proto.TristateEffect_TristateEffectRegular },
+	25361: func(b *proto.RaidBuffs) { b.StrengthOfEarthTotem = proto.TristateEffect_TristateEffectRegular },
+	25359: func(b *proto.RaidBuffs) { b.GraceOfAirTotem = proto.TristateEffect_TristateEffectRegular },
+	10157: func(b *proto.RaidBuffs) { b.ArcaneBrilliance = true }, // Arcane Intellect
+	23028: func(b *proto.RaidBuffs) { b.ArcaneBrilliance = true },
+	27841: func(b *proto.RaidBuffs) { b.DivineSpirit = true },
+	27681: func(b *proto.RaidBuffs) { b.DivineSpirit = true }, // Prayer of Spirit
+	25289: func(b *proto.RaidBuffs) { b.BattleShout = proto.TristateEffect_TristateEffectRegular },
+	20906: func(b *proto.RaidBuffs) { b.TrueshotAura = true },
+	24932: func(b *proto.RaidBuffs) { b.LeaderOfThePack = true },
+	24907: func(b *proto.RaidBuffs) { b.MoonkinAura = true },
+	10497: func(b *proto.RaidBuffs) { b.ManaSpringTotem = proto.TristateEffect_TristateEffectRegular },
+	19854: func(b *proto.RaidBuffs) { b.BlessingOfWisdom = proto.TristateEffect_TristateEffectRegular },
+	25918: func(b *proto.RaidBuffs) { b.BlessingOfWisdom = proto.TristateEffect_TristateEffectRegular }, // Greater Blessing of Wisdom
+	10958: func(b *proto.RaidBuffs) { b.ShadowProtection = true },
+	27683: func(b *proto.RaidBuffs) { b.ShadowProtection = true }, // Prayer of Shadow Protection
+	9910:  func(b *proto.RaidBuffs) { b.Thorns = proto.TristateEffect_TristateEffectRegular },
+	10293: func(b *proto.RaidBuffs) { b.DevotionAura = proto.TristateEffect_TristateEffectRegular },
+	10408: func(b *proto.RaidBuffs) { b.StoneskinTotem = proto.TristateEffect_TristateEffectRegular },
+	10301: func(b *proto.RaidBuffs) { b.RetributionAura = proto.TristateEffect_TristateEffectRegular },
+	20218: func(b *proto.RaidBuffs) { b.SanctityAura = true },
+	23060: func(b *proto.RaidBuffs) { b.BattleSquawk = 1 },
+}
+
+// Max rank spell ids of the auras behind each debuff option.
+var debuffsBySpell = map[int32]func(*proto.Debuffs){
+	20355: func(d *proto.Debuffs) { d.JudgementOfWisdom = true },
+	20346: func(d *proto.Debuffs) { d.JudgementOfLight = true },
+	20303: func(d *proto.Debuffs) { d.JudgementOfTheCrusader = proto.TristateEffect_TristateEffectRegular },
+	9907:  func(d *proto.Debuffs) { d.FaerieFire = true },
+	17392: func(d *proto.Debuffs) { d.FaerieFire = true }, // Faerie Fire (Feral)
+	11722: func(d *proto.Debuffs) { d.CurseOfElements = true },
+	17937: func(d *proto.Debuffs) { d.CurseOfShadow = true },
+	12579: func(d *proto.Debuffs) { d.WintersChill = true },
+	17800: func(d *proto.Debuffs) { d.ImprovedShadowBolt = true },
+	22959: func(d *proto.Debuffs) { d.ImprovedScorch = true },
+	15258: func(d *proto.Debuffs) { d.ShadowWeaving = true },
+	17364: func(d *proto.Debuffs) { d.Stormstrike = true },
+	11198: func(d *proto.Debuffs) { d.ExposeArmor = proto.TristateEffect_TristateEffectRegular },
+	11597: func(d *proto.Debuffs) { d.SunderArmor = true },
+	11708: func(d *proto.Debuffs) { d.CurseOfWeakness = proto.TristateEffect_TristateEffectRegular },
+	11717: func(d *proto.Debuffs) { d.CurseOfRecklessness = true },
+	9898:  func(d *proto.Debuffs) { d.DemoralizingRoar = proto.TristateEffect_TristateEffectRegular },
+	11556: func(d *proto.Debuffs) { d.DemoralizingShout = proto.TristateEffect_TristateEffectRegular },
+	11581: func(d *proto.Debuffs) { d.ThunderClap = proto.TristateEffect_TristateEffectRegular },
+	21992: func(d *proto.Debuffs) { d.Thunderfury = true },
+	24977: func(d *proto.Debuffs) { d.InsectSwarm = true },
+	14277: func(d *proto.Debuffs) { d.ScorpidSting = true },
+	14325: func(d *proto.Debuffs) { d.HuntersMark = proto.TristateEffect_TristateEffectRegular },
+	11374: func(d *proto.Debuffs) { d.GiftOfArthas = true },
+	15235: func(d *proto.Debuffs) { d.CrystalYield = true },
+}
+
+// SimCastsPerMinute returns the average casts per minute of each action of a player in a sim
+// result, keyed like Import.Casts. Ranks and targets of the same spell are added together.
+func SimCastsPerMinute(result *proto.RaidSimResult, raidIndex int) (*proto.CastsTestResult, error) {
+	parties := result.RaidMetrics.GetParties()
+	partyIdx, playerIdx := raidIndex/5, raidIndex%5
+	if partyIdx >= len(parties) || playerIdx >= len(parties[partyIdx].Players) {
+		return nil, fmt.Errorf("no player with raid index %d in sim result", raidIndex)
+	}
+	if result.IterationsDone == 0 {
+		return nil, fmt.Errorf("sim result has no iterations")
+	}
+
+	casts := map[string]float64{}
+	for _, metric := range parties[partyIdx].Players[playerIdx].Actions {
+		name := core.ProtoToActionID(metric.Id).WithTag(0).String()
+		for _, targetMetrics := range metric.Targets {
+			casts[name] += float64(targetMetrics.Casts)
+		}
+	}
+	for name := range casts {
+		casts[name] /= float64(result.IterationsDone)
+	}
+	return perMinute(casts, result.AvgIterationDuration), nil
+}
+
+func perMinute(casts map[string]float64, duration float64) *proto.CastsTestResult {
+	result := &proto.CastsTestResult{Casts: make(map[string]float64, len(casts))}
+	for name, count := range casts {
+		result.Casts[name] = count / duration * 60
+	}
+	return result
+}
+
+// WriteCastsComparison writes a table of observed vs simulated casts per minute, with the actions
+// that differ most first.
+func WriteCastsComparison(w io.Writer, observed *proto.CastsTestResult, simmed *proto.CastsTestResult) error {
+	var names []string
+	for name := range observed.Casts {
+		names = append(names, name)
+	}
+	for name := range simmed.Casts {
+		if _, ok := observed.Casts[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		diffA := math.Abs(simmed.Casts[a] - observed.Casts[a])
+		diffB := math.Abs(simmed.Casts[b] - observed.Casts[b])
+		if diffA != diffB {
+			if diffA > diffB {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "%-28s %10s %10s %10s\n", "Action", "Real CPM", "Sim CPM", "Diff")
+	for _, name := range names {
+		fmt.Fprintf(bw, "%-28s %10.2f %10.2f %+10.2f\n", name, observed.Casts[name], simmed.Casts[name], simmed.Casts[name]-observed.Casts[name])
+	}
+	return bw.Flush()
+}
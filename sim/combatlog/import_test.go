@@ -0,0 +1,132 @@
+package combatlog
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	_ "github.com/wowsims/classic/sim/encounters"
+)
+
+func TestReadWoWCombatLog(t *testing.T) {
+	shadowBolt := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 25307}}
+	shadowVuln := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 17800}}
+	curseOfShadow := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 17937}}
+	fortitude := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 10938}}
+	bloodPact := &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 11767}}
+
+	aura := func(timestamp float64, target *proto.UnitReference, actionID *proto.ActionID, auraType proto.CombatLogAura_Type) *proto.CombatLogEvent {
+		return &proto.CombatLogEvent{Timestamp: timestamp, Target: target, ActionId: actionID,
+			Event: &proto.CombatLogEvent_Aura{Aura: &proto.CombatLogAura{Type: auraType, Label: "Aura"}}}
+	}
+	events := []*proto.CombatLogEvent{
+		// Active since before the pull.
+		aura(5, player, fortitude, proto.CombatLogAura_Refreshed),
+		aura(0, player, bloodPact, proto.CombatLogAura_Gained),
+		aura(3, player, bloodPact, proto.CombatLogAura_Faded),
+		aura(2, boss, curseOfShadow, proto.CombatLogAura_Gained),
+		aura(4, boss, shadowVuln, proto.CombatLogAura_Gained),
+		aura(6, boss, shadowVuln, proto.CombatLogAura_Faded),
+	}
+	for _, timestamp := range []float64{2, 5, 8, 11, 12} {
+		events = append(events,
+			&proto.CombatLogEvent{Timestamp: timestamp, Source: player, Target: boss, ActionId: shadowBolt,
+				Event: &proto.CombatLogEvent_CastComplete{CastComplete: &proto.CombatLogCastComplete{}}},
+			&proto.CombatLogEvent{Timestamp: timestamp, Source: player, Target: boss, ActionId: shadowBolt,
+				Event: &proto.CombatLogEvent_Damage{Damage: &proto.CombatLogSpellHit{Amount: 1000, SpellSchool: int32(core.SpellSchoolShadow)}}})
+	}
+
+	request := testRequest()
+	request.Encounter.Targets[0] = &proto.Target{Id: 13020, Name: "Vaelastrasz the Corrupt"}
+	sb := &strings.Builder{}
+	if err := WriteWoWCombatLog(sb, request, testResult(events...), 0, time.Date(2024, 4, 7, 20, 0, 0, 0, time.UTC)); err != nil {
+		t.Fatal(err)
+	}
+
+	imp, err := ReadWoWCombatLog(strings.NewReader(sb.String()), ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if imp.Duration != 10 {
+		t.Fatalf("Expected a duration of 10s, got %f", imp.Duration)
+	}
+	if got := imp.Casts.Casts["{SpellID: 25307}"]; got != 5 {
+		t.Fatalf("Expected 5 Shadow Bolt casts, got %f", got)
+	}
+	if got := imp.CastsPerMinute().Casts["{SpellID: 25307}"]; got != 30 {
+		t.Fatalf("Expected 30 Shadow Bolt casts per minute, got %f", got)
+	}
+
+	wantUptimes := map[int32]float64{10938: 1, 11767: 0.1, 17937: 1, 17800: 0.2}
+	for spellID, want := range wantUptimes {
+		got, ok := imp.BuffUptimes[spellID]
+		if !ok {
+			got = imp.DebuffUptimes[spellID]
+		}
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("Expected uptime %f for spell %d, got %f", want, spellID, got)
+		}
+	}
+
+	raid := imp.Request.Raid
+	if raid.Buffs.PowerWordFortitude != proto.TristateEffect_TristateEffectRegular || raid.Buffs.BloodPact != proto.TristateEffect_TristateEffectMissing {
+		t.Errorf("Unexpected raid buffs: %v", raid.Buffs)
+	}
+	if !raid.Debuffs.CurseOfShadow || raid.Debuffs.ImprovedShadowBolt {
+		t.Errorf("Unexpected debuffs: %v", raid.Debuffs)
+	}
+	if name := raid.Parties[0].Players[0].Name; name != "Sim" {
+		t.Errorf("Expected player Sim, got %s", name)
+	}
+	if !imp.PresetTarget || imp.Request.Encounter.Targets[0].Id != 13020 || imp.Request.Encounter.Duration != 10 {
+		t.Errorf("Expected the Vaelastrasz preset in a 10s encounter, got %v", imp.Request.Encounter)
+	}
+}
+
+func TestReadWoWCombatLogErrors(t *testing.T) {
+	twoPlayers := strings.Join([]string{
+		`4/7/2024 20:00:00.0000-4  SPELL_CAST_SUCCESS,Player-4395-00000001,"Sim-Realm",0x511,0x0,0000000000000000,nil,0x80000000,0x0,25307,"Shadow Bolt",0x20`,
+		`4/7/2024 20:00:01.0000-4  SPELL_CAST_SUCCESS,Player-4395-00000002,"Other",0x511,0x0,0000000000000000,nil,0x80000000,0x0,25307,"Shadow Bolt",0x20`,
+	}, "\n")
+	if _, err := ReadWoWCombatLog(strings.NewReader(twoPlayers), ImportOptions{}); err == nil {
+		t.Errorf("Expected an error when the player is ambiguous")
+	}
+	if _, err := ReadWoWCombatLog(strings.NewReader(twoPlayers), ImportOptions{Player: "Sim"}); err == nil {
+		t.Errorf("Expected an error without a boss")
+	}
+	if _, err := ReadWoWCombatLog(strings.NewReader("not a combat log"), ImportOptions{}); err == nil {
+		t.Errorf("Expected an error for invalid lines")
+	}
+}
+
+func TestSimCastsPerMinute(t *testing.T) {
+	result := &proto.RaidSimResult{
+		IterationsDone:       10,
+		AvgIterationDuration: 120,
+		RaidMetrics: &proto.RaidMetrics{
+			Parties: []*proto.PartyMetrics{{
+				Players: []*proto.UnitMetrics{{
+					Actions: []*proto.ActionMetrics{
+						{Id: &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 25307}}, Targets: []*proto.TargetedActionMetrics{{Casts: 300}}},
+						{Id: &proto.ActionID{RawId: &proto.ActionID_SpellId{SpellId: 25307}, Tag: 1}, Targets: []*proto.TargetedActionMetrics{{Casts: 100}}},
+					},
+				}},
+			}},
+		},
+	}
+
+	casts, err := SimCastsPerMinute(result, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := casts.Casts["{SpellID: 25307}"]; got != 20 {
+		t.Fatalf("Expected 20 casts per minute, got %f", got)
+	}
+	if _, err := SimCastsPerMinute(result, 5); err == nil {
+		t.Fatalf("Expected an error for a missing player")
+	}
+}
@@ -234,7 +234,7 @@ func init() {
 	// 1 PPM from Armaments Discord
 	itemhelpers.CreateWeaponProcAura(ArgentAvenger, "Argent Avenger", 1.0, func(character *core.Character) *core.Aura {
 		matchingTargets := core.FilterSlice(
-			character.Env.Encounter.TargetUnits,
+			character.Env.Encounter.AllTargetUnits,
 			func(unit *core.Unit) bool { return unit.MobType == proto.MobType_MobTypeUndead },
 		)
 
@@ -303,7 +303,7 @@ func init() {
 
 		// Keep track of damage taken by each enemy
 		enemyDamageTaken := map[int32]float64{}
-		for _, target := range character.Env.Encounter.TargetUnits {
+		for _, target := range character.Env.Encounter.AllTargetUnits {
 			enemyDamageTaken[target.UnitIndex] = 0
 		}
 
@@ -1690,7 +1690,6 @@ func init() {
 	// Chance on hit: Blasts up to 3 targets for 105 to 145 Nature damage.
 	// Estimated based on data from WoW Armaments Discord
 	itemhelpers.CreateWeaponProcSpell(MasterworkStormhammer, "Masterwork Stormhammer", 0.5, func(character *core.Character) *core.Spell {
-		maxHits := int(min(3, character.Env.GetNumTargets()))
 		return character.RegisterSpell(core.SpellConfig{
 			ActionID:         core.ActionID{SpellID: 463946},
 			SpellSchool:      core.SpellSchoolNature,
@@ -1699,7 +1698,8 @@ func init() {
 			DamageMultiplier: 1,
 			ThreatMultiplier: 1,
 			ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
-				for numHits := 0; numHits < maxHits; numHits++ {
+				maxHits := min(3, sim.GetNumTargets())
+				for numHits := int32(0); numHits < maxHits; numHits++ {
 					spell.CalcAndDealDamage(sim, target, sim.Roll(105, 145), spell.OutcomeMagicHitAndCrit)
 					target = character.Env.NextTargetUnit(target)
 				}
@@ -2330,7 +2330,7 @@ func init() {
 			return aura
 		})
 
-		results := make([]*core.SpellResult, min(4, character.Env.GetNumTargets()))
+		results := make([]*core.SpellResult, min(4, character.Env.GetMaxNumTargets()))
 
 		return character.GetOrRegisterSpell(core.SpellConfig{
 			ActionID:         core.ActionID{SpellID: 13532},
@@ -2340,6 +2340,7 @@ func init() {
 			DamageMultiplier: 1,
 			ThreatMultiplier: 1,
 			ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+				results := results[:min(len(results), int(sim.GetNumTargets()))]
 				for idx := range results {
 					results[idx] = spell.CalcDamage(sim, target, 7, spell.OutcomeMagicHitAndCrit)
 					target = character.Env.NextTargetUnit(target)
@@ -2442,7 +2443,7 @@ func init() {
 			})
 		})
 
-		results := make([]*core.SpellResult, min(5, character.Env.GetNumTargets()))
+		results := make([]*core.SpellResult, min(5, character.Env.GetMaxNumTargets()))
 
 		bounceSpell := character.GetOrRegisterSpell(core.SpellConfig{
 			ActionID:    procActionID.WithTag(2),
@@ -2453,6 +2454,7 @@ func init() {
 			FlatThreatBonus:  126,
 
 			ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+				results := results[:min(len(results), int(sim.GetNumTargets()))]
 				for idx := range results {
 					results[idx] = spell.CalcDamage(sim, target, 0, spell.OutcomeMagicHit)
 					target = sim.Environment.NextTargetUnit(target)
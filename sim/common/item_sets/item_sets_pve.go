@@ -364,7 +364,7 @@ var ItemSetRegaliaOfUndeadCleansing = core.NewItemSet(core.ItemSet{
 			delta := 1.02
 
 			character.Env.RegisterPostFinalizeEffect(func() {
-				for _, target := range character.Env.Encounter.TargetUnits {
+				for _, target := range character.Env.Encounter.AllTargetUnits {
 					if target.MobType != proto.MobType_MobTypeUndead {
 						continue
 					}
@@ -388,7 +388,7 @@ var ItemSetUndeadSlayersArmor = core.NewItemSet(core.ItemSet{
 			delta := 1.02
 
 			character.Env.RegisterPostFinalizeEffect(func() {
-				for _, target := range character.Env.Encounter.TargetUnits {
+				for _, target := range character.Env.Encounter.AllTargetUnits {
 					if target.MobType != proto.MobType_MobTypeUndead {
 						continue
 					}
@@ -412,7 +412,7 @@ var ItemSetGarbOfTheUndeadSlayer = core.NewItemSet(core.ItemSet{
 			delta := 1.02
 
 			character.Env.RegisterPostFinalizeEffect(func() {
-				for _, target := range character.Env.Encounter.TargetUnits {
+				for _, target := range character.Env.Encounter.AllTargetUnits {
 					if target.MobType != proto.MobType_MobTypeUndead {
 						continue
 					}
@@ -436,7 +436,7 @@ var ItemSetBattlegearOfUndeadSlaying = core.NewItemSet(core.ItemSet{
 			delta := 1.02
 
 			character.Env.RegisterPostFinalizeEffect(func() {
-				for _, target := range character.Env.Encounter.TargetUnits {
+				for _, target := range character.Env.Encounter.AllTargetUnits {
 					if target.MobType != proto.MobType_MobTypeUndead {
 						continue
 					}
@@ -0,0 +1,151 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func TestBulkSimCommonRandomNumbers(t *testing.T) {
+	request := combatLogTestRequest(500, 0)
+	// The MC set has the same ring twice, which bulk sims reject.
+	request.Raid.Parties[0].Players[0].Equipment = core.GetGearSet("../ui/warlock/gear_sets", "prebis").GearSet
+	result := core.RunBulkSim(&proto.BulkSimRequest{
+		BaseSettings: request,
+		BulkSettings: &proto.BulkSettings{
+			Items:               []*proto.ItemSpec{{Id: 18820}}, // Talisman of Ephemeral Power
+			IterationsPerCombo:  500,
+			CommonRandomNumbers: true,
+		},
+	})
+	if result.Error != nil {
+		t.Fatalf("Bulk sim failed: %s", result.Error.Message)
+	}
+
+	baseDps := result.EquippedGearResult.UnitMetrics.Dps
+	if len(baseDps.AllValues) != 0 {
+		t.Fatalf("Expected per-iteration values to be cleared")
+	}
+
+	for _, combo := range result.Results {
+		delta := combo.DpsDelta
+		if delta == nil || delta.Iterations != 500 {
+			t.Fatalf("Expected a paired delta over 500 iterations, got %v", delta)
+		}
+
+		if len(combo.ItemsAdded) == 0 {
+			if delta.Mean != 0 || delta.Stdev != 0 {
+				t.Fatalf("Expected no difference for the equipped gear, got %v", delta)
+			}
+			continue
+		}
+
+		if math.Abs(delta.Mean-(combo.UnitMetrics.Dps.Avg-baseDps.Avg)) > 1e-6 {
+			t.Fatalf("Paired delta %f doesn't match the difference in averages %f", delta.Mean, combo.UnitMetrics.Dps.Avg-baseDps.Avg)
+		}
+		unpairedStderr := math.Sqrt((baseDps.Stdev*baseDps.Stdev + combo.UnitMetrics.Dps.Stdev*combo.UnitMetrics.Dps.Stdev) / 500)
+		if delta.Stderr >= unpairedStderr {
+			t.Fatalf("Expected paired standard error %f to be below the unpaired %f", delta.Stderr, unpairedStderr)
+		}
+	}
+}
+
+func TestStatWeightsDpsDeltas(t *testing.T) {
+	request := combatLogTestRequest(200, 0)
+	player := request.Raid.Parties[0].Players[0]
+	result := core.StatWeights(&proto.StatWeightsRequest{
+		Player:          player,
+		RaidBuffs:       request.Raid.Buffs,
+		PartyBuffs:      request.Raid.Parties[0].Buffs,
+		Debuffs:         request.Raid.Debuffs,
+		Encounter:       request.Encounter,
+		SimOptions:      request.SimOptions,
+		StatsToWeigh:    []proto.Stat{proto.Stat_StatSpellPower, proto.Stat_StatIntellect},
+		EpReferenceStat: proto.Stat_StatSpellPower,
+	})
+	if result.Error != nil {
+		t.Fatalf("Stat weights failed: %s", result.Error.Message)
+	}
+
+	if len(result.DpsDeltas) != 2 {
+		t.Fatalf("Expected deltas for 2 stats, got %d", len(result.DpsDeltas))
+	}
+	for _, delta := range result.DpsDeltas {
+		if delta.Low.GetIterations() != 100 || delta.High.GetIterations() != 100 {
+			t.Fatalf("Expected deltas over 100 iterations, got %v", delta)
+		}
+		if delta.StatData.UnitStat == int32(proto.Stat_StatSpellPower) && (delta.Low.Mean >= 0 || delta.High.Mean <= 0) {
+			t.Fatalf("Expected less DPS with less spell power and more with more, got %v", delta)
+		}
+	}
+}
+
+func TestStatWeightsRegression(t *testing.T) {
+	request := combatLogTestRequest(100, 0)
+	result := core.StatWeights(&proto.StatWeightsRequest{
+		Player:          request.Raid.Parties[0].Players[0],
+		RaidBuffs:       request.Raid.Buffs,
+		PartyBuffs:      request.Raid.Parties[0].Buffs,
+		Debuffs:         request.Raid.Debuffs,
+		Encounter:       request.Encounter,
+		SimOptions:      request.SimOptions,
+		StatsToWeigh:    []proto.Stat{proto.Stat_StatSpellPower, proto.Stat_StatIntellect},
+		EpReferenceStat: proto.Stat_StatSpellPower,
+		Regression:      &proto.StatWeightsRegressionOptions{Radius: 20, Quadratic: true},
+	})
+	if result.Error != nil {
+		t.Fatalf("Stat weights failed: %s", result.Error.Message)
+	}
+
+	if len(result.RegressionFits) != 2 || len(result.DpsDeltas) != 0 {
+		t.Fatalf("Expected regression fits for 2 stats, got %v", result.RegressionFits)
+	}
+	for _, fit := range result.RegressionFits {
+		if fit.WeightStderr <= 0 || fit.WeightCiLow > fit.Weight || fit.WeightCiHigh < fit.Weight {
+			t.Fatalf("Expected a confidence interval around the weight, got %v", fit)
+		}
+		if fit.UnitStat == int32(proto.Stat_StatSpellPower) {
+			if fit.WeightCiLow <= 0 {
+				t.Fatalf("Expected spell power to increase DPS, got %v", fit)
+			}
+			if ep := result.Dps.EpValues.Stats[proto.Stat_StatSpellPower]; ep != 1 {
+				t.Fatalf("Expected an EP of 1 for the reference stat, got %f", ep)
+			}
+		}
+	}
+	if result.RegressionRSquared <= 0 || result.RegressionRSquared > 1 {
+		t.Fatalf("Expected R squared between 0 and 1, got %f", result.RegressionRSquared)
+	}
+}
+
+func TestStatScaling(t *testing.T) {
+	request := combatLogTestRequest(50, 0)
+	result := core.StatScaling(&proto.StatScalingRequest{
+		Player:     request.Raid.Parties[0].Players[0],
+		RaidBuffs:  request.Raid.Buffs,
+		PartyBuffs: request.Raid.Parties[0].Buffs,
+		Debuffs:    request.Raid.Debuffs,
+		Encounter:  request.Encounter,
+		SimOptions: request.SimOptions,
+		UnitStat:   &proto.StatScalingRequest_Stat{Stat: proto.Stat_StatSpellPower},
+		Step:       50,
+		NumSteps:   3,
+	})
+	if result.Error != nil {
+		t.Fatalf("Stat scaling failed: %s", result.Error.Message)
+	}
+
+	if len(result.Points) != 4 || result.BaseValue <= 0 {
+		t.Fatalf("Expected 4 points above a base value, got %v", result)
+	}
+	for i, point := range result.Points {
+		if point.AmountAdded != float64(50*i) || point.DpsDelta.GetIterations() != 50 || len(point.Dps.AllValues) != 0 {
+			t.Fatalf("Unexpected point %d: %v", i, point)
+		}
+		if i > 0 && point.DpsDelta.Mean <= result.Points[i-1].DpsDelta.Mean {
+			t.Fatalf("Expected DPS to increase with spell power, got %v", result.Points)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func checkConvergence(t *testing.T, result *proto.RaidSimResult, maxIterations int32, maxRelativeStderr float64) {
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+	if !result.Converged || result.IterationsDone >= maxIterations {
+		t.Fatalf("Expected the sim to converge before %d iterations, stopped after %d", maxIterations, result.IterationsDone)
+	}
+	if n := result.RaidMetrics.Dps.AggregatorData.N; n != result.IterationsDone {
+		t.Fatalf("Expected metrics for %d iterations, got %d", result.IterationsDone, n)
+	}
+
+	ci := result.DpsConfidenceInterval
+	dps := result.RaidMetrics.Dps.Avg
+	// Concurrent splits may each finish one more iteration after the target was reached.
+	if ci.Stderr > 1.05*maxRelativeStderr*dps {
+		t.Fatalf("Standard error %f is above the requested %f", ci.Stderr, maxRelativeStderr*dps)
+	}
+	if ci.Lower >= dps || ci.Upper <= dps {
+		t.Fatalf("Confidence interval [%f, %f] doesn't contain the average %f", ci.Lower, ci.Upper, dps)
+	}
+}
+
+func TestConvergence(t *testing.T) {
+	request := combatLogTestRequest(20000, 0)
+	request.SimOptions.MaxDpsRelativeStderr = 0.005
+	checkConvergence(t, core.RunRaidSim(request), 20000, 0.005)
+}
+
+func TestConvergenceConcurrent(t *testing.T) {
+	request := combatLogTestRequest(20000, 0)
+	request.SimOptions.MaxDpsRelativeStderr = 0.005
+	request.SimOptions.IsTest = true // Always runs 3 splits.
+	checkConvergence(t, core.RunRaidSimConcurrent(request), 20000, 0.005)
+}
+
+func TestConvergenceCap(t *testing.T) {
+	request := combatLogTestRequest(50, 0)
+	request.SimOptions.MaxDpsStderr = 0.0001
+	request.SimOptions.IsTest = true
+	result := core.RunRaidSimConcurrent(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+	if result.Converged || result.IterationsDone != 50 {
+		t.Fatalf("Expected all 50 iterations without converging, got %d", result.IterationsDone)
+	}
+	if result.DpsConfidenceInterval.Stderr <= 0 {
+		t.Fatalf("Expected a confidence interval, got %v", result.DpsConfidenceInterval)
+	}
+}
@@ -51,6 +51,80 @@ func StatWeightsAsync(request *proto.StatWeightsRequest, progress chan *proto.Pr
 	}()
 }
 
+/**
+ * Returns DPS and TPS with increasing amounts of one stat added.
+ */
+func StatScaling(request *proto.StatScalingRequest) *proto.StatScalingResult {
+	return runStatScaling(request, nil, simsignals.CreateSignals())
+}
+
+func StatScalingAsync(request *proto.StatScalingRequest, progress chan *proto.ProgressMetrics, requestId string) {
+	runAsync(progress, requestId, func(err *proto.ErrorOutcome) *proto.ProgressMetrics {
+		return &proto.ProgressMetrics{FinalScalingResult: &proto.StatScalingResult{Error: err}}
+	}, func(signals simsignals.Signals) *proto.ProgressMetrics {
+		return &proto.ProgressMetrics{FinalScalingResult: runStatScaling(request, progress, signals)}
+	})
+}
+
+func OptimizeGear(request *proto.GearOptimizerRequest) *proto.GearOptimizerResult {
+	return runGearOptimizer(request, nil, simsignals.CreateSignals())
+}
+
+func OptimizeGearAsync(request *proto.GearOptimizerRequest, progress chan *proto.ProgressMetrics, requestId string) {
+	runAsync(progress, requestId, func(err *proto.ErrorOutcome) *proto.ProgressMetrics {
+		return &proto.ProgressMetrics{FinalGearResult: &proto.GearOptimizerResult{Error: err}}
+	}, func(signals simsignals.Signals) *proto.ProgressMetrics {
+		return &proto.ProgressMetrics{FinalGearResult: runGearOptimizer(request, progress, signals)}
+	})
+}
+
+func OptimizeTalents(request *proto.TalentOptimizerRequest) *proto.TalentOptimizerResult {
+	return runTalentOptimizer(request, nil, simsignals.CreateSignals())
+}
+
+func OptimizeTalentsAsync(request *proto.TalentOptimizerRequest, progress chan *proto.ProgressMetrics, requestId string) {
+	runAsync(progress, requestId, func(err *proto.ErrorOutcome) *proto.ProgressMetrics {
+		return &proto.ProgressMetrics{FinalTalentResult: &proto.TalentOptimizerResult{Error: err}}
+	}, func(signals simsignals.Signals) *proto.ProgressMetrics {
+		return &proto.ProgressMetrics{FinalTalentResult: runTalentOptimizer(request, progress, signals)}
+	})
+}
+
+func BuffValues(request *proto.BuffValuesRequest) *proto.BuffValuesResult {
+	return runBuffValues(request, nil, simsignals.CreateSignals())
+}
+
+func BuffValuesAsync(request *proto.BuffValuesRequest, progress chan *proto.ProgressMetrics, requestId string) {
+	runAsync(progress, requestId, func(err *proto.ErrorOutcome) *proto.ProgressMetrics {
+		return &proto.ProgressMetrics{FinalBuffValuesResult: &proto.BuffValuesResult{Error: err}}
+	}, func(signals simsignals.Signals) *proto.ProgressMetrics {
+		return &proto.ProgressMetrics{FinalBuffValuesResult: runBuffValues(request, progress, signals)}
+	})
+}
+
+// Registers requestId with the signal API and runs an API call in the background, sending the
+// progress report with its result once done. errorProgress wraps errors that keep it from starting.
+func runAsync(progress chan *proto.ProgressMetrics, requestId string, errorProgress func(*proto.ErrorOutcome) *proto.ProgressMetrics, run func(simsignals.Signals) *proto.ProgressMetrics) {
+	signals, err := simsignals.RegisterWithId(requestId)
+	if err != nil {
+		progress <- errorProgress(&proto.ErrorOutcome{
+			Message: "Couldn't register for signal API: " + err.Error(),
+		})
+		return
+	}
+	go func() {
+		defer simsignals.UnregisterId(requestId)
+		progress <- run(signals)
+	}()
+}
+
+// Whether a progress report is the last one of an async API call, i.e. it has the final result.
+func IsFinalProgress(progress *proto.ProgressMetrics) bool {
+	return progress.FinalRaidResult != nil || progress.FinalWeightResult != nil || progress.FinalBulkResult != nil ||
+		progress.FinalScalingResult != nil || progress.FinalGearResult != nil || progress.FinalTalentResult != nil ||
+		progress.FinalBuffValuesResult != nil
+}
+
 // Get data for all requests needed for stat weights.
 func StatWeightRequests(request *proto.StatWeightsRequest) *proto.StatWeightRequestsData {
 	return buildStatWeightRequests(request)
@@ -131,6 +205,11 @@ func RunBulkSimAsync(request *proto.BulkSimRequest, progress chan *proto.Progres
 	}()
 }
 
+// Searches the tunable constants of a player's rotation for the values with the highest DPS.
+func OptimizeAPL(request *proto.APLOptimizerRequest) *proto.APLOptimizerResult {
+	return runAPLOptimizer(request, simsignals.CreateSignals())
+}
+
 var runningInWasm = false
 
 func SetRunningInWasm() {
@@ -12,6 +12,13 @@ type APLRotation struct {
 	unit           *Unit
 	prepullActions []*APLAction
 	priorityList   []*APLAction
+	actionLists    []*aplActionList
+
+	// Index of the item config for each priority list action.
+	priorityListConfigIdxs []int
+
+	// User variables by name, declared up front from all set_variable actions.
+	variables map[string]*aplVariable
 
 	// Action currently controlling this rotation (only used for certain actions, such as StrictSequence).
 	controllingActions []APLActionImpl
@@ -26,14 +33,32 @@ type APLRotation struct {
 	// Used inside of actions/value to determine whether they will occur during the prepull or regular rotation.
 	parsingPrepull bool
 
+	// The action list whose actions are being parsed, nil for the prepull and main priority list.
+	parsingActionList *aplActionList
+
 	// Used to avoid recursive APL loops.
 	inLoop bool
 
+	// Counts calls to DoNextAction, so that actions can tell whether they already ran in the current one.
+	actionPass int
+
+	// Traces of all list items when SimOptions.AplTrace is enabled, and whether the current
+	// lookup of the next action should be recorded in them.
+	traces  []*aplItemTrace
+	tracing bool
+
 	// Validation warnings that occur during proto parsing.
 	// We return these back to the user for display in the UI.
 	curWarnings          []string
 	prepullWarnings      [][]string
 	priorityListWarnings [][]string
+
+	// Parsed value and action for each config, used by static analysis once parsing is done.
+	parsedValues  map[*proto.APLValue]APLValue
+	parsedActions map[*proto.APLAction]*APLAction
+
+	// Results of static analysis, see analyze().
+	diagnostics []*proto.APLDiagnostic
 }
 
 func (rot *APLRotation) ValidationWarning(message string, vals ...interface{}) {
@@ -75,8 +100,12 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 		unit:                 unit,
 		prepullWarnings:      make([][]string, len(config.PrepullActions)),
 		priorityListWarnings: make([][]string, len(config.PriorityList)),
+		parsedValues:         make(map[*proto.APLValue]APLValue),
+		parsedActions:        make(map[*proto.APLAction]*APLAction),
 	}
 
+	rotation.declareVariables(config)
+
 	// Parse prepull actions
 	for i, prepullItem := range config.PrepullActions {
 		prepullIdx := i // Save to local variable for correct lambda capture behavior
@@ -84,10 +113,8 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 			if !prepullItem.Hide {
 				doAtVal := rotation.newAPLValue(prepullItem.DoAtValue)
 				if doAtVal != nil {
-					doAt := doAtVal.GetDuration(nil)
-					if doAt > 0 {
-						rotation.ValidationWarning("Invalid time for 'Do At', ignoring this Prepull Action")
-					} else {
+					// Actions after 0s are ignored, which is reported by the KindPrepullAfterStart diagnostic.
+					if doAt := doAtVal.GetDuration(nil); doAt <= 0 {
 						action := rotation.newAPLAction(prepullItem.Action)
 						if action != nil {
 							rotation.prepullActions = append(rotation.prepullActions, action)
@@ -106,19 +133,21 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 	}
 
 	// Parse priority list
-	var configIdxs []int
 	for i, aplItem := range config.PriorityList {
 		rotation.doAndRecordWarnings(&rotation.priorityListWarnings[i], false, func() {
 			if !aplItem.Hide {
 				action := rotation.newAPLAction(aplItem.Action)
 				if action != nil {
 					rotation.priorityList = append(rotation.priorityList, action)
-					configIdxs = append(configIdxs, i)
+					rotation.priorityListConfigIdxs = append(rotation.priorityListConfigIdxs, i)
 				}
 			}
 		})
 	}
 
+	// Parse named action lists
+	rotation.newActionLists(config.ActionLists)
+
 	// Finalize
 	for i, action := range rotation.prepullActions {
 		rotation.doAndRecordWarnings(&rotation.prepullWarnings[i], true, func() {
@@ -130,6 +159,17 @@ func (unit *Unit) newAPLRotation(config *proto.APLRotation) *APLRotation {
 			action.Finalize(rotation)
 		})
 	}
+	for _, list := range rotation.actionLists {
+		for i, action := range list.actions {
+			rotation.doAndRecordWarnings(&list.itemWarnings[list.configIdxs[i]], false, func() {
+				action.Finalize(rotation)
+			})
+		}
+	}
+
+	rotation.analyze(config)
+	rotation.parsedValues = nil
+	rotation.parsedActions = nil
 
 	// Remove MCDs that are referenced by APL actions, so that the Autocast Other Cooldowns
 	// action does not include them.
@@ -149,12 +189,24 @@ func (rot *APLRotation) getStats() *proto.APLStats {
 	return &proto.APLStats{
 		PrepullActions: MapSlice(rot.prepullWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
 		PriorityList:   MapSlice(rot.priorityListWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
+		ActionLists: MapSlice(rot.actionLists, func(list *aplActionList) *proto.APLActionListStats {
+			return &proto.APLActionListStats{
+				Name:     list.name,
+				Items:    MapSlice(list.itemWarnings, func(warnings []string) *proto.APLActionStats { return &proto.APLActionStats{Warnings: warnings} }),
+				Warnings: list.warnings,
+			}
+		}),
+		Diagnostics: rot.diagnostics,
 	}
 }
 
 // Returns all action objects as an unstructured list. Used for easily finding specific actions.
 func (rot *APLRotation) allAPLActions() []*APLAction {
-	return Flatten(MapSlice(rot.priorityList, func(action *APLAction) []*APLAction { return action.GetAllActions() }))
+	actions := rot.priorityList
+	for _, list := range rot.actionLists {
+		actions = append(actions[:len(actions):len(actions)], list.actions...)
+	}
+	return Flatten(MapSlice(actions, func(action *APLAction) []*APLAction { return action.GetAllActions() }))
 }
 
 // Returns all action objects from the prepull as an unstructured list. Used for easily finding specific actions.
@@ -167,6 +219,9 @@ func (rot *APLRotation) reset(sim *Simulation) {
 	rot.inLoop = false
 	rot.interruptChannelIf = nil
 	rot.allowChannelRecastOnInterrupt = false
+	for _, variable := range rot.variables {
+		variable.reset()
+	}
 	for _, action := range rot.allAPLActions() {
 		action.impl.Reset(sim)
 	}
@@ -194,8 +249,9 @@ func (apl *APLRotation) DoNextAction(sim *Simulation) {
 
 	i := 0
 	apl.inLoop = true
+	apl.actionPass++
 
-	for nextAction := apl.getNextAction(sim); nextAction != nil; i, nextAction = i+1, apl.getNextAction(sim) {
+	for nextAction := apl.getNextTracedAction(sim); nextAction != nil; i, nextAction = i+1, apl.getNextTracedAction(sim) {
 		if i > 1000 {
 			panic(fmt.Sprintf("[USER_ERROR] Infinite loop detected, current action:\n%s", nextAction))
 		}
@@ -219,13 +275,8 @@ func (apl *APLRotation) getNextAction(sim *Simulation) *APLAction {
 		return apl.controllingActions[len(apl.controllingActions)-1].GetNextAction(sim)
 	}
 
-	for _, action := range apl.priorityList {
-		if action.IsReady(sim) {
-			return action
-		}
-	}
-
-	return nil
+	nextAction, _ := apl.getNextActionInList(sim, apl.priorityList)
+	return nextAction
 }
 
 func (apl *APLRotation) pushControllingAction(ca APLActionImpl) {
@@ -9,6 +9,9 @@ import (
 type APLAction struct {
 	condition APLValue
 	impl      APLActionImpl
+
+	// Only set for list items while tracing, see APLRotation.enableTrace.
+	trace *aplItemTrace
 }
 
 func (action *APLAction) Finalize(rot *APLRotation) {
@@ -122,9 +125,11 @@ func (rot *APLRotation) newAPLAction(config *proto.APLAction) *APLAction {
 
 	if action.impl == nil {
 		return nil
-	} else {
-		return action
 	}
+	if rot.parsedActions != nil {
+		rot.parsedActions[config] = action
+	}
+	return action
 }
 
 func (rot *APLRotation) newAPLActionImpl(config *proto.APLAction) APLActionImpl {
@@ -166,6 +171,16 @@ func (rot *APLRotation) newAPLActionImpl(config *proto.APLAction) APLActionImpl
 	case *proto.APLAction_StrictSequence:
 		return rot.newActionStrictSequence(config.GetStrictSequence())
 
+	// Action lists
+	case *proto.APLAction_CallActionList:
+		return rot.newActionCallActionList(config.GetCallActionList())
+	case *proto.APLAction_RunActionList:
+		return rot.newActionRunActionList(config.GetRunActionList())
+
+	// Variables
+	case *proto.APLAction_SetVariable:
+		return rot.newActionSetVariable(config.GetSetVariable())
+
 	// Misc
 	case *proto.APLAction_ChangeTarget:
 		return rot.newActionChangeTarget(config.GetChangeTarget())
@@ -122,7 +122,7 @@ func (rot *APLRotation) newActionMultidot(config *proto.APLActionMultidot) APLAc
 	}
 
 	maxDots := config.MaxDots
-	numTargets := unit.Env.GetNumTargets()
+	numTargets := unit.Env.GetMaxNumTargets()
 	if spell.Flags.Matches(SpellFlagHelpful) {
 		numTargets = int32(len(unit.Env.Raid.AllPlayerUnits))
 	}
@@ -156,8 +156,7 @@ func (action *APLActionMultidot) IsReady(sim *Simulation) bool {
 			}
 		}
 	} else {
-		for i := int32(0); i < action.maxDots; i++ {
-			target := sim.Encounter.TargetUnits[i]
+		for _, target := range sim.Encounter.TargetUnits[:min(int(action.maxDots), len(sim.Encounter.TargetUnits))] {
 			dot := action.spell.Dot(target)
 			if (!dot.IsActive() || dot.RemainingDuration(sim) < maxOverlap) && action.spell.CanCast(sim, target) {
 				action.nextTarget = target
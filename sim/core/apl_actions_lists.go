@@ -0,0 +1,194 @@
+package core
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+// A named list of actions, which can be entered from other lists with call_action_list or
+// run_action_list.
+type aplActionList struct {
+	name       string
+	actions    []*APLAction
+	configIdxs []int // Index of the item config for each action.
+
+	// Validation warnings for the list itself, and for each item in the list config.
+	warnings     []string
+	itemWarnings [][]string
+}
+
+func (rot *APLRotation) newActionLists(configs []*proto.APLActionList) {
+	for _, config := range configs {
+		list := &aplActionList{
+			name:         config.Name,
+			itemWarnings: make([][]string, len(config.Items)),
+		}
+		rot.actionLists = append(rot.actionLists, list)
+
+		rot.doAndRecordWarnings(&list.warnings, false, func() {
+			if config.Name == "" {
+				rot.ValidationWarning("Action lists must have a name")
+			} else if rot.getActionList(config.Name) != list {
+				rot.ValidationWarning("Duplicate action list name: '%s'", config.Name)
+			}
+		})
+
+		rot.parsingActionList = list
+		for i, item := range config.Items {
+			rot.doAndRecordWarnings(&list.itemWarnings[i], false, func() {
+				if !item.Hide {
+					if action := rot.newAPLAction(item.Action); action != nil {
+						list.actions = append(list.actions, action)
+						list.configIdxs = append(list.configIdxs, i)
+					}
+				}
+			})
+		}
+		rot.parsingActionList = nil
+	}
+}
+
+func (rot *APLRotation) getActionList(name string) *aplActionList {
+	for _, list := range rot.actionLists {
+		if list.name == name {
+			return list
+		}
+	}
+	return nil
+}
+
+// Returns the first ready action in actions, entering called action lists. The second return
+// value is true if a run_action_list was entered, in which case the actions after it are skipped
+// even if none of the run list's actions are ready.
+func (rot *APLRotation) getNextActionInList(sim *Simulation, actions []*APLAction) (*APLAction, bool) {
+	for _, action := range actions {
+		trace := action.trace
+		if !rot.tracing {
+			trace = nil
+		}
+		trace.evaluated()
+
+		if action.condition != nil && !action.condition.GetBool(sim) {
+			continue
+		}
+		trace.conditionWasTrue()
+
+		if listAction, ok := action.impl.(*APLActionActionList); ok {
+			if listAction.list == nil {
+				trace.notReady()
+				continue
+			}
+			nextAction, ran := rot.getNextActionInList(sim, listAction.list.actions)
+			if nextAction != nil {
+				trace.executed()
+				return nextAction, false
+			}
+			trace.notReady()
+			if ran || listAction.run {
+				return nil, true
+			}
+		} else if action.impl.IsReady(sim) {
+			trace.executed()
+			return action, false
+		} else {
+			trace.notReady()
+		}
+	}
+	return nil, false
+}
+
+// Implements both call_action_list and run_action_list. When used as the inner action of another
+// action, e.g. in a sequence, both simply perform the first ready action of the list.
+type APLActionActionList struct {
+	defaultAPLActionImpl
+	rot  *APLRotation
+	name string
+	run  bool
+
+	// The list containing this action, nil for the main priority list.
+	owner *aplActionList
+	list  *aplActionList
+}
+
+func (rot *APLRotation) newActionCallActionList(config *proto.APLActionCallActionList) APLActionImpl {
+	return rot.newActionActionList(config.Name, false)
+}
+func (rot *APLRotation) newActionRunActionList(config *proto.APLActionRunActionList) APLActionImpl {
+	return rot.newActionActionList(config.Name, true)
+}
+func (rot *APLRotation) newActionActionList(name string, run bool) APLActionImpl {
+	if name == "" {
+		rot.ValidationWarning("Call/Run Action List must provide an action list name")
+		return nil
+	}
+	if rot.parsingPrepull {
+		rot.ValidationWarning("Action lists can't be used in the prepull")
+		return nil
+	}
+	return &APLActionActionList{
+		rot:   rot,
+		name:  name,
+		run:   run,
+		owner: rot.parsingActionList,
+	}
+}
+func (action *APLActionActionList) Finalize(rot *APLRotation) {
+	list := rot.getActionList(action.name)
+	if list == nil {
+		rot.ValidationWarning("No action list with name: '%s'", action.name)
+		return
+	}
+	if action.owner != nil {
+		if path := rot.actionListPath(list, action.owner, nil); path != nil {
+			rot.ValidationWarning("Action list '%s' enters itself through %s, ignoring this action", action.owner.name, strings.Join(path, " -> "))
+			return
+		}
+	}
+	action.list = list
+}
+
+// Returns the names of the lists entered on the way from one list to another, or nil if the
+// target list can't be reached.
+func (rot *APLRotation) actionListPath(from *aplActionList, to *aplActionList, visited []*aplActionList) []string {
+	path := []string{fmt.Sprintf("'%s'", from.name)}
+	if from == to {
+		return path
+	}
+	for _, v := range visited {
+		if v == from {
+			return nil
+		}
+	}
+	visited = append(visited, from)
+
+	for _, action := range Flatten(MapSlice(from.actions, (*APLAction).GetAllActions)) {
+		if listAction, ok := action.impl.(*APLActionActionList); ok {
+			if next := rot.getActionList(listAction.name); next != nil {
+				if rest := rot.actionListPath(next, to, visited); rest != nil {
+					return append(path, rest...)
+				}
+			}
+		}
+	}
+	return nil
+}
+func (action *APLActionActionList) IsReady(sim *Simulation) bool {
+	if action.list == nil {
+		return false
+	}
+	nextAction, _ := action.rot.getNextActionInList(sim, action.list.actions)
+	return nextAction != nil
+}
+func (action *APLActionActionList) Execute(sim *Simulation) {
+	if nextAction, _ := action.rot.getNextActionInList(sim, action.list.actions); nextAction != nil {
+		nextAction.Execute(sim)
+	}
+}
+func (action *APLActionActionList) String() string {
+	if action.run {
+		return fmt.Sprintf("Run Action List(%s)", action.name)
+	}
+	return fmt.Sprintf("Call Action List(%s)", action.name)
+}
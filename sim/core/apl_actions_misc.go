@@ -35,6 +35,7 @@ func (action *APLActionChangeTarget) Execute(sim *Simulation) {
 		action.unit.Log(sim, "Changing target to %s", action.newTarget.Get().Label)
 	}
 	action.unit.CurrentTarget = action.newTarget.Get()
+	action.unit.updateDistanceFromTarget(sim)
 }
 func (action *APLActionChangeTarget) String() string {
 	return fmt.Sprintf("Change Target(%s)", action.newTarget.Get().Label)
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+// A user variable, set by set_variable actions and read with the variable value.
+type aplVariable struct {
+	name      string
+	valueType proto.APLValueType
+
+	isSet bool
+	value aplVariableValue
+}
+
+func (variable *aplVariable) reset() {
+	variable.isSet = false
+	variable.value = aplVariableValue{}
+}
+
+// Holds a value of any APL value type, only the field for the variable's type is used.
+type aplVariableValue struct {
+	boolVal     bool
+	intVal      int32
+	floatVal    float64
+	durationVal time.Duration
+	stringVal   string
+}
+
+func readAPLVariableValue(sim *Simulation, value APLValue) aplVariableValue {
+	switch value.Type() {
+	case proto.APLValueType_ValueTypeBool:
+		return aplVariableValue{boolVal: value.GetBool(sim)}
+	case proto.APLValueType_ValueTypeInt:
+		return aplVariableValue{intVal: value.GetInt(sim)}
+	case proto.APLValueType_ValueTypeFloat:
+		return aplVariableValue{floatVal: value.GetFloat(sim)}
+	case proto.APLValueType_ValueTypeDuration:
+		return aplVariableValue{durationVal: value.GetDuration(sim)}
+	case proto.APLValueType_ValueTypeString:
+		return aplVariableValue{stringVal: value.GetString(sim)}
+	}
+	return aplVariableValue{}
+}
+
+// Declares every variable that is set somewhere in the rotation, so that values can read them
+// regardless of where they're set. A variable's type is the type of its first set_variable value.
+func (rot *APLRotation) declareVariables(config *proto.APLRotation) {
+	rot.variables = make(map[string]*aplVariable)
+
+	var actions []*proto.APLAction
+	for _, prepullItem := range config.PrepullActions {
+		if !prepullItem.Hide {
+			actions = append(actions, prepullItem.Action)
+		}
+	}
+	items := config.PriorityList
+	for _, list := range config.ActionLists {
+		items = append(items[:len(items):len(items)], list.Items...)
+	}
+	for _, item := range items {
+		if !item.Hide {
+			actions = append(actions, item.Action)
+		}
+	}
+
+	// Values can read other variables, so keep going until no more variables can be declared.
+	setVariables := FilterSlice(MapSlice(Flatten(MapSlice(actions, allAPLActionConfigs)), (*proto.APLAction).GetSetVariable),
+		func(setVariable *proto.APLActionSetVariable) bool {
+			return setVariable != nil && setVariable.Name != ""
+		})
+	for declared := true; declared; {
+		declared = false
+		for _, setVariable := range setVariables {
+			if rot.variables[setVariable.Name] != nil {
+				continue
+			}
+
+			// Warnings are discarded here, since they'll be reported again when the action itself is parsed.
+			var value APLValue
+			rot.doAndRecordWarnings(nil, false, func() {
+				value = rot.newAPLValue(setVariable.Value)
+			})
+			if value != nil {
+				rot.variables[setVariable.Name] = &aplVariable{
+					name:      setVariable.Name,
+					valueType: value.Type(),
+				}
+				declared = true
+			}
+		}
+	}
+}
+
+// Returns config along with all of its inner action configs.
+func allAPLActionConfigs(config *proto.APLAction) []*proto.APLAction {
+	if config == nil {
+		return nil
+	}
+	actions := []*proto.APLAction{config}
+	switch action := config.Action.(type) {
+	case *proto.APLAction_Sequence:
+		actions = append(actions, Flatten(MapSlice(action.Sequence.Actions, allAPLActionConfigs))...)
+	case *proto.APLAction_StrictSequence:
+		actions = append(actions, Flatten(MapSlice(action.StrictSequence.Actions, allAPLActionConfigs))...)
+	case *proto.APLAction_Schedule:
+		actions = append(actions, allAPLActionConfigs(action.Schedule.InnerAction)...)
+	}
+	return actions
+}
+
+type APLActionSetVariable struct {
+	defaultAPLActionImpl
+	rot      *APLRotation
+	variable *aplVariable
+	value    APLValue
+
+	// The action pass in which this was last executed.
+	lastPass int
+}
+
+func (rot *APLRotation) newActionSetVariable(config *proto.APLActionSetVariable) APLActionImpl {
+	if config.Name == "" {
+		rot.ValidationWarning("Set Variable must provide a variable name")
+		return nil
+	}
+	value := rot.newAPLValue(config.Value)
+	if value == nil {
+		rot.ValidationWarning("Set Variable must provide a valid value")
+		return nil
+	}
+
+	variable := rot.variables[config.Name]
+	if variable == nil {
+		return nil
+	}
+	if variable.valueType != value.Type() {
+		rot.ValidationWarning("Variable '%s' was first set to a %s, converting this value to match", config.Name, variable.valueType)
+	}
+	return &APLActionSetVariable{
+		rot:      rot,
+		variable: variable,
+		value:    rot.coerceTo(value, variable.valueType),
+		lastPass: -1,
+	}
+}
+func (action *APLActionSetVariable) GetAPLValues() []APLValue {
+	return []APLValue{action.value}
+}
+
+// Only ready when the value would change, so that the rest of the list is evaluated afterwards.
+// Runs at most once per action pass, so variables that depend on themselves (e.g. counters) don't loop forever.
+func (action *APLActionSetVariable) IsReady(sim *Simulation) bool {
+	if action.lastPass == action.rot.actionPass {
+		return false
+	}
+	return !action.variable.isSet || readAPLVariableValue(sim, action.value) != action.variable.value
+}
+func (action *APLActionSetVariable) Execute(sim *Simulation) {
+	action.lastPass = action.rot.actionPass
+	action.variable.isSet = true
+	action.variable.value = readAPLVariableValue(sim, action.value)
+}
+func (action *APLActionSetVariable) String() string {
+	return fmt.Sprintf("Set Variable(%s = %s)", action.variable.name, action.value)
+}
@@ -0,0 +1,290 @@
+package core
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	googleProto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// Static analysis of rotations. Validation warnings are emitted while parsing, when part of the
+// rotation has to be ignored. This pass runs after parsing and looks for things that parse fine
+// but probably don't do what was intended, e.g. actions that can never be reached.
+
+type aplDiagnosticLocation struct {
+	prepull    bool
+	actionList string
+	itemIdx    int
+}
+
+func (rot *APLRotation) addDiagnostic(loc aplDiagnosticLocation, kind proto.APLDiagnostic_Kind, message string, vals ...interface{}) {
+	diagnostic := &proto.APLDiagnostic{
+		Kind:       kind,
+		Message:    fmt.Sprintf(message, vals...),
+		Prepull:    loc.prepull,
+		ActionList: loc.actionList,
+		ItemIndex:  int32(loc.itemIdx),
+	}
+	for _, existing := range rot.diagnostics {
+		if googleProto.Equal(existing, diagnostic) {
+			return
+		}
+	}
+	rot.diagnostics = append(rot.diagnostics, diagnostic)
+}
+
+// Analyzes the rotation config, using the values and actions that were parsed from it.
+func (rot *APLRotation) analyze(config *proto.APLRotation) {
+	for i, item := range config.PrepullActions {
+		if item.Hide {
+			continue
+		}
+		loc := aplDiagnosticLocation{prepull: true, itemIdx: i}
+
+		if doAt := rot.parsedValues[item.DoAtValue]; doAt != nil {
+			if doAtTime := doAt.GetDuration(nil); doAtTime > 0 {
+				rot.addDiagnostic(loc, proto.APLDiagnostic_KindPrepullAfterStart, "Prepull action is scheduled at %s, after the pull at 0s, so it is never performed", doAtTime)
+				continue
+			}
+		}
+		rot.analyzeAction(loc, item.Action)
+	}
+
+	rot.analyzeList(aplDiagnosticLocation{}, config.PriorityList)
+	for _, list := range config.ActionLists {
+		rot.analyzeList(aplDiagnosticLocation{actionList: list.Name}, list.Items)
+	}
+}
+
+func (rot *APLRotation) analyzeList(loc aplDiagnosticLocation, items []*proto.APLListItem) {
+	alwaysPerformedIdx := -1
+	for i, item := range items {
+		if item.Hide || item.Action == nil {
+			continue
+		}
+		loc.itemIdx = i
+
+		if alwaysPerformedIdx != -1 {
+			rot.addDiagnostic(loc, proto.APLDiagnostic_KindUnreachableAction, "Never reached, because action #%d above is always performed", alwaysPerformedIdx+1)
+			continue
+		}
+
+		// Conditions that failed to parse are dropped, so only parsed conditions count.
+		alwaysTrue := true
+		if condition := item.Action.Condition; rot.parsedValues[condition] != nil {
+			val, isConst := rot.constBool(condition)
+			if isConst && !val {
+				rot.addDiagnostic(loc, proto.APLDiagnostic_KindUnreachableAction, "Condition is always false, so this action is never performed")
+				continue
+			}
+			alwaysTrue = isConst
+		}
+
+		rot.analyzeAction(loc, item.Action)
+		if alwaysTrue && rot.isAlwaysReady(item.Action) {
+			alwaysPerformedIdx = i
+		}
+	}
+}
+
+// Whether the action is ready regardless of the state of the sim, so that nothing after it in
+// a list is ever performed.
+func (rot *APLRotation) isAlwaysReady(config *proto.APLAction) bool {
+	action := rot.parsedActions[config]
+	if action == nil {
+		return false
+	}
+	switch impl := action.impl.(type) {
+	case *APLActionActionList:
+		return impl.run && impl.list != nil
+	case *APLActionWait:
+		_, isConst := impl.duration.(*APLValueConst)
+		return isConst && impl.duration.GetDuration(nil) > 0
+	case *APLActionActivateAura, *APLActionActivateAuraWithStacks, *APLActionAddComboPoints:
+		return true
+	}
+	return false
+}
+
+type aplSpellConfig interface {
+	GetSpellId() *proto.ActionID
+}
+type aplAuraConfig interface {
+	GetAuraId() *proto.ActionID
+	GetSourceUnit() *proto.UnitReference
+}
+
+// Checks the spells, auras and comparisons used anywhere within an action.
+func (rot *APLRotation) analyzeAction(loc aplDiagnosticLocation, config *proto.APLAction) {
+	if config == nil {
+		return
+	}
+	walkAPLConfigs(config.ProtoReflect(), func(m protoreflect.Message) {
+		var oneof protoreflect.OneofDescriptor
+		switch m.Interface().(type) {
+		case *proto.APLAction:
+			oneof = m.Descriptor().Oneofs().ByName("action")
+		case *proto.APLValue:
+			oneof = m.Descriptor().Oneofs().ByName("value")
+		default:
+			return
+		}
+		fd := m.WhichOneof(oneof)
+		if fd == nil {
+			return
+		}
+
+		switch kind := m.Get(fd).Message().Interface().(type) {
+		case *proto.APLValueSpellIsKnown, *proto.APLValueAuraIsKnown:
+			// These are how rotations check for spells and auras, so it's fine for them to be unknown.
+		case *proto.APLValueCompare:
+			rot.analyzeCompare(loc, kind)
+		case aplSpellConfig:
+			if kind.GetSpellId() == nil {
+				break
+			}
+			var spell *Spell
+			rot.doAndRecordWarnings(nil, loc.prepull, func() {
+				spell = rot.GetAPLSpell(kind.GetSpellId())
+			})
+			if spell == nil {
+				rot.addDiagnostic(loc, proto.APLDiagnostic_KindUnknownSpell, "%s does not know spell %s with the current talents and runes", rot.unit.Label, ProtoToActionID(kind.GetSpellId()))
+			}
+		case aplAuraConfig:
+			if kind.GetAuraId() == nil {
+				break
+			}
+			var sourceUnit UnitReference
+			rot.doAndRecordWarnings(nil, loc.prepull, func() {
+				if _, ok := kind.(*proto.APLValueAuraShouldRefresh); ok {
+					sourceUnit = rot.GetTargetUnit(kind.GetSourceUnit())
+				} else {
+					sourceUnit = rot.GetSourceUnit(kind.GetSourceUnit())
+				}
+			})
+			// Missing units are already reported by a warning.
+			unit := sourceUnit.Get()
+			if unit == nil {
+				break
+			}
+			aura, icdAura := NewAuraReference(sourceUnit, kind.GetAuraId()), NewIcdAuraReference(sourceUnit, kind.GetAuraId())
+			if aura.Get() == nil && icdAura.Get() == nil {
+				rot.addDiagnostic(loc, proto.APLDiagnostic_KindMissingAura, "Aura %s never exists on %s, so this condition is ignored", ProtoToActionID(kind.GetAuraId()), unit.Label)
+			}
+		}
+	})
+}
+
+// Comparisons convert both sides to the same type. That's fine for constants, which don't have a
+// type of their own, e.g. 1.5 compared with a duration means 1.5s, but otherwise it's likely a
+// mistake.
+func (rot *APLRotation) analyzeCompare(loc aplDiagnosticLocation, config *proto.APLValueCompare) {
+	lhs, rhs := rot.parsedValues[config.Lhs], rot.parsedValues[config.Rhs]
+	if lhs == nil || rhs == nil || rot.isConstValue(config.Lhs) || rot.isConstValue(config.Rhs) {
+		return
+	}
+
+	lhsType, rhsType := aplValueTypeName(lhs.Type()), aplValueTypeName(rhs.Type())
+	if lhsType == rhsType {
+		return
+	}
+	fromType, toType := lhsType, rhsType
+	if higherOrderType(lhs.Type(), rhs.Type()) == lhs.Type() {
+		fromType, toType = rhsType, lhsType
+	}
+	rot.addDiagnostic(loc, proto.APLDiagnostic_KindMismatchedTypes, "Compares a %s with a %s, the %s is converted to a %s", lhsType, rhsType, fromType, toType)
+}
+
+// Names value types as they're compared, ints and floats are both numbers.
+func aplValueTypeName(valueType proto.APLValueType) string {
+	if valueType == proto.APLValueType_ValueTypeInt || valueType == proto.APLValueType_ValueTypeFloat {
+		return "number"
+	}
+	return strings.ToLower(strings.TrimPrefix(valueType.String(), "ValueType"))
+}
+
+// Whether the value is the same for the whole sim, i.e. made only of constants and math on them.
+func (rot *APLRotation) isConstValue(config *proto.APLValue) bool {
+	if rot.parsedValues[config] == nil {
+		return false
+	}
+	switch value := config.Value.(type) {
+	case *proto.APLValue_Const:
+		return true
+	case *proto.APLValue_Math:
+		return rot.isConstValue(value.Math.Lhs) && rot.isConstValue(value.Math.Rhs)
+	case *proto.APLValue_Min:
+		return !slices.ContainsFunc(value.Min.Vals, rot.isNonConstValue)
+	case *proto.APLValue_Max:
+		return !slices.ContainsFunc(value.Max.Vals, rot.isNonConstValue)
+	}
+	return false
+}
+func (rot *APLRotation) isNonConstValue(config *proto.APLValue) bool {
+	return !rot.isConstValue(config)
+}
+
+// Returns the value of a boolean expression, and whether it's the same for the whole sim.
+// Besides constants, spell_is_known and aura_is_known never change during a sim.
+func (rot *APLRotation) constBool(config *proto.APLValue) (bool, bool) {
+	value := rot.parsedValues[config]
+	if value == nil {
+		return false, false
+	}
+
+	switch v := config.Value.(type) {
+	case *proto.APLValue_Const, *proto.APLValue_SpellIsKnown, *proto.APLValue_AuraIsKnown:
+		return value.GetBool(nil), true
+	case *proto.APLValue_Cmp:
+		if rot.isConstValue(v.Cmp.Lhs) && rot.isConstValue(v.Cmp.Rhs) {
+			return value.GetBool(nil), true
+		}
+	case *proto.APLValue_Not:
+		val, isConst := rot.constBool(v.Not.Val)
+		return !val, isConst
+	case *proto.APLValue_And:
+		return rot.constBoolJoined(v.And.Vals, false)
+	case *proto.APLValue_Or:
+		return rot.constBoolJoined(v.Or.Vals, true)
+	}
+	return false, false
+}
+
+// Folds an and (shortCircuit false) or an or (shortCircuit true). Values that failed to parse
+// are left out of these operators, so they're skipped here too.
+func (rot *APLRotation) constBoolJoined(configs []*proto.APLValue, shortCircuit bool) (bool, bool) {
+	allConst := true
+	for _, config := range configs {
+		if rot.parsedValues[config] == nil {
+			continue
+		}
+		val, isConst := rot.constBool(config)
+		if isConst && val == shortCircuit {
+			return shortCircuit, true
+		}
+		allConst = allConst && isConst
+	}
+	return !shortCircuit, allConst
+}
+
+// Calls fn for m and every message nested within it.
+func walkAPLConfigs(m protoreflect.Message, fn func(protoreflect.Message)) {
+	fn(m)
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.MessageKind || fd.IsMap() {
+			return true
+		}
+		if fd.IsList() {
+			list := v.List()
+			for i := 0; i < list.Len(); i++ {
+				walkAPLConfigs(list.Get(i).Message(), fn)
+			}
+		} else {
+			walkAPLConfigs(v.Message(), fn)
+		}
+		return true
+	})
+}
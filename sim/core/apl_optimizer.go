@@ -0,0 +1,345 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/simsignals"
+	googleProto "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+// A constant of the rotation being optimized, see APLValueConst.tunable.
+type aplTunable struct {
+	config *proto.APLValueConst
+	name   string
+	suffix string // Unit the constant is written with, which is kept when changing it.
+
+	min, max, step float64
+	initialVal     string  // Value the rotation is first simmed with, snapped to the range and step.
+	value          float64 // Best value found so far.
+}
+
+func newAPLTunable(config *proto.APLValueConst, location string) (*aplTunable, error) {
+	tunable := &aplTunable{
+		config: config,
+		name:   config.Tunable.Name,
+		min:    config.Tunable.Min,
+		max:    config.Tunable.Max,
+		step:   config.Tunable.Step,
+	}
+	if tunable.name == "" {
+		tunable.name = location
+	}
+
+	for _, suffix := range []string{"ms", "s", "%"} {
+		if strings.HasSuffix(config.Val, suffix) {
+			tunable.suffix = suffix
+			break
+		}
+	}
+	value, err := strconv.ParseFloat(strings.TrimSuffix(config.Val, tunable.suffix), 64)
+	if err != nil {
+		return nil, fmt.Errorf("tunable constant %q at %s is not a number", config.Val, location)
+	}
+	if tunable.max <= tunable.min {
+		return nil, fmt.Errorf("tunable constant at %s has an empty range", location)
+	}
+
+	if tunable.step <= 0 {
+		isInt := func(v float64) bool { return v == math.Trunc(v) }
+		if isInt(value) && isInt(tunable.min) && isInt(tunable.max) && !strings.Contains(config.Val, ".") {
+			tunable.step = 1
+		} else {
+			tunable.step = (tunable.max - tunable.min) / 100
+		}
+	}
+	tunable.value = tunable.snap(value)
+	tunable.initialVal = tunable.format(tunable.value)
+	return tunable, nil
+}
+
+// Rounds value to the nearest step within the range.
+func (tunable *aplTunable) snap(value float64) float64 {
+	value = tunable.min + math.Round((value-tunable.min)/tunable.step)*tunable.step
+	value = min(max(value, tunable.min), tunable.max)
+	// Avoid printing float errors, e.g. 0.30000000000000004.
+	return math.Round(value*1e9) / 1e9
+}
+
+func (tunable *aplTunable) format(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64) + tunable.suffix
+}
+
+type aplOptimizer struct {
+	signals   simsignals.Signals
+	request   *proto.RaidSimRequest // Working copy, the tunables point into its rotation.
+	playerIdx int
+	tunables  []*aplTunable
+
+	// DPS distribution for each combination of tunable values that was simmed.
+	results map[string]*proto.DistributionMetrics
+}
+
+func runAPLOptimizer(request *proto.APLOptimizerRequest, signals simsignals.Signals) *proto.APLOptimizerResult {
+	result, err := optimizeAPL(request, signals)
+	if err != nil {
+		errorType := proto.ErrorOutcomeType_ErrorOutcomeError
+		if signals.Abort.IsTriggered() {
+			errorType = proto.ErrorOutcomeType_ErrorOutcomeAborted
+		}
+		return &proto.APLOptimizerResult{Error: &proto.ErrorOutcome{Type: errorType, Message: err.Error()}}
+	}
+	return result
+}
+
+func optimizeAPL(request *proto.APLOptimizerRequest, signals simsignals.Signals) (*proto.APLOptimizerResult, error) {
+	if request.RaidSimRequest == nil {
+		return nil, errors.New("no raid sim request")
+	}
+	opt := &aplOptimizer{
+		signals:   signals,
+		request:   googleProto.Clone(request.RaidSimRequest).(*proto.RaidSimRequest),
+		playerIdx: int(request.PlayerIndex),
+		results:   make(map[string]*proto.DistributionMetrics),
+	}
+
+	player := opt.player(opt.request)
+	if player == nil {
+		return nil, fmt.Errorf("no player with index %d", request.PlayerIndex)
+	}
+	if player.Rotation == nil {
+		return nil, errors.New("player has no rotation")
+	}
+	var err error
+	if opt.tunables, err = findAPLTunables(player.Rotation); err != nil {
+		return nil, err
+	}
+	if len(opt.tunables) == 0 {
+		return nil, errors.New("rotation has no tunable constants")
+	}
+
+	// Every sim uses the same random numbers, like stat weights, so that small differences show up
+	// with few iterations. Per-iteration values are needed for the paired deltas.
+	if opt.request.SimOptions == nil {
+		opt.request.SimOptions = &proto.SimOptions{}
+	}
+	simOptions := opt.request.SimOptions
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
+	}
+	simOptions.UseLabeledRands = true
+	simOptions.SaveAllValues = true
+	simOptions.Debug = false
+	simOptions.ReplaySeed = 0
+	simOptions.CombatLogIterations = 0
+	simOptions.MaxDpsStderr = 0
+	simOptions.MaxDpsRelativeStderr = 0
+
+	initial, err := opt.evaluate()
+	if err != nil {
+		return nil, err
+	}
+	best := initial
+
+	maxRounds := request.MaxRounds
+	if maxRounds <= 0 {
+		maxRounds = 3
+	}
+	for round := int32(0); round < maxRounds; round++ {
+		changed := false
+		for _, tunable := range opt.tunables {
+			var improved bool
+			if best, improved, err = opt.optimizeTunable(tunable, best); err != nil {
+				return nil, err
+			}
+			changed = changed || improved
+		}
+		if !changed {
+			break
+		}
+	}
+
+	result := &proto.APLOptimizerResult{
+		InitialDps: initial.Avg,
+		BestDps:    best.Avg,
+	}
+	numPoints := request.SensitivityPoints
+	if numPoints <= 0 {
+		numPoints = 5
+	}
+	for _, tunable := range opt.tunables {
+		sensitivity, err := opt.sensitivity(tunable, best, max(numPoints, 2))
+		if err != nil {
+			return nil, err
+		}
+		result.Tunables = append(result.Tunables, &proto.APLTunableResult{
+			Name:        tunable.name,
+			InitialVal:  tunable.initialVal,
+			BestVal:     tunable.format(tunable.value),
+			Sensitivity: sensitivity,
+		})
+	}
+
+	opt.setVals()
+	result.Rotation = opt.player(opt.request).Rotation
+	result.SimsRun = int32(len(opt.results))
+	return result, nil
+}
+
+// Returns the player with the given index, counting through all parties in order.
+func (opt *aplOptimizer) player(request *proto.RaidSimRequest) *proto.Player {
+	idx := opt.playerIdx
+	for _, party := range request.GetRaid().GetParties() {
+		if idx < len(party.Players) {
+			return party.Players[idx]
+		}
+		idx -= len(party.Players)
+	}
+	return nil
+}
+
+// Coordinate descent on a single tunable, with the others fixed. Steps towards whichever
+// neighbor is better, halving the step size when neither is, until it's below the tunable's step.
+func (opt *aplOptimizer) optimizeTunable(tunable *aplTunable, best *proto.DistributionMetrics) (*proto.DistributionMetrics, bool, error) {
+	improved := false
+	for stepSize := max((tunable.max-tunable.min)/4, tunable.step); stepSize >= tunable.step; {
+		moved := false
+		for _, candidate := range []float64{tunable.value - stepSize, tunable.value + stepSize} {
+			candidate = tunable.snap(candidate)
+			if candidate == tunable.value {
+				continue
+			}
+			dps, err := opt.evaluateWith(tunable, candidate)
+			if err != nil {
+				return nil, false, err
+			}
+			if dps.Avg > best.Avg {
+				tunable.value = candidate
+				best = dps
+				moved, improved = true, true
+				break
+			}
+		}
+		if !moved {
+			stepSize /= 2
+		}
+	}
+	return best, improved, nil
+}
+
+// Sims values across the range of a tunable, along with its best value.
+func (opt *aplOptimizer) sensitivity(tunable *aplTunable, best *proto.DistributionMetrics, numPoints int32) ([]*proto.APLTunableSample, error) {
+	values := []float64{tunable.value}
+	for i := int32(0); i < numPoints; i++ {
+		values = append(values, tunable.snap(tunable.min+(tunable.max-tunable.min)*float64(i)/float64(numPoints-1)))
+	}
+	slices.Sort(values)
+	values = slices.Compact(values)
+
+	samples := make([]*proto.APLTunableSample, 0, len(values))
+	for _, value := range values {
+		dps, err := opt.evaluateWith(tunable, value)
+		if err != nil {
+			return nil, err
+		}
+		samples = append(samples, &proto.APLTunableSample{
+			Val:      tunable.format(value),
+			Dps:      dps.Avg,
+			DpsDelta: newPairedDelta(best, dps),
+		})
+	}
+	return samples, nil
+}
+
+// Sims the rotation with one tunable changed, and the others at their best values.
+func (opt *aplOptimizer) evaluateWith(tunable *aplTunable, value float64) (*proto.DistributionMetrics, error) {
+	bestValue := tunable.value
+	tunable.value = value
+	defer func() { tunable.value = bestValue }()
+	return opt.evaluate()
+}
+
+// Writes the current tunable values into the rotation, and returns them joined.
+func (opt *aplOptimizer) setVals() string {
+	vals := make([]string, len(opt.tunables))
+	for i, tunable := range opt.tunables {
+		vals[i] = tunable.format(tunable.value)
+		tunable.config.Val = vals[i]
+	}
+	return strings.Join(vals, ",")
+}
+
+// Sims the rotation with the current tunable values, reusing earlier results.
+func (opt *aplOptimizer) evaluate() (*proto.DistributionMetrics, error) {
+	key := opt.setVals()
+	if dps, ok := opt.results[key]; ok {
+		return dps, nil
+	}
+
+	result := runSimConcurrent(googleProto.Clone(opt.request).(*proto.RaidSimRequest), nil, opt.signals)
+	if result.Error != nil {
+		return nil, fmt.Errorf("sim failed with %s: %s", key, result.Error.Message)
+	}
+	var player *proto.UnitMetrics
+	idx := opt.playerIdx
+	for _, party := range result.RaidMetrics.Parties {
+		if idx < len(party.Players) {
+			player = party.Players[idx]
+			break
+		}
+		idx -= len(party.Players)
+	}
+	opt.results[key] = player.Dps
+	return player.Dps, nil
+}
+
+// Returns all constants of a rotation that are marked as tunable.
+func findAPLTunables(rotation *proto.APLRotation) ([]*aplTunable, error) {
+	var tunables []*aplTunable
+	addItem := func(location string, config protoreflect.ProtoMessage) error {
+		var consts []*proto.APLValueConst
+		walkAPLConfigs(config.ProtoReflect(), func(m protoreflect.Message) {
+			if constConfig, ok := m.Interface().(*proto.APLValueConst); ok && constConfig.Tunable != nil {
+				consts = append(consts, constConfig)
+			}
+		})
+		for i, constConfig := range consts {
+			constLocation := location
+			if len(consts) > 1 {
+				constLocation = fmt.Sprintf("%s, constant %d", location, i+1)
+			}
+			tunable, err := newAPLTunable(constConfig, constLocation)
+			if err != nil {
+				return err
+			}
+			tunables = append(tunables, tunable)
+		}
+		return nil
+	}
+
+	for i, item := range rotation.PrepullActions {
+		if err := addItem(fmt.Sprintf("prepull #%d", i+1), item); err != nil {
+			return nil, err
+		}
+	}
+	for i, item := range rotation.PriorityList {
+		if err := addItem(fmt.Sprintf("priority list #%d", i+1), item); err != nil {
+			return nil, err
+		}
+	}
+	for _, list := range rotation.ActionLists {
+		for i, item := range list.Items {
+			if err := addItem(fmt.Sprintf("list '%s' #%d", list.Name, i+1), item); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return tunables, nil
+}
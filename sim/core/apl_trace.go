@@ -0,0 +1,79 @@
+package core
+
+import (
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+// Counts how an item of an APL list was considered while picking actions, see SimOptions.AplTrace.
+// Methods are no-ops on a nil trace, which is used when tracing is disabled.
+type aplItemTrace struct {
+	actionList string
+	itemIdx    int
+
+	evaluations   int64
+	conditionTrue int64
+	notReadyCount int64
+	executions    int64
+}
+
+func (trace *aplItemTrace) evaluated() {
+	if trace != nil {
+		trace.evaluations++
+	}
+}
+func (trace *aplItemTrace) conditionWasTrue() {
+	if trace != nil {
+		trace.conditionTrue++
+	}
+}
+func (trace *aplItemTrace) notReady() {
+	if trace != nil {
+		trace.notReadyCount++
+	}
+}
+func (trace *aplItemTrace) executed() {
+	if trace != nil {
+		trace.executions++
+	}
+}
+
+func (trace *aplItemTrace) ToProto() *proto.APLItemMetrics {
+	return &proto.APLItemMetrics{
+		ActionList:    trace.actionList,
+		ItemIndex:     int32(trace.itemIdx),
+		Evaluations:   trace.evaluations,
+		ConditionTrue: trace.conditionTrue,
+		NotReady:      trace.notReadyCount,
+		Executions:    trace.executions,
+	}
+}
+
+// Starts tracing the items of the priority list and all action lists.
+func (rot *APLRotation) enableTrace() {
+	if rot.traces != nil {
+		return
+	}
+	for i, action := range rot.priorityList {
+		action.trace = &aplItemTrace{itemIdx: rot.priorityListConfigIdxs[i]}
+		rot.traces = append(rot.traces, action.trace)
+	}
+	for _, list := range rot.actionLists {
+		for i, action := range list.actions {
+			action.trace = &aplItemTrace{actionList: list.name, itemIdx: list.configIdxs[i]}
+			rot.traces = append(rot.traces, action.trace)
+		}
+	}
+}
+
+// Picks the next action to perform, recording it in the traces. Other lookups of the next action,
+// e.g. to check whether a channel should be interrupted, aren't decisions so they aren't traced.
+func (rot *APLRotation) getNextTracedAction(sim *Simulation) *APLAction {
+	rot.tracing = rot.traces != nil
+	nextAction := rot.getNextAction(sim)
+	rot.tracing = false
+	return nextAction
+}
+
+func (rot *APLRotation) getTraceMetrics() []*proto.APLItemMetrics {
+	return MapSlice(rot.traces, (*aplItemTrace).ToProto)
+}
@@ -51,10 +51,13 @@ func (impl DefaultAPLValueImpl) GetString(_ *Simulation) string {
 	panic("Unimplemented GetString")
 }
 
-func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
+func (rot *APLRotation) newAPLValue(config *proto.APLValue) (value APLValue) {
 	if config == nil {
 		return nil
 	}
+	if rot.parsedValues != nil {
+		defer func() { rot.parsedValues[config] = value }()
+	}
 
 	customValue := rot.unit.Env.GetAgentFromUnit(rot.unit).NewAPLValue(rot, config)
 	if customValue != nil {
@@ -93,6 +96,12 @@ func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
 		return rot.newValueIsExecutePhase(config.GetIsExecutePhase())
 	case *proto.APLValue_NumberTargets:
 		return rot.newValueNumberTargets(config.GetNumberTargets())
+	case *proto.APLValue_TargetPhase:
+		return rot.newValueTargetPhase(config.GetTargetPhase())
+	case *proto.APLValue_TargetIsAvailable:
+		return rot.newValueTargetIsAvailable(config.GetTargetIsAvailable())
+	case *proto.APLValue_TimeUntilTargetAvailable:
+		return rot.newValueTimeUntilTargetAvailable(config.GetTimeUntilTargetAvailable())
 
 	// Resources
 	case *proto.APLValue_CurrentHealth:
@@ -190,6 +199,10 @@ func (rot *APLRotation) newAPLValue(config *proto.APLValue) APLValue {
 	case *proto.APLValue_SequenceTimeToReady:
 		return rot.newValueSequenceTimeToReady(config.GetSequenceTimeToReady())
 
+	// Variables
+	case *proto.APLValue_Variable:
+		return rot.newValueVariable(config.GetVariable())
+
 	// Properties
 	case *proto.APLValue_ChannelClipDelay:
 		return rot.newValueChannelClipDelay(config.GetChannelClipDelay())
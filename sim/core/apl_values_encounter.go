@@ -122,3 +122,64 @@ func (value *APLValueIsExecutePhase) GetBool(sim *Simulation) bool {
 func (value *APLValueIsExecutePhase) String() string {
 	return "Is Execute Phase"
 }
+
+type APLValueTargetPhase struct {
+	DefaultAPLValueImpl
+	targetUnit UnitReference
+}
+
+func (rot *APLRotation) newValueTargetPhase(config *proto.APLValueTargetPhase) APLValue {
+	targetUnit := rot.GetTargetUnit(config.TargetUnit)
+	if targetUnit.Get() == nil {
+		return nil
+	} else if targetUnit.Get().Type != EnemyUnit {
+		rot.ValidationWarning("%s is not an enemy", targetUnit.Get().Label)
+		return nil
+	}
+	return &APLValueTargetPhase{
+		targetUnit: targetUnit,
+	}
+}
+func (value *APLValueTargetPhase) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeInt
+}
+func (value *APLValueTargetPhase) GetInt(sim *Simulation) int32 {
+	return sim.Encounter.AllTargets[value.targetUnit.Get().Index].Phase()
+}
+func (value *APLValueTargetPhase) String() string {
+	return fmt.Sprintf("Target Phase(%s)", value.targetUnit.String())
+}
+
+type APLValueTargetIsAvailable struct {
+	DefaultAPLValueImpl
+}
+
+func (rot *APLRotation) newValueTargetIsAvailable(config *proto.APLValueTargetIsAvailable) APLValue {
+	return &APLValueTargetIsAvailable{}
+}
+func (value *APLValueTargetIsAvailable) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeBool
+}
+func (value *APLValueTargetIsAvailable) GetBool(sim *Simulation) bool {
+	return sim.Encounter.TargetsAvailable()
+}
+func (value *APLValueTargetIsAvailable) String() string {
+	return "Target Is Available"
+}
+
+type APLValueTimeUntilTargetAvailable struct {
+	DefaultAPLValueImpl
+}
+
+func (rot *APLRotation) newValueTimeUntilTargetAvailable(config *proto.APLValueTimeUntilTargetAvailable) APLValue {
+	return &APLValueTimeUntilTargetAvailable{}
+}
+func (value *APLValueTimeUntilTargetAvailable) Type() proto.APLValueType {
+	return proto.APLValueType_ValueTypeDuration
+}
+func (value *APLValueTimeUntilTargetAvailable) GetDuration(sim *Simulation) time.Duration {
+	return sim.Encounter.TimeUntilTargetsAvailable(sim)
+}
+func (value *APLValueTimeUntilTargetAvailable) String() string {
+	return "Time Until Target Available"
+}
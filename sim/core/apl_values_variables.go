@@ -0,0 +1,49 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+type APLValueVariable struct {
+	DefaultAPLValueImpl
+	variable *aplVariable
+}
+
+func (rot *APLRotation) newValueVariable(config *proto.APLValueVariable) APLValue {
+	if config.Name == "" {
+		rot.ValidationWarning("Variable must provide a variable name")
+		return nil
+	}
+	variable := rot.variables[config.Name]
+	if variable == nil {
+		rot.ValidationWarning("No variable with name: '%s'", config.Name)
+		return nil
+	}
+	return &APLValueVariable{
+		variable: variable,
+	}
+}
+func (value *APLValueVariable) Type() proto.APLValueType {
+	return value.variable.valueType
+}
+func (value *APLValueVariable) GetBool(sim *Simulation) bool {
+	return value.variable.value.boolVal
+}
+func (value *APLValueVariable) GetInt(sim *Simulation) int32 {
+	return value.variable.value.intVal
+}
+func (value *APLValueVariable) GetFloat(sim *Simulation) float64 {
+	return value.variable.value.floatVal
+}
+func (value *APLValueVariable) GetDuration(sim *Simulation) time.Duration {
+	return value.variable.value.durationVal
+}
+func (value *APLValueVariable) GetString(sim *Simulation) string {
+	return value.variable.value.stringVal
+}
+func (value *APLValueVariable) String() string {
+	return fmt.Sprintf("Variable(%s)", value.variable.name)
+}
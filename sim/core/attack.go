@@ -693,6 +693,32 @@ func (aa *AutoAttacks) EnableAutoSwing(sim *Simulation) {
 	}
 }
 
+// Adds or removes the melee swings after the unit got into or out of melee range, while auto attacks are on.
+func (aa *AutoAttacks) updateMeleeRange(sim *Simulation, wasInRange bool) {
+	if !aa.AutoSwingMelee || !aa.enabled {
+		return
+	}
+
+	inRange := aa.mh.unit.DistanceFromTarget <= MaxMeleeAttackDistance
+	if inRange == wasInRange {
+		return
+	}
+
+	if inRange {
+		aa.mh.swingAt = max(aa.mh.swingAt, sim.CurrentTime, 0)
+		aa.mh.addWeaponAttack(sim, aa.mh.unit.SwingSpeed())
+		if aa.IsDualWielding {
+			aa.oh.swingAt = max(aa.oh.swingAt, sim.CurrentTime, 0)
+			aa.oh.addWeaponAttack(sim, aa.mh.unit.SwingSpeed())
+		}
+	} else {
+		sim.removeWeaponAttack(&aa.mh)
+		if aa.IsDualWielding {
+			sim.removeWeaponAttack(&aa.oh)
+		}
+	}
+}
+
 // The amount of time between two MH swings.
 func (aa *AutoAttacks) MainhandSwingSpeed() time.Duration {
 	return aa.mh.curSwingDuration
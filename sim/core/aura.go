@@ -179,6 +179,9 @@ func (aura *Aura) Refresh(sim *Simulation) {
 	if sim.Log != nil && aura.IsActive() && !aura.ActionID.IsEmptyAction() {
 		aura.Unit.Log(sim, "Aura refreshed: %s", aura.ActionID)
 	}
+	if sim.CombatLog != nil && aura.IsActive() && !aura.ActionID.IsEmptyAction() {
+		sim.CombatLog.aura(sim, aura, proto.CombatLogAura_Refreshed)
+	}
 }
 
 func (aura *Aura) GetStacks() int32 {
@@ -209,6 +212,9 @@ func (aura *Aura) SetStacks(sim *Simulation, newStacks int32) {
 		aura.Unit.Log(sim, "%s stacks: %d --> %d", aura.ActionID, oldStacks, newStacks)
 	}
 	aura.stacks = newStacks
+	if sim.CombatLog != nil && !aura.ActionID.IsEmptyAction() {
+		sim.CombatLog.aura(sim, aura, proto.CombatLogAura_StacksChanged)
+	}
 	if aura.OnStacksChange != nil {
 		aura.OnStacksChange(aura, sim, oldStacks, newStacks)
 	}
@@ -653,6 +659,9 @@ func (aura *Aura) Activate(sim *Simulation) {
 	if sim.Log != nil && !aura.ActionID.IsEmptyAction() {
 		aura.Unit.Log(sim, "Aura gained: %s", aura.ActionID)
 	}
+	if sim.CombatLog != nil && !aura.ActionID.IsEmptyAction() {
+		sim.CombatLog.aura(sim, aura, proto.CombatLogAura_Gained)
+	}
 
 	// don't invoke possible callbacks until the internal state is consistent
 	if aura.OnGain != nil {
@@ -675,6 +684,9 @@ func (aura *Aura) Deactivate(sim *Simulation) {
 		if sim.Log != nil {
 			aura.Unit.Log(sim, "Aura faded: %s", aura.ActionID)
 		}
+		if sim.CombatLog != nil {
+			sim.CombatLog.aura(sim, aura, proto.CombatLogAura_Faded)
+		}
 		sim.CurrentTime = oldTime
 	}
 
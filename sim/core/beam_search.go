@@ -0,0 +1,209 @@
+package core
+
+import (
+	"cmp"
+	"runtime"
+	"slices"
+	"strings"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/simsignals"
+)
+
+// Search shared by the gear and talent optimizers: every round, all candidates one change away
+// from the best few candidates so far are simmed, and the best of those are kept for the next
+// round. Iterations start low and double each round like in fast mode, so the final rounds compare
+// the best candidates accurately.
+
+const maxOptimizerRounds = 20
+
+// A variation of the player, e.g. an equipment set or a talent build.
+type beamCandidate interface {
+	// Identifies the candidate, candidates with the same key give the same sim results.
+	searchKey() string
+	// Changes the player of a copy of the base request into the candidate.
+	applyTo(player *proto.Player)
+}
+
+type beamResult[T beamCandidate] struct {
+	candidate   T
+	iterations  int32
+	unitMetrics *proto.UnitMetrics
+}
+
+type beamSearch[T beamCandidate] struct {
+	baseRequest *proto.RaidSimRequest // See newOptimizerBaseRequest.
+	signals     simsignals.Signals
+	progress    chan *proto.ProgressMetrics
+
+	results map[string]*beamResult[T]
+
+	// Totals over all rounds, for progress reports.
+	simsRun         int32
+	simsTotal       int32
+	iterationsDone  int32
+	iterationsTotal int32
+}
+
+func newBeamSearch[T beamCandidate](baseRequest *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, signals simsignals.Signals) *beamSearch[T] {
+	return &beamSearch[T]{
+		baseRequest: baseRequest,
+		signals:     signals,
+		progress:    progress,
+		results:     make(map[string]*beamResult[T]),
+	}
+}
+
+// Iterations of the first round, the same as bulk sim's fast mode.
+func beamStartIterations(fullIterations int32) int32 {
+	return min(max(fullIterations/100, 50), 1000, fullIterations)
+}
+
+// Searches the neighbors of start until the best candidates stop changing with the full iterations.
+// Returns the results of the last round ranked by DPS, which always include start.
+func (search *beamSearch[T]) run(start T, neighbors func(T) []T, beamWidth int32, fullIterations int32) ([]*beamResult[T], *proto.ErrorOutcome) {
+	if beamWidth <= 0 {
+		beamWidth = 5
+	}
+	iterations := beamStartIterations(fullIterations)
+	beam := []T{start}
+	var pool []*beamResult[T]
+	for round := 0; round < maxOptimizerRounds; round++ {
+		candidates := []T{start}
+		for _, candidate := range beam {
+			candidates = append(candidates, candidate)
+			candidates = append(candidates, neighbors(candidate)...)
+		}
+
+		var errorOutcome *proto.ErrorOutcome
+		if pool, errorOutcome = search.sim(candidates, iterations); errorOutcome != nil {
+			return nil, errorOutcome
+		}
+
+		prevBeam := beam
+		beam = nil
+		for _, result := range pool[:min(int(beamWidth), len(pool))] {
+			beam = append(beam, result.candidate)
+		}
+		converged := slices.EqualFunc(prevBeam, beam, func(a, b T) bool { return a.searchKey() == b.searchKey() })
+		if converged && iterations == fullIterations {
+			break
+		}
+		iterations = min(iterations*2, fullIterations)
+	}
+	return pool, nil
+}
+
+// Sims all distinct candidates with the given iterations, reusing earlier results with the same
+// iterations. Returns the results ranked by DPS.
+func (search *beamSearch[T]) sim(candidates []T, iterations int32) ([]*beamResult[T], *proto.ErrorOutcome) {
+	var ranked []*beamResult[T]
+	var toSim []T
+	seen := make(map[string]bool)
+	for _, candidate := range candidates {
+		key := candidate.searchKey()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		if result, ok := search.results[key]; ok && result.iterations == iterations {
+			ranked = append(ranked, result)
+		} else {
+			toSim = append(toSim, candidate)
+		}
+	}
+
+	concurrency := runtime.NumCPU() + 1
+	tickets := make(chan struct{}, concurrency)
+	results := make(chan *beamResult[T], concurrency)
+	errors := make(chan *proto.ErrorOutcome, concurrency)
+	go func() {
+		for _, candidate := range toSim {
+			tickets <- struct{}{}
+			go func(candidate T) {
+				defer func() { <-tickets }()
+				request := goproto.Clone(search.baseRequest).(*proto.RaidSimRequest)
+				request.SimOptions.Iterations = iterations
+				candidate.applyTo(request.Raid.Parties[0].Players[0])
+
+				simResult := runSim(request, nil, false, search.signals)
+				if simResult.Error != nil {
+					errors <- simResult.Error
+					return
+				}
+				um := simResult.RaidMetrics.Parties[0].Players[0]
+				um.Actions = nil
+				um.Auras = nil
+				um.Resources = nil
+				um.Pets = nil
+				results <- &beamResult[T]{candidate: candidate, iterations: iterations, unitMetrics: um}
+			}(candidate)
+		}
+	}()
+
+	search.simsTotal += int32(len(toSim))
+	search.iterationsTotal += int32(len(toSim)) * iterations
+	var errorOutcome *proto.ErrorOutcome
+	for range toSim {
+		select {
+		case result := <-results:
+			search.results[result.candidate.searchKey()] = result
+			ranked = append(ranked, result)
+		case err := <-errors:
+			if errorOutcome == nil {
+				errorOutcome = err
+				search.signals.Abort.Trigger()
+			}
+		}
+		search.simsRun++
+		search.iterationsDone += iterations
+		if search.progress != nil {
+			search.progress <- &proto.ProgressMetrics{
+				CompletedSims:       search.simsRun,
+				TotalSims:           search.simsTotal,
+				CompletedIterations: search.iterationsDone,
+				TotalIterations:     search.iterationsTotal,
+			}
+		}
+	}
+	if errorOutcome != nil {
+		return nil, errorOutcome
+	}
+
+	slices.SortStableFunc(ranked, func(a, b *beamResult[T]) int {
+		return cmp.Or(cmp.Compare(b.unitMetrics.Dps.Avg, a.unitMetrics.Dps.Avg), strings.Compare(a.candidate.searchKey(), b.candidate.searchKey()))
+	})
+	return ranked, nil
+}
+
+// Converts the result of start and the best topK results of the pool with toProto, which is given
+// their DPS difference to start. Per-iteration values are cleared afterwards, unless the base
+// settings asked for them.
+func beamFinalResults[T beamCandidate, R any](start T, pool []*beamResult[T], topK int32, saveAllValues bool, toProto func(*beamResult[T], *proto.PairedDelta) R) (R, []R) {
+	var startResult *beamResult[T]
+	for _, result := range pool {
+		if result.candidate.searchKey() == start.searchKey() {
+			startResult = result
+		}
+	}
+	if topK <= 0 {
+		topK = 5
+	}
+	best := pool[:min(int(topK), len(pool))]
+
+	startProto := toProto(startResult, newPairedDelta(startResult.unitMetrics.Dps, startResult.unitMetrics.Dps))
+	bestProtos := make([]R, len(best))
+	for i, result := range best {
+		bestProtos[i] = toProto(result, newPairedDelta(startResult.unitMetrics.Dps, result.unitMetrics.Dps))
+	}
+
+	if !saveAllValues {
+		clearAllValues(startResult.unitMetrics)
+		for _, result := range best {
+			clearAllValues(result.unitMetrics)
+		}
+	}
+	return startProto, bestProtos
+}
@@ -0,0 +1,236 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/descriptorpb"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/simsignals"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+// IndividualBuffs fields that are world buffs, the others are given by other players.
+var worldBuffFields = []protoreflect.Name{
+	"rallying_cry_of_the_dragonslayer",
+	"sayges_fortune",
+	"spirit_of_zandalar",
+	"songflower_serenade",
+	"warchiefs_blessing",
+}
+
+// A single change to the request.
+type buffToggle struct {
+	category string
+	field    string
+	value    string
+	apply    func(request *proto.RaidSimRequest)
+}
+
+// Fields chosen with BuffValuesRequest.fields, as category and field name, e.g. Consumes.flask.
+// Without any, only the fields that are set are toggled.
+type buffFieldSelection struct {
+	fields  []string
+	matched map[string]bool
+}
+
+// Whether path or one of its parent messages was chosen, so that it's set to each of its values.
+func (selection *buffFieldSelection) isChosen(path string) bool {
+	for _, field := range selection.fields {
+		if path == field || strings.HasPrefix(path, field+".") {
+			selection.matched[field] = true
+			return true
+		}
+	}
+	return false
+}
+
+// Whether a message field needs to be searched for chosen fields.
+func (selection *buffFieldSelection) hasChosenWithin(path string) bool {
+	return len(selection.fields) == 0 || selection.isChosen(path) || slices.ContainsFunc(selection.fields, func(field string) bool {
+		return strings.HasPrefix(field, path+".")
+	})
+}
+
+// Returns a toggle for every other value of each chosen field: bools are flipped, enums are set to
+// each of their other values, and numbers are set to 0 if they aren't already. Without chosen
+// fields, the fields that are set are turned off instead.
+func addBuffToggles(toggles []buffToggle, selection *buffFieldSelection, category string, prefix string, msg protoreflect.Message, fieldNames []protoreflect.Name, get func(*proto.RaidSimRequest) protoreflect.Message) []buffToggle {
+	fields := msg.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fieldNames != nil && !slices.Contains(fieldNames, fd.Name()) {
+			continue
+		}
+		if options, ok := fd.Options().(*descriptorpb.FieldOptions); ok && options.GetDeprecated() {
+			continue
+		}
+
+		set := func(value protoreflect.Value) func(*proto.RaidSimRequest) {
+			return func(request *proto.RaidSimRequest) { get(request).Set(fd, value) }
+		}
+		name := prefix + string(fd.Name())
+		current := msg.Get(fd)
+		if fd.Kind() == protoreflect.MessageKind {
+			if selection.hasChosenWithin(category + "." + name) {
+				toggles = addBuffToggles(toggles, selection, category, name+".", current.Message(), nil, func(request *proto.RaidSimRequest) protoreflect.Message {
+					return get(request).Mutable(fd).Message()
+				})
+			}
+			continue
+		}
+		if len(selection.fields) == 0 {
+			if msg.Has(fd) {
+				off := fd.Default()
+				if fd.Kind() == protoreflect.EnumKind {
+					off = protoreflect.ValueOfEnum(fd.Enum().Values().Get(0).Number())
+				}
+				toggles = append(toggles, buffToggle{category, name, offBuffValueName(fd, off), set(off)})
+			}
+			continue
+		}
+		if !selection.isChosen(category + "." + name) {
+			continue
+		}
+
+		switch fd.Kind() {
+		case protoreflect.BoolKind:
+			toggles = append(toggles, buffToggle{category, name, fmt.Sprint(!current.Bool()), set(protoreflect.ValueOfBool(!current.Bool()))})
+		case protoreflect.EnumKind:
+			values := fd.Enum().Values()
+			for j := 0; j < values.Len(); j++ {
+				if ev := values.Get(j); ev.Number() != current.Enum() {
+					toggles = append(toggles, buffToggle{category, name, string(ev.Name()), set(protoreflect.ValueOfEnum(ev.Number()))})
+				}
+			}
+		case protoreflect.Int32Kind:
+			if current.Int() != 0 {
+				toggles = append(toggles, buffToggle{category, name, "0", set(protoreflect.ValueOfInt32(0))})
+			}
+		}
+	}
+	return toggles
+}
+
+// Names the zero value a field is turned off with, in the same format as the other toggles.
+func offBuffValueName(fd protoreflect.FieldDescriptor, off protoreflect.Value) string {
+	if fd.Kind() == protoreflect.EnumKind {
+		return string(fd.Enum().Values().ByNumber(off.Enum()).Name())
+	}
+	return fmt.Sprint(off.Interface())
+}
+
+func runBuffValues(request *proto.BuffValuesRequest, progress chan *proto.ProgressMetrics, signals simsignals.Signals) *proto.BuffValuesResult {
+	if request.RaidSimRequest == nil {
+		return &proto.BuffValuesResult{Error: &proto.ErrorOutcome{Message: "no raid sim request"}}
+	}
+	baseRequest := googleProto.Clone(request.RaidSimRequest).(*proto.RaidSimRequest)
+	playerIdx := int(request.PlayerIndex)
+	player := func(request *proto.RaidSimRequest) *proto.Player {
+		idx := playerIdx
+		for _, party := range request.GetRaid().GetParties() {
+			if idx < len(party.Players) {
+				return party.Players[idx]
+			}
+			idx -= len(party.Players)
+		}
+		return nil
+	}
+	if player(baseRequest) == nil {
+		return &proto.BuffValuesResult{Error: &proto.ErrorOutcome{Message: fmt.Sprintf("no player with index %d", request.PlayerIndex)}}
+	}
+
+	// Every sim uses the same random numbers, so each buff is compared with the same iterations.
+	if baseRequest.SimOptions == nil {
+		baseRequest.SimOptions = &proto.SimOptions{}
+	}
+	simOptions := baseRequest.SimOptions
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
+	}
+	simOptions.UseLabeledRands = true
+	simOptions.SaveAllValues = true
+	simOptions.Debug = false
+	simOptions.ReplaySeed = 0
+	simOptions.CombatLogIterations = 0
+	simOptions.MaxDpsStderr = 0
+	simOptions.MaxDpsRelativeStderr = 0
+
+	basePlayer := player(baseRequest)
+	if basePlayer.Consumes == nil {
+		basePlayer.Consumes = &proto.Consumes{}
+	}
+	if basePlayer.Buffs == nil {
+		basePlayer.Buffs = &proto.IndividualBuffs{}
+	}
+	if baseRequest.Raid.Buffs == nil {
+		baseRequest.Raid.Buffs = &proto.RaidBuffs{}
+	}
+
+	selection := &buffFieldSelection{fields: request.Fields, matched: make(map[string]bool)}
+	var toggles []buffToggle
+	toggles = addBuffToggles(toggles, selection, "Consumes", "", basePlayer.Consumes.ProtoReflect(), nil, func(request *proto.RaidSimRequest) protoreflect.Message {
+		return player(request).Consumes.ProtoReflect()
+	})
+	toggles = addBuffToggles(toggles, selection, "IndividualBuffs", "", basePlayer.Buffs.ProtoReflect(), worldBuffFields, func(request *proto.RaidSimRequest) protoreflect.Message {
+		return player(request).Buffs.ProtoReflect()
+	})
+	toggles = addBuffToggles(toggles, selection, "RaidBuffs", "", baseRequest.Raid.Buffs.ProtoReflect(), nil, func(request *proto.RaidSimRequest) protoreflect.Message {
+		return request.Raid.Buffs.ProtoReflect()
+	})
+	for _, field := range request.Fields {
+		if !selection.matched[field] {
+			return &proto.BuffValuesResult{Error: &proto.ErrorOutcome{Message: fmt.Sprintf("unknown buff field %q", field)}}
+		}
+	}
+
+	simRequests := []*proto.RaidSimRequest{baseRequest}
+	for _, toggle := range toggles {
+		simRequest := googleProto.Clone(baseRequest).(*proto.RaidSimRequest)
+		toggle.apply(simRequest)
+		simRequests = append(simRequests, simRequest)
+	}
+	simResults, simErr := runSimsSequentially(simRequests, progress, signals)
+	if simErr != nil {
+		return &proto.BuffValuesResult{Error: simErr}
+	}
+
+	metrics := make([]*proto.UnitMetrics, len(simResults))
+	for i, simResult := range simResults {
+		idx := playerIdx
+		for _, party := range simResult.RaidMetrics.Parties {
+			if idx < len(party.Players) {
+				metrics[i] = party.Players[idx]
+				break
+			}
+			idx -= len(party.Players)
+		}
+	}
+
+	result := &proto.BuffValuesResult{}
+	for i, toggle := range toggles {
+		result.Values = append(result.Values, &proto.BuffValue{
+			Category: toggle.category,
+			Field:    toggle.field,
+			Value:    toggle.value,
+			DpsDelta: newPairedDelta(metrics[0].Dps, metrics[i+1].Dps),
+			TpsDelta: newPairedDelta(metrics[0].Threat, metrics[i+1].Threat),
+		})
+	}
+	slices.SortStableFunc(result.Values, func(a, b *proto.BuffValue) int {
+		return cmp.Compare(b.DpsDelta.Mean, a.DpsDelta.Mean)
+	})
+
+	// Per-iteration values were only needed for the deltas.
+	if !request.RaidSimRequest.GetSimOptions().GetSaveAllValues() {
+		clearAllValues(metrics[0])
+	}
+	result.BaseDps = metrics[0].Dps
+	result.BaseTps = metrics[0].Threat
+	return result
+}
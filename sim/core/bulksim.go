@@ -131,6 +131,21 @@ func (b *bulkSimRunner) Run(signals simsignals.Signals, progress chan *proto.Pro
 	}
 	baseItems := player.Equipment.Items
 
+	// Combos are cloned from the base settings, so this gives all of them the same random numbers.
+	commonRandomNumbers := b.Request.BulkSettings.CommonRandomNumbers
+	saveAllValues := b.Request.BaseSettings.SimOptions.SaveAllValues
+	if commonRandomNumbers {
+		simOptions := b.Request.BaseSettings.SimOptions
+		if simOptions.RandomSeed == 0 {
+			simOptions.RandomSeed = time.Now().UnixNano()
+		}
+		simOptions.UseLabeledRands = true
+		simOptions.SaveAllValues = true
+		// Iterations are compared pairwise, so all combos need to do the same ones.
+		simOptions.MaxDpsStderr = 0
+		simOptions.MaxDpsRelativeStderr = 0
+	}
+
 	allCombos := generateAllEquipmentSubstitutions(signals, baseItems, b.Request.BulkSettings.Combinations, distinctItemSlotCombos)
 
 	var validCombos []singleBulkSim
@@ -222,6 +237,7 @@ func (b *bulkSimRunner) Run(signals simsignals.Signals, progress chan *proto.Pro
 	}
 
 	bum := baseResult.Result.GetRaidMetrics().GetParties()[0].GetPlayers()[0]
+	baseDps := bum.Dps
 	bum.Actions = nil
 	bum.Auras = nil
 	bum.Resources = nil
@@ -240,10 +256,22 @@ func (b *bulkSimRunner) Run(signals simsignals.Signals, progress chan *proto.Pro
 		um.Resources = nil
 		um.Pets = nil
 
-		result.Results = append(result.Results, &proto.BulkComboResult{
+		comboResult := &proto.BulkComboResult{
 			ItemsAdded:  r.ChangeLog.AddedItems,
 			UnitMetrics: um,
-		})
+		}
+		if commonRandomNumbers {
+			comboResult.DpsDelta = newPairedDelta(baseDps, um.Dps)
+		}
+		result.Results = append(result.Results, comboResult)
+	}
+
+	// Per-iteration values were only needed for the deltas.
+	if commonRandomNumbers && !saveAllValues {
+		clearAllValues(bum)
+		for _, r := range result.Results {
+			clearAllValues(r.UnitMetrics)
+		}
 	}
 
 	if progress != nil {
@@ -352,6 +380,14 @@ func (b *bulkSimRunner) getRankedResults(signals simsignals.Signals, validCombos
 	return rankedResults, baseResult, nil
 }
 
+func clearAllValues(um *proto.UnitMetrics) {
+	for _, dist := range []*proto.DistributionMetrics{um.Dps, um.Dpasp, um.Threat, um.Dtps, um.Tmi, um.Hps, um.Tto} {
+		if dist != nil {
+			dist.AllValues = nil
+		}
+	}
+}
+
 // itemSubstitutionSimResult stores the request and response of a simulation, along with the used
 // equipment susbstitution and a changelog of which items were added and removed from the base
 // equipment set.
@@ -116,6 +116,8 @@ func NewCharacter(party *Party, partyIndex int, player *proto.Player) Character
 			ChannelClipDelay:        max(0, time.Duration(player.ChannelClipDelayMs)*time.Millisecond),
 			DistanceFromTarget:      player.DistanceFromTarget,
 			StartDistanceFromTarget: player.DistanceFromTarget,
+			StartPosition:           Vector2FromProto(player.Position),
+			hasStartPosition:        player.Position != nil,
 		},
 
 		Name:  player.Name,
@@ -662,6 +664,9 @@ func (character *Character) GetMetricsProto() *proto.UnitMetrics {
 	metrics.Name = character.Name
 	metrics.UnitIndex = character.UnitIndex
 	metrics.Auras = character.auraTracker.GetMetricsProto()
+	if character.Rotation != nil {
+		metrics.AplItems = character.Rotation.getTraceMetrics()
+	}
 
 	metrics.Pets = make([]*proto.UnitMetrics, len(character.Pets))
 	for i, pet := range character.Pets {
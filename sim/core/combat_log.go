@@ -0,0 +1,110 @@
+package core
+
+import (
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+// CombatLog records typed combat log events, for tools that would otherwise have to parse the
+// free-text logs. It is only set on the Simulation while recording, so callers check for nil
+// the same way as for sim.Log.
+type CombatLog struct {
+	Events []*proto.CombatLogEvent
+
+	iteration int32
+	unitRefs  map[*Unit]*proto.UnitReference
+}
+
+func newCombatLog(env *Environment) *CombatLog {
+	cl := &CombatLog{
+		unitRefs: make(map[*Unit]*proto.UnitReference),
+	}
+
+	for _, party := range env.Raid.Parties {
+		for _, player := range party.Players {
+			character := player.GetCharacter()
+			playerRef := &proto.UnitReference{Type: proto.UnitReference_Player, Index: character.Index}
+			cl.unitRefs[&character.Unit] = playerRef
+			for i, pet := range character.PetAgents {
+				cl.unitRefs[&pet.GetCharacter().Unit] = &proto.UnitReference{Type: proto.UnitReference_Pet, Index: int32(i), Owner: playerRef}
+			}
+		}
+	}
+	for _, target := range env.Encounter.AllTargetUnits {
+		cl.unitRefs[target] = &proto.UnitReference{Type: proto.UnitReference_Target, Index: target.Index}
+	}
+
+	return cl
+}
+
+func (cl *CombatLog) unitReference(unit *Unit) *proto.UnitReference {
+	if unit == nil {
+		return nil
+	}
+	return cl.unitRefs[unit]
+}
+
+func (cl *CombatLog) add(sim *Simulation, source *Unit, target *Unit, actionID ActionID, event *proto.CombatLogEvent) {
+	event.Iteration = cl.iteration
+	event.Timestamp = sim.CurrentTime.Seconds()
+	event.Source = cl.unitReference(source)
+	event.Target = cl.unitReference(target)
+	event.ActionId = actionID.ToProto()
+	cl.Events = append(cl.Events, event)
+}
+
+func (cl *CombatLog) castStart(sim *Simulation, spell *Spell, target *Unit) {
+	cl.add(sim, spell.Unit, target, spell.ActionID, &proto.CombatLogEvent{
+		Event: &proto.CombatLogEvent_CastStart{CastStart: &proto.CombatLogCastStart{
+			CastTime: spell.CurCast.CastTime.Seconds(),
+		}},
+	})
+}
+
+func (cl *CombatLog) castComplete(sim *Simulation, spell *Spell, target *Unit, cost float64) {
+	cl.add(sim, spell.Unit, target, spell.ActionID, &proto.CombatLogEvent{
+		Event: &proto.CombatLogEvent_CastComplete{CastComplete: &proto.CombatLogCastComplete{
+			Cost: max(0, cost),
+		}},
+	})
+}
+
+func (cl *CombatLog) spellHit(sim *Simulation, spell *Spell, result *SpellResult, isPeriodic bool, isHealing bool) {
+	hit := &proto.CombatLogSpellHit{
+		Outcome:          result.Outcome.ToProto(),
+		ResistedQuarters: result.Outcome.resistedQuarters(),
+		Amount:           result.Damage,
+		Threat:           result.Threat,
+		SpellSchool:      int32(spell.SpellSchool),
+		Periodic:         isPeriodic,
+		Swing:            spell.ProcMask.Matches(ProcMaskWhiteHit),
+	}
+
+	event := &proto.CombatLogEvent{}
+	if isHealing {
+		event.Event = &proto.CombatLogEvent_Healing{Healing: hit}
+	} else {
+		event.Event = &proto.CombatLogEvent_Damage{Damage: hit}
+	}
+	cl.add(sim, spell.Unit, result.Target, spell.ActionID, event)
+}
+
+func (cl *CombatLog) aura(sim *Simulation, aura *Aura, eventType proto.CombatLogAura_Type) {
+	cl.add(sim, nil, aura.Unit, aura.ActionID, &proto.CombatLogEvent{
+		Event: &proto.CombatLogEvent_Aura{Aura: &proto.CombatLogAura{
+			Type:   eventType,
+			Label:  aura.Label,
+			Stacks: aura.stacks,
+		}},
+	})
+}
+
+func (cl *CombatLog) resource(sim *Simulation, unit *Unit, metrics *ResourceMetrics, amount float64, actualAmount float64, newValue float64) {
+	cl.add(sim, unit, nil, metrics.ActionID, &proto.CombatLogEvent{
+		Event: &proto.CombatLogEvent_Resource{Resource: &proto.CombatLogResource{
+			Type:         metrics.Type,
+			Amount:       amount,
+			ActualAmount: actualAmount,
+			NewValue:     newValue,
+		}},
+	})
+}
@@ -107,7 +107,7 @@ func addImbueStats(character *Character, imbue proto.WeaponImbue, isMh bool, sha
 			})
 		case proto.WeaponImbue_BlessedWizardOil:
 			character.Env.RegisterPostFinalizeEffect(func() {
-				for _, target := range character.Env.Encounter.TargetUnits {
+				for _, target := range character.Env.Encounter.AllTargetUnits {
 					if target.MobType != proto.MobType_MobTypeUndead {
 						continue
 					}
@@ -161,7 +161,7 @@ func addImbueStats(character *Character, imbue proto.WeaponImbue, isMh bool, sha
 			}
 		case proto.WeaponImbue_ConsecratedSharpeningStone:
 			character.Env.RegisterPostFinalizeEffect(func() {
-				for _, target := range character.Env.Encounter.TargetUnits {
+				for _, target := range character.Env.Encounter.AllTargetUnits {
 					if target.MobType != proto.MobType_MobTypeUndead {
 						continue
 					}
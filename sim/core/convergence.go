@@ -0,0 +1,91 @@
+package core
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+// Standard errors from fewer iterations are too noisy to stop on.
+const minConvergenceIterations = 100
+
+// z-score of a two-sided 95% confidence interval.
+const confidenceZ95 = 1.959964
+
+// Stops sims once the standard error of the raid's average DPS reaches the precision requested in
+// SimOptions. The splits of a concurrent sim share a tracker, so that they stop together based on
+// their combined iterations.
+type convergenceTracker struct {
+	maxStdErr    float64
+	maxRelStdErr float64
+
+	mut       sync.Mutex
+	partials  []aggregator // Raid DPS so far, per split.
+	converged atomic.Bool
+}
+
+// Returns nil if the options don't ask for early stopping.
+func newConvergenceTracker(options *proto.SimOptions, numSplits int) *convergenceTracker {
+	if options.MaxDpsStderr <= 0 && options.MaxDpsRelativeStderr <= 0 {
+		return nil
+	}
+	return &convergenceTracker{
+		maxStdErr:    options.MaxDpsStderr,
+		maxRelStdErr: options.MaxDpsRelativeStderr,
+		partials:     make([]aggregator, numSplits),
+	}
+}
+
+// Records the raid DPS of a split so far, and returns whether all splits should stop.
+func (ct *convergenceTracker) update(split int, partial aggregator) bool {
+	if ct.converged.Load() {
+		return true
+	}
+
+	ct.mut.Lock()
+	defer ct.mut.Unlock()
+	ct.partials[split] = partial
+
+	total := &aggregator{}
+	for i := range ct.partials {
+		total = total.merge(&ct.partials[i])
+	}
+	if total.n < minConvergenceIterations {
+		return false
+	}
+
+	mean, stdev := total.meanAndStdDev()
+	stdErr := stdev / math.Sqrt(float64(total.n))
+	if (ct.maxStdErr > 0 && stdErr <= ct.maxStdErr) || (ct.maxRelStdErr > 0 && stdErr <= ct.maxRelStdErr*math.Abs(mean)) {
+		ct.converged.Store(true)
+	}
+	return ct.converged.Load()
+}
+
+func (ct *convergenceTracker) isConverged() bool {
+	return ct != nil && ct.converged.Load()
+}
+
+// Scales the precision targets of a request run as one of numSplits separate sims, so that each
+// split converges about when their combined result would.
+func scaleConvergenceForSplit(options *proto.SimOptions, numSplits int32) {
+	scale := math.Sqrt(float64(numSplits))
+	options.MaxDpsStderr *= scale
+	options.MaxDpsRelativeStderr *= scale
+}
+
+func newConfidenceInterval(dist *proto.DistributionMetrics) *proto.ConfidenceInterval {
+	n := dist.GetAggregatorData().GetN()
+	if n == 0 {
+		return nil
+	}
+	stdErr := dist.Stdev / math.Sqrt(float64(n))
+	return &proto.ConfidenceInterval{
+		Confidence: 0.95,
+		Lower:      dist.Avg - confidenceZ95*stdErr,
+		Upper:      dist.Avg + confidenceZ95*stdErr,
+		Stderr:     stdErr,
+	}
+}
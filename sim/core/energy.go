@@ -163,6 +163,9 @@ func (eb *energyBar) addEnergyInternal(sim *Simulation, amount float64, metrics
 	if sim.Log != nil {
 		eb.unit.Log(sim, "Gained %0.3f energy from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, eb.currentEnergy, newEnergy)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, eb.unit, metrics, amount, newEnergy-eb.currentEnergy, newEnergy)
+	}
 
 	crossedThreshold := eb.cumulativeEnergyDecisionThresholds == nil || eb.cumulativeEnergyDecisionThresholds[int(eb.currentEnergy)] != eb.cumulativeEnergyDecisionThresholds[int(newEnergy)]
 	eb.currentEnergy = newEnergy
@@ -185,6 +188,9 @@ func (eb *energyBar) SpendEnergy(sim *Simulation, amount float64, metrics *Resou
 	if sim.Log != nil {
 		eb.unit.Log(sim, "Spent %0.3f energy from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, eb.currentEnergy, newEnergy)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, eb.unit, metrics, -amount, -amount, newEnergy)
+	}
 
 	eb.currentEnergy = newEnergy
 }
@@ -212,6 +218,9 @@ func (eb *energyBar) AddComboPointsIgnoreTarget(sim *Simulation, pointsToAdd int
 	if sim.Log != nil {
 		eb.unit.Log(sim, "Gained %d combo points on %s from %s (%d --> %d)", pointsToAdd, eb.comboPointTarget.LogLabel(), metrics.ActionID, eb.comboPoints, newComboPoints)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, eb.unit, metrics, float64(pointsToAdd), float64(newComboPoints-eb.comboPoints), float64(newComboPoints))
+	}
 
 	eb.comboPoints = newComboPoints
 
@@ -241,6 +250,10 @@ func (eb *energyBar) AddComboPoints(sim *Simulation, pointsToAdd int32, target *
 			eb.unit.Log(sim, "Spent %d combo points on %s from %s (%d --> %d) (target swap)", pointsToAdd, eb.comboPointTarget.LogLabel(), metrics.ActionID, eb.comboPoints, 0)
 			eb.unit.Log(sim, "Gained %d combo points on %s from %s (%d --> %d)", pointsToAdd, target.LogLabel(), metrics.ActionID, 0, newComboPoints)
 		}
+		if sim.CombatLog != nil {
+			sim.CombatLog.resource(sim, eb.unit, metrics, -float64(eb.comboPoints), -float64(eb.comboPoints), 0)
+			sim.CombatLog.resource(sim, eb.unit, metrics, float64(pointsToAdd), float64(newComboPoints), float64(newComboPoints))
+		}
 	} else {
 		newComboPoints = min(eb.comboPoints+pointsToAdd, 5)
 		metrics.AddEvent(float64(pointsToAdd), float64(newComboPoints-eb.comboPoints))
@@ -248,6 +261,9 @@ func (eb *energyBar) AddComboPoints(sim *Simulation, pointsToAdd int32, target *
 		if sim.Log != nil {
 			eb.unit.Log(sim, "Gained %d combo points on %s from %s (%d --> %d)", pointsToAdd, target.LogLabel(), metrics.ActionID, eb.comboPoints, newComboPoints)
 		}
+		if sim.CombatLog != nil {
+			sim.CombatLog.resource(sim, eb.unit, metrics, float64(pointsToAdd), float64(newComboPoints-eb.comboPoints), float64(newComboPoints))
+		}
 	}
 
 	eb.comboPoints = newComboPoints
@@ -274,6 +290,9 @@ func (eb *energyBar) SpendComboPoints(sim *Simulation, spell *Spell) {
 	if sim.Log != nil {
 		eb.unit.Log(sim, "Spent %d combo points from %s (%d --> %d).", comboPoints, spell.ActionID, comboPoints, 0)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, eb.unit, spell.ComboPointMetrics(), float64(-comboPoints), float64(-comboPoints), 0)
+	}
 	spell.ComboPointMetrics().AddEvent(float64(-comboPoints), float64(-comboPoints))
 	eb.comboPoints = 0
 
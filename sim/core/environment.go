@@ -57,7 +57,7 @@ func NewEnvironment(raidProto *proto.Raid, encounterProto *proto.Encounter, runF
 	env.finalize(raidProto, encounterProto, raidStats, runFakePrepull)
 
 	encounterStats := &proto.EncounterStats{}
-	for _, target := range env.Encounter.Targets {
+	for _, target := range env.Encounter.AllTargets {
 		encounterStats.Targets = append(encounterStats.Targets, &proto.TargetStats{
 			Metadata: target.GetMetadata(),
 		})
@@ -75,7 +75,7 @@ func (env *Environment) construct(raidProto *proto.Raid, encounterProto *proto.E
 
 	env.Raid.updatePlayersAndPets()
 
-	env.AllUnits = append(env.Encounter.TargetUnits, env.Raid.AllUnits...)
+	env.AllUnits = append(env.Encounter.AllTargetUnits, env.Raid.AllUnits...)
 
 	for unitIndex, unit := range env.AllUnits {
 		unit.Env = env
@@ -83,18 +83,19 @@ func (env *Environment) construct(raidProto *proto.Raid, encounterProto *proto.E
 	}
 
 	for _, unit := range env.Raid.AllUnits {
-		unit.CurrentTarget = env.Encounter.TargetUnits[0]
+		unit.CurrentTarget = env.Encounter.AllTargetUnits[0]
+		unit.initPosition()
 	}
 
 	// Apply extra debuffs from raid.
-	if raidProto.Debuffs != nil && len(env.Encounter.TargetUnits) > 0 {
-		for targetIdx, targetUnit := range env.Encounter.TargetUnits {
+	if raidProto.Debuffs != nil && len(env.Encounter.AllTargetUnits) > 0 {
+		for targetIdx, targetUnit := range env.Encounter.AllTargetUnits {
 			applyDebuffEffects(targetUnit, targetIdx, raidProto.Debuffs, raidProto)
 		}
 	}
 
 	// Assign target or target using Tanks field.
-	for _, target := range env.Encounter.Targets {
+	for _, target := range env.Encounter.AllTargets {
 		if target.Index < int32(len(encounterProto.Targets)) {
 			targetProto := encounterProto.Targets[target.Index]
 			if targetProto.TankIndex >= 0 && targetProto.TankIndex < int32(len(raidProto.Tanks)) {
@@ -114,7 +115,7 @@ func (env *Environment) construct(raidProto *proto.Raid, encounterProto *proto.E
 
 // The initialization phase.
 func (env *Environment) initialize(raidProto *proto.Raid, encounterProto *proto.Encounter) *proto.RaidStats {
-	for _, target := range env.Encounter.Targets {
+	for _, target := range env.Encounter.AllTargets {
 		if target.Index < int32(len(encounterProto.Targets)) {
 			target.initialize(encounterProto.Targets[target.Index])
 		} else {
@@ -122,6 +123,8 @@ func (env *Environment) initialize(raidProto *proto.Raid, encounterProto *proto.
 		}
 	}
 
+	env.Encounter.initializeDowntimes()
+
 	for _, party := range env.Raid.Parties {
 		for _, playerOrPet := range party.PlayersAndPets {
 			playerOrPet.GetCharacter().initialize(playerOrPet)
@@ -147,7 +150,7 @@ func (env *Environment) finalize(raidProto *proto.Raid, _ *proto.Encounter, raid
 	}
 	env.preFinalizeEffects = nil
 
-	for _, target := range env.Encounter.Targets {
+	for _, target := range env.Encounter.AllTargets {
 		target.finalize()
 		if target.AI != nil {
 			target.Rotation = target.newCustomRotation()
@@ -258,10 +261,13 @@ func (env *Environment) IsFinalized() bool {
 func (env *Environment) reset(sim *Simulation) {
 	// Reset primary targets damage taken for tracking health fights.
 	env.Encounter.DamageTaken = 0
+	env.Encounter.resetHealthTriggers(sim)
+	env.Encounter.resetPresentTargets()
+	env.Encounter.resetDowntimes(sim)
 
 	// Targets need to be reset before the raid, so that players can check for
 	// the presence of permanent target auras in their Reset handlers.
-	for _, target := range env.Encounter.Targets {
+	for _, target := range env.Encounter.AllTargets {
 		target.Reset(sim)
 	}
 
@@ -273,18 +279,24 @@ func (env *Environment) GetMaxDuration() time.Duration {
 	return env.BaseDuration + env.DurationVariation
 }
 
+// The number of targets currently present in the fight.
 func (env *Environment) GetNumTargets() int32 {
 	return int32(len(env.Encounter.Targets))
 }
 
+// The number of targets in the fight, including adds that aren't present.
+func (env *Environment) GetMaxNumTargets() int32 {
+	return int32(len(env.Encounter.AllTargets))
+}
+
 func (env *Environment) GetTarget(index int32) *Target {
-	return env.Encounter.Targets[index]
+	return env.Encounter.AllTargets[index]
 }
 func (env *Environment) GetTargetUnit(index int32) *Unit {
-	return &env.Encounter.Targets[index].Unit
+	return &env.Encounter.AllTargets[index].Unit
 }
 func (env *Environment) NextTarget(target *Unit) *Target {
-	return env.Encounter.Targets[target.Index].NextTarget()
+	return env.Encounter.AllTargets[target.Index].NextTarget()
 }
 func (env *Environment) NextTargetUnit(target *Unit) *Unit {
 	return &env.NextTarget(target).Unit
@@ -295,7 +307,7 @@ func (env *Environment) GetAgentFromUnit(unit *Unit) Agent {
 		return raidAgent
 	}
 
-	for _, target := range env.Encounter.Targets {
+	for _, target := range env.Encounter.AllTargets {
 		if unit == &target.Unit {
 			return target
 		}
@@ -335,8 +347,8 @@ func (env *Environment) GetUnit(ref *proto.UnitReference, contextUnit *Unit) *Un
 			return nil
 		}
 	case proto.UnitReference_Target:
-		if int(ref.Index) < len(env.Encounter.TargetUnits) {
-			return env.Encounter.TargetUnits[ref.Index]
+		if int(ref.Index) < len(env.Encounter.AllTargetUnits) {
+			return env.Encounter.AllTargetUnits[ref.Index]
 		} else {
 			return nil
 		}
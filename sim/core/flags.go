@@ -1,5 +1,7 @@
 package core
 
+import "github.com/wowsims/classic/sim/core/proto"
+
 type ProcMask uint32
 
 // Returns whether there is any overlap between the given masks.
@@ -111,6 +113,8 @@ const (
 	OutcomePartial1_4 // 1/4 of the spell was resisted.
 	OutcomePartial2_4 // 2/4 of the spell was resisted.
 	OutcomePartial3_4 // 3/4 of the spell was resisted.
+
+	OutcomeUnavailable // The target couldn't be hit, see outcomeTargetUnavailable.
 )
 
 const (
@@ -137,11 +141,49 @@ func (ho HitOutcome) String() string {
 		return "Hit" + ho.PartialResistString()
 	} else if ho.Matches(OutcomeCrush) {
 		return "Crush"
+	} else if ho.Matches(OutcomeUnavailable) {
+		return "Unavailable"
 	} else {
 		return "Empty"
 	}
 }
 
+func (ho HitOutcome) ToProto() proto.HitOutcome {
+	if ho.Matches(OutcomeMiss) {
+		return proto.HitOutcome_HitOutcomeMiss
+	} else if ho.Matches(OutcomeDodge) {
+		return proto.HitOutcome_HitOutcomeDodge
+	} else if ho.Matches(OutcomeParry) {
+		return proto.HitOutcome_HitOutcomeParry
+	} else if ho.Matches(OutcomeGlance) {
+		return proto.HitOutcome_HitOutcomeGlance
+	} else if ho.Matches(OutcomeBlock) && ho.Matches(OutcomeCrit) {
+		return proto.HitOutcome_HitOutcomeBlockedCrit
+	} else if ho.Matches(OutcomeBlock) {
+		return proto.HitOutcome_HitOutcomeBlock
+	} else if ho.Matches(OutcomeCrit) {
+		return proto.HitOutcome_HitOutcomeCrit
+	} else if ho.Matches(OutcomeHit) {
+		return proto.HitOutcome_HitOutcomeHit
+	} else if ho.Matches(OutcomeCrush) {
+		return proto.HitOutcome_HitOutcomeCrush
+	} else {
+		return proto.HitOutcome_HitOutcomeEmpty
+	}
+}
+
+func (ho HitOutcome) resistedQuarters() int32 {
+	if ho.Matches(OutcomePartial1_4) {
+		return 1
+	} else if ho.Matches(OutcomePartial2_4) {
+		return 2
+	} else if ho.Matches(OutcomePartial3_4) {
+		return 3
+	} else {
+		return 0
+	}
+}
+
 func (ho HitOutcome) PartialResistString() string {
 	if ho.Matches(OutcomePartial1_4) {
 		return " (25% Resist)"
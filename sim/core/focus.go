@@ -73,6 +73,9 @@ func (fb *focusBar) AddFocus(sim *Simulation, amount float64, metrics *ResourceM
 	if sim.Log != nil {
 		fb.unit.Log(sim, "Gained %0.3f focus from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, fb.currentFocus, newFocus)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, fb.unit, metrics, amount, newFocus-fb.currentFocus, newFocus)
+	}
 
 	fb.currentFocus = newFocus
 
@@ -92,6 +95,9 @@ func (fb *focusBar) SpendFocus(sim *Simulation, amount float64, metrics *Resourc
 	if sim.Log != nil {
 		fb.unit.Log(sim, "Spent %0.3f focus from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, fb.currentFocus, newFocus)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, fb.unit, metrics, -amount, -amount, newFocus)
+	}
 
 	fb.currentFocus = newFocus
 }
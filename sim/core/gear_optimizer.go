@@ -0,0 +1,359 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/simsignals"
+	"github.com/wowsims/classic/sim/core/stats"
+)
+
+// Bulk sims enumerate every combination of the given items, which only works for a handful of them.
+// The gear optimizer instead prunes the candidates of each slot by stat weights, then does a beam
+// search over the sets that change a single item or enchant, see beamSearch.
+
+const numGearSlots = int(proto.ItemSlot_ItemSlotRanged) + 1
+
+// A complete equipment set.
+type gearSet struct {
+	items []*proto.ItemSpec // Indexed by slot.
+	key   string
+}
+
+func newGearSet(items []*proto.ItemSpec) *gearSet {
+	parts := make([]string, len(items))
+	for i, item := range items {
+		parts[i] = fmt.Sprintf("%d/%d/%d", item.Id, item.Enchant, item.RandomSuffix)
+	}
+	// Swapping rings or trinkets gives the same set.
+	for _, slot := range []proto.ItemSlot{proto.ItemSlot_ItemSlotFinger1, proto.ItemSlot_ItemSlotTrinket1} {
+		if parts[slot] > parts[slot+1] {
+			parts[slot], parts[slot+1] = parts[slot+1], parts[slot]
+		}
+	}
+	return &gearSet{items: items, key: strings.Join(parts, ":")}
+}
+
+// Returns a copy of the set with the given items replaced.
+func (set *gearSet) with(changes ...*proto.ItemSpecWithSlot) *gearSet {
+	items := slices.Clone(set.items)
+	for _, change := range changes {
+		items[change.Slot] = change.Item
+	}
+	return newGearSet(items)
+}
+
+func (set *gearSet) isValid() bool {
+	return isValidEquipment(&proto.EquipmentSpec{Items: set.items})
+}
+
+func (set *gearSet) searchKey() string {
+	return set.key
+}
+func (set *gearSet) applyTo(player *proto.Player) {
+	player.Equipment = &proto.EquipmentSpec{Items: set.items}
+}
+
+type gearOptimizer struct {
+	request     *proto.GearOptimizerRequest
+	baseRequest *proto.RaidSimRequest
+	signals     simsignals.Signals
+	progress    chan *proto.ProgressMetrics
+	search      *beamSearch[*gearSet]
+
+	candidates [][]*proto.ItemSpec // Indexed by slot.
+	enchants   [][]int32           // Indexed by slot.
+	// Candidates that are pieces of item sets with bonuses, by set name.
+	setPieces map[string][]*proto.ItemSpecWithSlot
+}
+
+func runGearOptimizer(request *proto.GearOptimizerRequest, progress chan *proto.ProgressMetrics, signals simsignals.Signals) *proto.GearOptimizerResult {
+	opt := &gearOptimizer{
+		request:  request,
+		signals:  signals,
+		progress: progress,
+	}
+	return opt.run()
+}
+
+func (opt *gearOptimizer) run() (result *proto.GearOptimizerResult) {
+	defer func() {
+		if err := recover(); err != nil {
+			result = &proto.GearOptimizerResult{
+				Error: &proto.ErrorOutcome{Message: fmt.Sprintf("%v\nStack Trace:\n%s", err, string(debug.Stack()))},
+			}
+		}
+	}()
+
+	equipped, errorOutcome := opt.setup()
+	if errorOutcome != nil {
+		return &proto.GearOptimizerResult{Error: errorOutcome}
+	}
+
+	fullIterations := cmp.Or(opt.request.Iterations, defaultIterationsPerCombo)
+	opt.search = newBeamSearch[*gearSet](opt.baseRequest, opt.progress, opt.signals)
+	pool, errorOutcome := opt.search.run(equipped, opt.neighbors, opt.request.BeamWidth, fullIterations)
+	if errorOutcome != nil {
+		return &proto.GearOptimizerResult{Error: errorOutcome}
+	}
+
+	return opt.finalResult(equipped, pool)
+}
+
+// Validates the request, and sets up the base request and candidates. Returns the equipped set.
+func (opt *gearOptimizer) setup() (*gearSet, *proto.ErrorOutcome) {
+	var errorOutcome *proto.ErrorOutcome
+	if opt.baseRequest, errorOutcome = newOptimizerBaseRequest("gear optimizer", opt.request.BaseSettings); errorOutcome != nil {
+		return nil, errorOutcome
+	}
+	player := opt.baseRequest.Raid.Parties[0].Players[0]
+
+	if player.Equipment == nil {
+		player.Equipment = &proto.EquipmentSpec{}
+	}
+	equippedItems := make([]*proto.ItemSpec, numGearSlots)
+	for i := range equippedItems {
+		if i < len(player.Equipment.Items) && player.Equipment.Items[i] != nil {
+			equippedItems[i] = player.Equipment.Items[i]
+		} else {
+			equippedItems[i] = &proto.ItemSpec{}
+		}
+	}
+
+	opt.enchants = make([][]int32, numGearSlots)
+	for _, slotEnchants := range opt.request.Enchants {
+		opt.enchants[slotEnchants.Slot] = append(opt.enchants[slotEnchants.Slot], slotEnchants.EnchantIds...)
+	}
+
+	for _, is := range opt.request.Items {
+		if _, ok := ItemsByID[is.Id]; !ok {
+			return nil, &proto.ErrorOutcome{Message: fmt.Sprintf("unknown item with id %d in gear optimizer settings", is.Id)}
+		}
+	}
+	weights := opt.request.StatWeights
+	if weights == nil {
+		if weights, errorOutcome = opt.simStatWeights(player); errorOutcome != nil {
+			return nil, errorOutcome
+		}
+	}
+	opt.pruneCandidates(weights, equippedItems)
+
+	return newGearSet(equippedItems), nil
+}
+
+// Returns a copy of the request with only its single named player, set up so that all variations
+// of the player use the same random numbers and can be compared with fewer iterations.
+func newOptimizerBaseRequest(optimizer string, baseSettings *proto.RaidSimRequest) (*proto.RaidSimRequest, *proto.ErrorOutcome) {
+	baseRequest := goproto.Clone(baseSettings).(*proto.RaidSimRequest)
+	var playerCount int
+	var player *proto.Player
+	var party *proto.Party
+	for _, p := range baseRequest.GetRaid().GetParties() {
+		for _, pl := range p.GetPlayers() {
+			if pl.Name != "" {
+				player = pl
+				party = p
+				playerCount++
+			}
+		}
+	}
+	if playerCount != 1 || player == nil {
+		return nil, &proto.ErrorOutcome{Message: fmt.Sprintf("%s: expected exactly 1 player, found %d", optimizer, playerCount)}
+	}
+	if player.GetDatabase() != nil {
+		addToDatabase(player.GetDatabase())
+		player.Database = nil
+	}
+	party.Players = []*proto.Player{player}
+	baseRequest.Raid.Parties = []*proto.Party{party}
+
+	if baseRequest.SimOptions == nil {
+		baseRequest.SimOptions = &proto.SimOptions{}
+	}
+	simOptions := baseRequest.SimOptions
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
+	}
+	simOptions.UseLabeledRands = true
+	simOptions.SaveAllValues = true
+	simOptions.MaxDpsStderr = 0
+	simOptions.MaxDpsRelativeStderr = 0
+	return baseRequest, nil
+}
+
+// Sims stat weights for the stats of the candidate items.
+func (opt *gearOptimizer) simStatWeights(player *proto.Player) (*proto.UnitStats, *proto.ErrorOutcome) {
+	var statsToWeigh []proto.Stat
+	for stat := stats.Stat(0); stat < stats.Len; stat++ {
+		for _, is := range opt.request.Items {
+			if ItemsByID[is.Id].Stats[stat] != 0 {
+				statsToWeigh = append(statsToWeigh, proto.Stat(stat))
+				break
+			}
+		}
+	}
+	if len(statsToWeigh) == 0 {
+		return &proto.UnitStats{}, nil
+	}
+
+	simOptions := goproto.Clone(opt.baseRequest.SimOptions).(*proto.SimOptions)
+	simOptions.Iterations = cmp.Or(opt.request.Iterations, defaultIterationsPerCombo)
+	result := runStatWeights(&proto.StatWeightsRequest{
+		Player:          goproto.Clone(player).(*proto.Player),
+		RaidBuffs:       opt.baseRequest.Raid.Buffs,
+		PartyBuffs:      opt.baseRequest.Raid.Parties[0].Buffs,
+		Debuffs:         opt.baseRequest.Raid.Debuffs,
+		Tanks:           opt.baseRequest.Raid.Tanks,
+		Encounter:       opt.baseRequest.Encounter,
+		SimOptions:      simOptions,
+		StatsToWeigh:    statsToWeigh,
+		EpReferenceStat: statsToWeigh[0],
+	}, nil, opt.signals)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return result.Dps.Weights, nil
+}
+
+// Keeps the candidates with the highest value by stat weights in each slot, along with set pieces
+// and the equipped items, so that the search can go back on a change.
+func (opt *gearOptimizer) pruneCandidates(weights *proto.UnitStats, equippedItems []*proto.ItemSpec) {
+	setNames := make(map[string]bool)
+	for _, set := range sets {
+		if len(set.Bonuses) > 0 {
+			setNames[set.Name] = true
+			setNames[set.AlternativeName] = set.AlternativeName != ""
+		}
+	}
+
+	maxCandidates := int(opt.request.CandidatesPerSlot)
+	if maxCandidates <= 0 {
+		maxCandidates = 5
+	}
+
+	type scoredItem struct {
+		spec  *proto.ItemSpec
+		score float64
+	}
+	bySlot := make([][]scoredItem, numGearSlots)
+	opt.candidates = make([][]*proto.ItemSpec, numGearSlots)
+	opt.setPieces = make(map[string][]*proto.ItemSpecWithSlot)
+	for _, is := range opt.request.Items {
+		item := ItemsByID[is.Id]
+		score := 0.0
+		for i, weight := range weights.GetStats() {
+			if i < int(stats.Len) {
+				score += weight * item.Stats[i]
+			}
+		}
+		slots := eligibleSlotsForItem(item)
+		for _, slot := range slots {
+			if setNames[item.SetName] {
+				opt.candidates[slot] = append(opt.candidates[slot], is)
+			} else {
+				bySlot[slot] = append(bySlot[slot], scoredItem{spec: is, score: score})
+			}
+		}
+		// Set pieces are also tried together, as they might only be worth it with the bonus.
+		if setNames[item.SetName] && len(slots) > 0 {
+			opt.setPieces[item.SetName] = append(opt.setPieces[item.SetName], &proto.ItemSpecWithSlot{Item: is, Slot: slots[0]})
+		}
+	}
+
+	for slot, items := range bySlot {
+		if equippedItems[slot].Id != 0 {
+			opt.candidates[slot] = append(opt.candidates[slot], equippedItems[slot])
+		}
+		slices.SortStableFunc(items, func(a, b scoredItem) int {
+			return cmp.Compare(b.score, a.score)
+		})
+		for _, item := range items[:min(maxCandidates, len(items))] {
+			opt.candidates[slot] = append(opt.candidates[slot], item.spec)
+		}
+	}
+}
+
+// Returns the valid sets that differ from set by a single item or enchant, or by equipping all
+// candidate pieces of an item set.
+func (opt *gearOptimizer) neighbors(set *gearSet) []*gearSet {
+	var neighbors []*gearSet
+	addNeighbor := func(changes ...*proto.ItemSpecWithSlot) {
+		if neighbor := set.with(changes...); neighbor.key != set.key && neighbor.isValid() {
+			neighbors = append(neighbors, neighbor)
+		}
+	}
+
+	for slot := range set.items {
+		current := set.items[slot]
+		for _, candidate := range opt.candidates[slot] {
+			change := opt.replacement(current, candidate, proto.ItemSlot(slot))
+			if ItemsByID[candidate.Id].HandType == proto.HandType_HandTypeTwoHand {
+				// Otherwise two-handers could never replace a main hand and off hand.
+				addNeighbor(change, &proto.ItemSpecWithSlot{Item: &proto.ItemSpec{}, Slot: proto.ItemSlot_ItemSlotOffHand})
+			} else {
+				addNeighbor(change)
+			}
+		}
+		if current.Id == 0 {
+			continue
+		}
+		for _, enchant := range opt.enchants[slot] {
+			enchanted := goproto.Clone(current).(*proto.ItemSpec)
+			enchanted.Enchant = enchant
+			addNeighbor(&proto.ItemSpecWithSlot{Item: enchanted, Slot: proto.ItemSlot(slot)})
+		}
+	}
+
+	for _, pieces := range opt.setPieces {
+		if len(pieces) < 2 {
+			continue
+		}
+		changes := make([]*proto.ItemSpecWithSlot, len(pieces))
+		for i, piece := range pieces {
+			changes[i] = opt.replacement(set.items[piece.Slot], piece.Item, piece.Slot)
+		}
+		addNeighbor(changes...)
+	}
+	return neighbors
+}
+
+// Candidates without an enchant keep the enchant of the item they replace, like bulk sim's
+// auto enchant.
+func (opt *gearOptimizer) replacement(current *proto.ItemSpec, candidate *proto.ItemSpec, slot proto.ItemSlot) *proto.ItemSpecWithSlot {
+	if candidate.Enchant == 0 && current.Enchant != 0 {
+		candidate = goproto.Clone(candidate).(*proto.ItemSpec)
+		candidate.Enchant = current.Enchant
+	}
+	return &proto.ItemSpecWithSlot{Item: candidate, Slot: slot}
+}
+
+func (opt *gearOptimizer) finalResult(equipped *gearSet, pool []*beamResult[*gearSet]) *proto.GearOptimizerResult {
+	toProto := func(setResult *beamResult[*gearSet], dpsDelta *proto.PairedDelta) *proto.GearOptimizerSet {
+		var changed []*proto.ItemSpecWithSlot
+		for slot, item := range setResult.candidate.items {
+			if !goproto.Equal(item, equipped.items[slot]) {
+				changed = append(changed, &proto.ItemSpecWithSlot{Item: item, Slot: proto.ItemSlot(slot)})
+			}
+		}
+		return &proto.GearOptimizerSet{
+			Equipment:    &proto.EquipmentSpec{Items: setResult.candidate.items},
+			ItemsChanged: changed,
+			UnitMetrics:  setResult.unitMetrics,
+			DpsDelta:     dpsDelta,
+		}
+	}
+
+	equippedResult, results := beamFinalResults(equipped, pool, opt.request.TopK, opt.request.BaseSettings.GetSimOptions().GetSaveAllValues(), toProto)
+	return &proto.GearOptimizerResult{
+		Results:            results,
+		EquippedGearResult: equippedResult,
+		SimsRun:            opt.search.simsRun,
+	}
+}
@@ -159,7 +159,7 @@ func NewMobTypeAttackPowerEffect(itemID int32, mobTypes []proto.MobType, bonus f
 		character := agent.GetCharacter()
 
 		matchingTargets := FilterSlice(
-			character.Env.Encounter.TargetUnits,
+			character.Env.Encounter.AllTargetUnits,
 			func(unit *Unit) bool { return slices.Contains(mobTypes, unit.MobType) },
 		)
 
@@ -189,7 +189,7 @@ func NewMobTypeSpellPowerEffect(itemID int32, mobTypes []proto.MobType, bonus fl
 		character := agent.GetCharacter()
 
 		matchingTargets := FilterSlice(
-			character.Env.Encounter.TargetUnits,
+			character.Env.Encounter.AllTargetUnits,
 			func(unit *Unit) bool { return slices.Contains(mobTypes, unit.MobType) },
 		)
 
@@ -87,6 +87,9 @@ func (unit *Unit) AddMana(sim *Simulation, amount float64, metrics *ResourceMetr
 	if sim.Log != nil {
 		unit.Log(sim, "Gained %0.3f mana from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, oldMana, newMana)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, unit, metrics, amount, newMana-oldMana, newMana)
+	}
 
 	unit.currentMana = newMana
 	unit.Metrics.ManaGained += newMana - oldMana
@@ -103,6 +106,9 @@ func (unit *Unit) SpendMana(sim *Simulation, amount float64, metrics *ResourceMe
 	if sim.Log != nil {
 		unit.Log(sim, "Spent %0.3f mana from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, unit.CurrentMana(), newMana)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, unit, metrics, -amount, -amount, newMana)
+	}
 
 	unit.currentMana = newMana
 	unit.Metrics.ManaSpent += amount
@@ -75,6 +75,27 @@ func (distMetrics *DistributionMetrics) ToProto() *proto.DistributionMetrics {
 	}
 }
 
+// Pairs up the per-iteration values of two runs with common random numbers, see
+// SimOptions.SaveAllValues. Runs that stopped at different iterations are paired up to the shorter one.
+func newPairedDelta(base *proto.DistributionMetrics, other *proto.DistributionMetrics) *proto.PairedDelta {
+	n := min(len(base.GetAllValues()), len(other.GetAllValues()))
+	if n == 0 {
+		return nil
+	}
+
+	var deltas aggregator
+	for i := 0; i < n; i++ {
+		deltas.add(other.AllValues[i] - base.AllValues[i])
+	}
+	mean, stdev := deltas.meanAndStdDev()
+	return &proto.PairedDelta{
+		Mean:       mean,
+		Stdev:      stdev,
+		Stderr:     stdev / math.Sqrt(float64(n)),
+		Iterations: int32(n),
+	}
+}
+
 func NewDistributionMetrics() DistributionMetrics {
 	return DistributionMetrics{
 		hist: make(map[int32]int32),
@@ -78,6 +78,7 @@ type MovementHandler struct {
 	baseSpeed          float64
 	moveAura           *Aura
 	moveSpell          *Spell
+	moveAction         *PendingAction
 	moveSpeedBonuses   *MoveHeap
 	moveSpeedPenalties *MoveHeap
 }
@@ -144,22 +145,95 @@ func (unit *Unit) MoveTo(moveRange float64, sim *Simulation) {
 	moveTicks := math.Abs(moveDistance)
 	moveInterval := moveDistance / float64(moveTicks)
 
+	// Moves straight towards or away from the target.
+	targetPosition := unit.CurrentTarget.Position
+	direction := Vector2{X: 1}
+	if offset := unit.Position.Sub(targetPosition); offset.Length() > 0 {
+		direction = offset.Scale(1 / offset.Length())
+	}
+
 	unit.MovementHandler.moveSpell.Cast(sim, unit.CurrentTarget)
 
-	sim.AddPendingAction(NewPeriodicAction(sim, PeriodicActionOptions{
+	unit.MovementHandler.moveAction = NewPeriodicAction(sim, PeriodicActionOptions{
 		Period:          time.Millisecond * time.Duration(1000/(unit.MovementHandler.MoveSpeed)),
 		NumTicks:        int(moveTicks),
 		TickImmediately: false,
 
 		OnAction: func(sim *Simulation) {
 			unit.DistanceFromTarget += moveInterval
+			unit.Position = targetPosition.Add(direction.Scale(unit.DistanceFromTarget))
 			unit.MovementHandler.moveAura.SetStacks(sim, int32(unit.DistanceFromTarget))
 
 			if unit.DistanceFromTarget == moveRange {
+				unit.MovementHandler.moveAction = nil
 				unit.MovementHandler.moveAura.Deactivate(sim)
 			}
 		},
-	}))
+	})
+	sim.AddPendingAction(unit.MovementHandler.moveAction)
+}
+
+// Moves the unit in a straight line to the given point, at its move speed.
+// Replaces any movement in progress, and waits for the current cast to finish.
+func (unit *Unit) MoveToPoint(point Vector2, sim *Simulation) {
+	move := unit.MovementHandler
+	if move.moveAction != nil {
+		move.moveAction.Cancel(sim)
+		move.moveAction = nil
+	}
+
+	if unit.IsCasting(sim) {
+		// Stops the unit from starting another cast in the meantime.
+		move.Moving = true
+		move.moveAction = StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: unit.Hardcast.Expires,
+			OnAction: func(sim *Simulation) {
+				move.moveAction = nil
+				unit.MoveToPoint(point, sim)
+			},
+		})
+		return
+	}
+
+	distance := unit.Position.DistanceTo(point)
+	if distance == 0 {
+		move.Moving = false
+		move.moveAura.Deactivate(sim)
+		return
+	}
+
+	if !move.moveAura.IsActive() {
+		if unit.IsChanneling(sim) {
+			unit.ChanneledDot.Cancel(sim)
+		}
+		move.moveSpell.Cast(sim, unit.CurrentTarget)
+	}
+
+	// One tick per yard, so that range checks see the unit in between.
+	numTicks := int(math.Ceil(distance))
+	step := point.Sub(unit.Position).Scale(1 / float64(numTicks))
+	ticks := 0
+
+	move.moveAction = NewPeriodicAction(sim, PeriodicActionOptions{
+		Period:   DurationFromSeconds(distance / float64(numTicks) / move.MoveSpeed),
+		NumTicks: numTicks,
+
+		OnAction: func(sim *Simulation) {
+			ticks++
+			if ticks == numTicks {
+				unit.SetPosition(point, sim)
+			} else {
+				unit.SetPosition(unit.Position.Add(step), sim)
+			}
+			move.moveAura.SetStacks(sim, int32(unit.DistanceFromTarget))
+
+			if ticks == numTicks {
+				move.moveAction = nil
+				move.moveAura.Deactivate(sim)
+			}
+		},
+	})
+	sim.AddPendingAction(move.moveAction)
 }
 
 // A move speed increase of 30% should be represented as 1.30 and a move speed slow of 70% should be respresented as 0.70
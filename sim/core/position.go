@@ -0,0 +1,91 @@
+package core
+
+import (
+	"math"
+
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+// A point or offset on the ground, in yards.
+type Vector2 struct {
+	X float64
+	Y float64
+}
+
+func Vector2FromProto(vector *proto.Vector2) Vector2 {
+	if vector == nil {
+		return Vector2{}
+	}
+	return Vector2{X: vector.X, Y: vector.Y}
+}
+
+func (v Vector2) Add(other Vector2) Vector2 {
+	return Vector2{X: v.X + other.X, Y: v.Y + other.Y}
+}
+
+func (v Vector2) Sub(other Vector2) Vector2 {
+	return Vector2{X: v.X - other.X, Y: v.Y - other.Y}
+}
+
+func (v Vector2) Scale(factor float64) Vector2 {
+	return Vector2{X: v.X * factor, Y: v.Y * factor}
+}
+
+func (v Vector2) Length() float64 {
+	return math.Hypot(v.X, v.Y)
+}
+
+func (v Vector2) DistanceTo(other Vector2) float64 {
+	return v.Sub(other).Length()
+}
+
+// Places the unit next to its target, unless it was given a start position.
+func (unit *Unit) initPosition() {
+	targetPosition := unit.CurrentTarget.StartPosition
+	if unit.hasStartPosition {
+		unit.StartDistanceFromTarget = unit.StartPosition.DistanceTo(targetPosition)
+	} else {
+		unit.StartPosition = targetPosition.Add(Vector2{X: unit.StartDistanceFromTarget})
+	}
+	unit.Position = unit.StartPosition
+	unit.DistanceFromTarget = unit.StartDistanceFromTarget
+}
+
+// Distance to another unit, in yards.
+func (unit *Unit) DistanceTo(other *Unit) float64 {
+	return unit.Position.DistanceTo(other.Position)
+}
+
+// Whether the other unit is at most maxRange yards away. Always true if the encounter doesn't check ranges.
+func (unit *Unit) IsWithinRange(other *Unit, maxRange float64) bool {
+	return unit.Env.Encounter.IsWithinRange(unit.Position, other.Position, maxRange)
+}
+
+// Whether the points are at most maxRange yards apart. Always true if the encounter doesn't check ranges.
+func (encounter *Encounter) IsWithinRange(a Vector2, b Vector2, maxRange float64) bool {
+	return !encounter.RangeChecks || a.DistanceTo(b) <= maxRange
+}
+
+// Moves the unit, keeping the distance of raid units to their targets up to date.
+// Targets are assumed to always reach whoever they are attacking.
+func (unit *Unit) SetPosition(position Vector2, sim *Simulation) {
+	unit.Position = position
+	if unit.Type == EnemyUnit {
+		for _, raidUnit := range unit.Env.Raid.AllUnits {
+			if raidUnit.CurrentTarget == unit {
+				raidUnit.updateDistanceFromTarget(sim)
+			}
+		}
+	} else {
+		unit.updateDistanceFromTarget(sim)
+	}
+}
+
+// Also starts or stops melee swings when the unit gets into or out of melee range.
+func (unit *Unit) updateDistanceFromTarget(sim *Simulation) {
+	if unit.Type != EnemyUnit && unit.CurrentTarget != nil {
+		wasInMeleeRange := unit.DistanceFromTarget <= MaxMeleeAttackDistance
+		unit.DistanceFromTarget = unit.DistanceTo(unit.CurrentTarget)
+		unit.AutoAttacks.updateMeleeRange(sim, wasInMeleeRange)
+	}
+}
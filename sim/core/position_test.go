@@ -0,0 +1,27 @@
+package core
+
+import (
+	"testing"
+)
+
+func TestSpellRangeChecks(t *testing.T) {
+	sim := SetupFakeSim()
+	fa := sim.Raid.Parties[0].Players[0].(*FakeAgent)
+	target := sim.Encounter.TargetUnits[0]
+	fa.Spell.MaxRange = 30
+
+	fa.SetPosition(target.Position.Add(Vector2{X: 40}), sim)
+	if !fa.Spell.CanCast(sim, target) {
+		t.Fatalf("Expected the spell to be castable from 40 yards without range checks")
+	}
+
+	sim.Encounter.RangeChecks = true
+	if fa.Spell.CanCast(sim, target) {
+		t.Fatalf("Expected the spell to be out of range from 40 yards")
+	}
+
+	fa.SetPosition(target.Position.Add(Vector2{X: 30}), sim)
+	if !fa.Spell.CanCast(sim, target) {
+		t.Fatalf("Expected the spell to be castable from 30 yards")
+	}
+}
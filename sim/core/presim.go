@@ -70,6 +70,10 @@ func (sim *Simulation) runPresims(request *proto.RaidSimRequest) *proto.RaidSimR
 	presimRequest.SimOptions.Debug = false
 	presimRequest.SimOptions.DebugFirstIteration = false
 	presimRequest.SimOptions.Iterations = numPresimIterations
+	presimRequest.SimOptions.MaxDpsStderr = 0
+	presimRequest.SimOptions.MaxDpsRelativeStderr = 0
+	presimRequest.SimOptions.ReplaySeed = 0
+	presimRequest.SimOptions.CombatLogIterations = 0
 	duration := DurationFromSeconds(presimRequest.Encounter.Duration)
 
 	var lastResult *proto.RaidSimResult
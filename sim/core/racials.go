@@ -122,7 +122,7 @@ func applyRaceEffects(agent Agent) {
 
 		// Beast Slaying (+5% damage to beasts)
 		character.Env.RegisterPostFinalizeEffect(func() {
-			for _, t := range character.Env.Encounter.Targets {
+			for _, t := range character.Env.Encounter.AllTargets {
 				if t.MobType == proto.MobType_MobTypeBeast {
 					for _, at := range character.AttackTables[t.UnitIndex] {
 						at.DamageDealtMultiplier *= 1.05
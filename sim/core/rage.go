@@ -166,6 +166,9 @@ func (rb *rageBar) AddRage(sim *Simulation, amount float64, metrics *ResourceMet
 	if sim.Log != nil {
 		rb.unit.Log(sim, "Gained %0.3f rage from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, rb.currentRage, newRage)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, rb.unit, metrics, amount, newRage-rb.currentRage, newRage)
+	}
 
 	rb.currentRage = newRage
 	if !sim.Options.Interactive {
@@ -191,6 +194,9 @@ func (rb *rageBar) SpendRage(sim *Simulation, amount float64, metrics *ResourceM
 	if sim.Log != nil {
 		rb.unit.Log(sim, "Spent %0.3f rage from %s (%0.3f --> %0.3f).", amount, metrics.ActionID, rb.currentRage, newRage)
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.resource(sim, rb.unit, metrics, -amount, -amount, newRage)
+	}
 
 	rb.currentRage = newRage
 
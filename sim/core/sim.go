@@ -43,6 +43,13 @@ type Simulation struct {
 
 	Log func(string, ...interface{})
 
+	// Only set while recording structured combat log events, see SimOptions.CombatLogIterations.
+	CombatLog *CombatLog
+
+	// Set if the sim should stop once precise enough, with the index this sim reports to it as.
+	convergence      *convergenceTracker
+	convergenceSplit int
+
 	executePhase int32 // 20, 25, or 35 for the respective execute range, 100 otherwise
 
 	executePhaseCallbacks []func(*Simulation, int32) // 2nd parameter is 35 for 35%, 25 for 25% and 20 for 20%
@@ -110,7 +117,14 @@ func RunSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, sig
 	return runSim(rsr, progress, false, signals)
 }
 
-func runSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool, signals simsignals.Signals) (result *proto.RaidSimResult) {
+func runSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool, signals simsignals.Signals) *proto.RaidSimResult {
+	return runSimWithConvergence(rsr, progress, skipPresim, signals, newConvergenceTracker(rsr.SimOptions, 1), 0)
+}
+
+// Like runSim, but reports raid DPS to a convergence tracker shared with other sims, using the
+// given split index. This lets the splits of a concurrent sim stop together, once their combined
+// raid DPS is precise enough.
+func runSimWithConvergence(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, skipPresim bool, signals simsignals.Signals, convergence *convergenceTracker, convergenceSplit int) (result *proto.RaidSimResult) {
 	if !rsr.SimOptions.IsTest {
 		defer func() {
 			if err := recover(); err != nil {
@@ -138,7 +152,10 @@ func runSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, ski
 		}()
 	}
 
+	applyReplaySeed(rsr.SimOptions)
 	sim := NewSim(rsr, signals)
+	sim.convergence = convergence
+	sim.convergenceSplit = convergenceSplit
 
 	if !skipPresim {
 		if progress != nil {
@@ -182,6 +199,20 @@ func runSim(rsr *proto.RaidSimRequest, progress chan *proto.ProgressMetrics, ski
 	return result
 }
 
+// Sets up the options for replaying a single iteration, see SimOptions.ReplaySeed. Iterations are
+// seeded by reseedRands, which gives the same random numbers as starting a new sim with that seed.
+func applyReplaySeed(options *proto.SimOptions) {
+	if options.ReplaySeed == 0 {
+		return
+	}
+	options.RandomSeed = options.ReplaySeed
+	options.Iterations = 1
+	options.Debug = true
+	options.CombatLogIterations = 1
+	options.MaxDpsStderr = 0
+	options.MaxDpsRelativeStderr = 0
+}
+
 func NewSim(rsr *proto.RaidSimRequest, signals simsignals.Signals) *Simulation {
 	env, _, _ := NewEnvironment(rsr.Raid, rsr.Encounter, false)
 	return newSimWithEnv(env, rsr.SimOptions, signals)
@@ -290,6 +321,20 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 		}
 	}
 
+	var combatLog *CombatLog
+	if sim.Options.CombatLogIterations > 0 {
+		combatLog = newCombatLog(sim.Environment)
+		sim.CombatLog = combatLog
+	}
+
+	if sim.Options.AplTrace {
+		for _, unit := range sim.AllUnits {
+			if unit.Rotation != nil {
+				unit.Rotation.enableTrace()
+			}
+		}
+	}
+
 	// Uncomment this to print logs directly to console.
 	// sim.Options.Debug = true
 	// sim.Log = func(message string, vals ...interface{}) {
@@ -308,7 +353,13 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 	}
 
 	var st time.Time
+	iterations := sim.Options.Iterations
 	for i := int32(1); i < sim.Options.Iterations; i++ {
+		if sim.convergence != nil && sim.convergence.update(sim.convergenceSplit, sim.Raid.dpsMetrics.aggregator) {
+			iterations = i
+			break
+		}
+
 		if sim.Signals.Abort.IsTriggered() {
 			quitResult := &proto.RaidSimResult{Error: &proto.ErrorOutcome{Type: proto.ErrorOutcomeType_ErrorOutcomeAborted}}
 			if sim.ProgressReport != nil {
@@ -332,6 +383,14 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 		// Before each iteration, reset state to seed+iterations
 		sim.reseedRands(int64(i))
 
+		if combatLog != nil {
+			if i < sim.Options.CombatLogIterations {
+				combatLog.iteration = i
+			} else {
+				sim.CombatLog = nil
+			}
+		}
+
 		sim.runOnce()
 		iterDuration := sim.Duration
 		if sim.Encounter.EndFightAtHealth != 0 {
@@ -345,16 +404,21 @@ func (sim *Simulation) run() *proto.RaidSimResult {
 
 		Logs:                   logsBuffer.String(),
 		FirstIterationDuration: firstIterationDuration.Seconds(),
-		AvgIterationDuration:   totalDuration.Seconds() / float64(sim.Options.Iterations),
-		IterationsDone:         sim.Options.Iterations,
+		AvgIterationDuration:   totalDuration.Seconds() / float64(iterations),
+		IterationsDone:         iterations,
+		Converged:              iterations < sim.Options.Iterations,
+	}
+	result.DpsConfidenceInterval = newConfidenceInterval(result.RaidMetrics.Dps)
+	if combatLog != nil {
+		result.CombatLog = combatLog.Events
 	}
 
 	// Final progress report
 	if sim.ProgressReport != nil {
-		sim.ProgressReport(&proto.ProgressMetrics{TotalIterations: sim.Options.Iterations, CompletedIterations: sim.Options.Iterations, Dps: result.RaidMetrics.Dps.Avg, FinalRaidResult: result})
+		sim.ProgressReport(&proto.ProgressMetrics{TotalIterations: sim.Options.Iterations, CompletedIterations: iterations, Dps: result.RaidMetrics.Dps.Avg, FinalRaidResult: result})
 	}
 
-	if d := sim.Options.Iterations; d > 3000 {
+	if d := iterations; d > 3000 {
 		log.Printf("running %d iterations took %s", d, time.Since(t0))
 	}
 
@@ -467,7 +531,7 @@ func (sim *Simulation) Cleanup() {
 	for _, unit := range sim.Raid.AllUnits {
 		unit.Metrics.doneIteration(unit, sim)
 	}
-	for _, target := range sim.Encounter.TargetUnits {
+	for _, target := range sim.Encounter.AllTargetUnits {
 		target.Metrics.doneIteration(target, sim)
 	}
 }
@@ -556,6 +620,10 @@ func (sim *Simulation) advance(nextTime time.Duration) {
 		}
 	}
 
+	if sim.Encounter.DamageTaken >= sim.Encounter.nextHealthTriggerDamage {
+		sim.Encounter.runHealthTriggers(sim)
+	}
+
 	if sim.CurrentTime >= sim.minTrackerTime {
 		sim.minTrackerTime = NeverExpires
 		for _, t := range sim.trackers {
@@ -22,6 +22,11 @@ func SplitSimRequestForConcurrency(request *proto.RaidSimRequest, splitCount int
 		return res
 	}
 
+	if request.SimOptions.ReplaySeed != 0 {
+		request = googleProto.Clone(request).(*proto.RaidSimRequest)
+		applyReplaySeed(request.SimOptions)
+	}
+
 	if request.SimOptions.Iterations <= 0 {
 		res.ErrorResult = "Iterations can't be 0 or negative!"
 		return res
@@ -38,12 +43,21 @@ func SplitSimRequestForConcurrency(request *proto.RaidSimRequest, splitCount int
 	// Sims increment their seed each iteration. Offset starting seed of each split to emulate that.
 	nextStartSeed := split[0].SimOptions.RandomSeed + int64(split[0].SimOptions.Iterations)
 
+	// Splits run consecutive iterations, so record the combat log in as many of them as needed.
+	combatLogIterationsLeft := max(request.SimOptions.CombatLogIterations-split[0].SimOptions.Iterations, 0)
+	split[0].SimOptions.CombatLogIterations = min(request.SimOptions.CombatLogIterations, split[0].SimOptions.Iterations)
+	scaleConvergenceForSplit(split[0].SimOptions, splitCount)
+
 	for i := 1; i < int(splitCount); i++ {
 		split[i] = googleProto.Clone(request).(*proto.RaidSimRequest)
 		split[i].SimOptions.Iterations = iterPerSplit
 		split[i].SimOptions.DebugFirstIteration = false // No logs
 		split[i].SimOptions.RandomSeed = nextStartSeed
 		nextStartSeed += int64(split[i].SimOptions.Iterations)
+
+		split[i].SimOptions.CombatLogIterations = min(combatLogIterationsLeft, iterPerSplit)
+		combatLogIterationsLeft -= split[i].SimOptions.CombatLogIterations
+		scaleConvergenceForSplit(split[i].SimOptions, splitCount)
 	}
 
 	res.SplitsDone = splitCount
@@ -81,6 +95,7 @@ func (rsrc *raidSimResultCombiner) newUnitMetrics(baseUnit *proto.UnitMetrics) *
 		Auras:     make([]*proto.AuraMetrics, len(baseUnit.Auras)),
 		Resources: make([]*proto.ResourceMetrics, 0, len(baseUnit.Resources)),
 		Pets:      make([]*proto.UnitMetrics, len(baseUnit.Pets)),
+		AplItems:  make([]*proto.APLItemMetrics, 0, len(baseUnit.AplItems)),
 	}
 
 	for i, aura := range baseUnit.Auras {
@@ -245,6 +260,30 @@ func (rsrc *raidSimResultCombiner) addResourceMetrics(unit *proto.UnitMetrics, a
 	rm.ActualGain += add.ActualGain
 }
 
+func (rsrc *raidSimResultCombiner) addAplItemMetrics(unit *proto.UnitMetrics, add *proto.APLItemMetrics) {
+	var im *proto.APLItemMetrics
+
+	for _, baseItem := range unit.AplItems {
+		if baseItem.ActionList == add.ActionList && baseItem.ItemIndex == add.ItemIndex {
+			im = baseItem
+			break
+		}
+	}
+
+	if im == nil {
+		im = &proto.APLItemMetrics{
+			ActionList: add.ActionList,
+			ItemIndex:  add.ItemIndex,
+		}
+		unit.AplItems = append(unit.AplItems, im)
+	}
+
+	im.Evaluations += add.Evaluations
+	im.ConditionTrue += add.ConditionTrue
+	im.NotReady += add.NotReady
+	im.Executions += add.Executions
+}
+
 func (rsrc *raidSimResultCombiner) combineUnitMetrics(base *proto.UnitMetrics, add *proto.UnitMetrics, isLast bool, weight float64) {
 	rsrc.combineDistMetrics(base.Dps, add.Dps, isLast, weight)
 	rsrc.combineDistMetrics(base.Dpasp, add.Dpasp, isLast, weight)
@@ -269,6 +308,10 @@ func (rsrc *raidSimResultCombiner) combineUnitMetrics(base *proto.UnitMetrics, a
 		rsrc.addResourceMetrics(base, addResource)
 	}
 
+	for _, addItem := range add.AplItems {
+		rsrc.addAplItemMetrics(base, addItem)
+	}
+
 	for i, addPet := range add.Pets {
 		rsrc.combineUnitMetrics(base.Pets[i], addPet, isLast, weight)
 	}
@@ -292,7 +335,14 @@ func (rsrc *raidSimResultCombiner) AddResult(result *proto.RaidSimResult, isLast
 	}
 
 	rsrc.Combined.AvgIterationDuration += result.AvgIterationDuration * weight
+
+	// Combat log iterations are numbered per split, continue from the iterations of the previous splits.
+	for _, event := range result.CombatLog {
+		event.Iteration += rsrc.Combined.IterationsDone
+		rsrc.Combined.CombatLog = append(rsrc.Combined.CombatLog, event)
+	}
 	rsrc.Combined.IterationsDone += result.IterationsDone
+	rsrc.Combined.Converged = rsrc.Combined.Converged || result.Converged
 
 	if rsrc.Debug {
 		rsrc.Combined.Logs += "-SIMSTART-\n" + result.Logs
@@ -349,6 +399,7 @@ func CombineConcurrentSimResults(results []*proto.RaidSimResult, isDebug bool) *
 		resultWeight := float64(results[i].IterationsDone) / float64(totalIterations)
 		rsrc.AddResult(result, i == numResults-1, resultWeight)
 	}
+	rsrc.Combined.DpsConfidenceInterval = newConfidenceInterval(rsrc.Combined.RaidMetrics.Dps)
 
 	return rsrc.Combined
 }
@@ -439,6 +490,7 @@ func runSimConcurrent(request *proto.RaidSimRequest, progress chan *proto.Progre
 		}
 	}()
 
+	applyReplaySeed(request.SimOptions)
 	splitRes := SplitSimRequestForConcurrency(request, TernaryInt32(request.SimOptions.IsTest, 3, int32(runtime.NumCPU())))
 
 	if splitRes.ErrorResult != "" {
@@ -468,8 +520,11 @@ func runSimConcurrent(request *proto.RaidSimRequest, progress chan *proto.Progre
 		log.Printf("Running %d iterations on %d concurrent sims.", csd.IterationsTotal, csd.Concurrency)
 	}
 
+	// Splits share the precision target of the whole request, rather than the scaled down ones
+	// used when splits run separately.
+	convergence := newConvergenceTracker(request.SimOptions, len(splitRes.Requests))
 	for i, req := range splitRes.Requests {
-		go RunSim(req, substituteChannels[i], signals)
+		go runSimWithConvergence(req, substituteChannels[i], false, signals, convergence, i)
 	}
 
 	progressCounter := 0
@@ -23,6 +23,7 @@ type SpellConfig struct {
 	Flags         SpellFlag
 	CastType      proto.CastType
 	MissileSpeed  float64
+	MaxRange      float64
 	BaseCost      float64
 	MetricSplits  int
 	Rank          int
@@ -100,6 +101,10 @@ type Spell struct {
 	// Example: https://wow.tools/dbc/?dbc=spellmisc&build=3.4.0.44996
 	MissileSpeed float64
 
+	// Maximum distance to the target in yards, or 0 for no range check. Only
+	// checked in encounters with range checks. Melee abilities default to melee range.
+	MaxRange float64
+
 	Rank          int
 	RequiredLevel int
 
@@ -227,6 +232,14 @@ func (unit *Unit) RegisterSpell(config SpellConfig) *Spell {
 		panic("Cast.SharedCD w/o Duration specified for spell " + config.ActionID.String())
 	}
 
+	if config.MaxRange == 0 && config.Flags.Matches(SpellFlagAPL) {
+		if config.DefenseType == DefenseTypeMelee && config.ProcMask.Matches(ProcMaskMeleeSpecial) {
+			config.MaxRange = MaxMeleeAttackDistance
+		} else if config.ProcMask.Matches(ProcMaskSpellDamage | ProcMaskRangedSpecial) {
+			config.MaxRange = DefaultSpellRange
+		}
+	}
+
 	if config.Cast.CastTime == nil {
 		config.Cast.CastTime = func(spell *Spell) time.Duration {
 			return spell.Unit.ApplyCastSpeedForSpell(spell.DefaultCast.CastTime, spell)
@@ -242,6 +255,7 @@ func (unit *Unit) RegisterSpell(config SpellConfig) *Spell {
 		Flags:        config.Flags,
 		CastType:     config.CastType,
 		MissileSpeed: config.MissileSpeed,
+		MaxRange:     config.MaxRange,
 
 		SpellSchool:       config.SpellSchool,
 		SchoolIndex:       config.SpellSchool.GetSchoolIndex(),
@@ -512,6 +526,15 @@ func (spell *Spell) CanCast(sim *Simulation, target *Unit) bool {
 		return false
 	}
 
+	// Adds can't be targeted before they spawn or after they despawn
+	if target != nil && target.Type == EnemyUnit && !target.IsEnabled() {
+		return false
+	}
+
+	if spell.MaxRange > 0 && target != nil && target != spell.Unit && !spell.Unit.IsWithinRange(target, spell.MaxRange) {
+		return false
+	}
+
 	// While moving only instant casts are possible
 	if spell.DefaultCast.CastTime > 0 && spell.Unit.IsMoving() {
 		//if sim.Log != nil {
@@ -577,9 +600,8 @@ func (spell *Spell) applyEffects(sim *Simulation, target *Unit) {
 }
 
 func (spell *Spell) ApplyAOEThreatIgnoreMultipliers(threatAmount float64) {
-	numTargets := spell.Unit.Env.GetNumTargets()
-	for i := int32(0); i < numTargets; i++ {
-		spell.SpellMetrics[i].TotalThreat += threatAmount
+	for _, target := range spell.Unit.Env.Encounter.TargetUnits {
+		spell.SpellMetrics[target.UnitIndex].TotalThreat += threatAmount
 	}
 }
 func (spell *Spell) ApplyAOEThreat(threatAmount float64) {
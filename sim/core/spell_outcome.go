@@ -28,6 +28,13 @@ func (spell *Spell) OutcomeAlwaysMiss(_ *Simulation, result *SpellResult, _ *Att
 	spell.SpellMetrics[result.Target.UnitIndex].Misses++
 }
 
+// Targets can't be hit during encounter downtime or while they aren't present. Not counted as a miss,
+// and doesn't trigger any procs.
+func (spell *Spell) outcomeTargetUnavailable(_ *Simulation, result *SpellResult, _ *AttackTable) {
+	result.Outcome = OutcomeUnavailable
+	result.Damage = 0
+}
+
 func (dot *Dot) OutcomeTick(_ *Simulation, result *SpellResult, _ *AttackTable) {
 	isPartialResist := result.DidResist()
 	result.Outcome = OutcomeHit
@@ -261,6 +261,9 @@ func (spell *Spell) ApplyPostOutcomeDamageModifiers(sim *Simulation, result *Spe
 
 // For spells that do no damage but still have a hit/miss check.
 func (spell *Spell) CalcOutcome(sim *Simulation, target *Unit, outcomeApplier OutcomeApplier) *SpellResult {
+	if !sim.Encounter.TargetAvailable(target) {
+		outcomeApplier = spell.outcomeTargetUnavailable
+	}
 	attackTable := spell.Unit.AttackTables[target.UnitIndex][spell.CastType]
 	result := spell.NewResult(target)
 
@@ -270,6 +273,9 @@ func (spell *Spell) CalcOutcome(sim *Simulation, target *Unit, outcomeApplier Ou
 }
 
 func (spell *Spell) calcDamageInternal(sim *Simulation, target *Unit, baseDamage float64, attackerMultiplier float64, isPeriodic bool, outcomeApplier OutcomeApplier) *SpellResult {
+	if !sim.Encounter.TargetAvailable(target) {
+		outcomeApplier = spell.outcomeTargetUnavailable
+	}
 	attackTable := spell.Unit.AttackTables[target.UnitIndex][spell.CastType]
 
 	result := spell.NewResult(target)
@@ -448,7 +454,7 @@ func (spell *Spell) dealDamageInternal(sim *Simulation, isPeriodic bool, result
 	// Mark total damage done in raid so far for health based fights.
 	// Don't include damage done by EnemyUnits to Players
 	if result.Target.Type == EnemyUnit {
-		sim.Encounter.DamageTaken += result.Damage
+		sim.Encounter.damageTaken(sim, result.Target, result.Damage)
 	}
 
 	if sim.Log != nil && !spell.Flags.Matches(SpellFlagNoLogs) {
@@ -458,8 +464,11 @@ func (spell *Spell) dealDamageInternal(sim *Simulation, isPeriodic bool, result
 			spell.Unit.Log(sim, "%s %s %s (SpellSchool: %d). (Threat: %0.3f)", result.Target.LogLabel(), spell.ActionID, result.DamageString(), spell.SpellSchool, result.Threat)
 		}
 	}
+	if sim.CombatLog != nil && !spell.Flags.Matches(SpellFlagNoLogs) {
+		sim.CombatLog.spellHit(sim, spell, result, isPeriodic, false)
+	}
 
-	if !spell.Flags.Matches(SpellFlagNoOnDamageDealt) {
+	if !spell.Flags.Matches(SpellFlagNoOnDamageDealt) && !result.Outcome.Matches(OutcomeUnavailable) {
 		if isPeriodic {
 			spell.Unit.OnPeriodicDamageDealt(sim, spell, result)
 			result.Target.OnPeriodicDamageTaken(sim, spell, result)
@@ -566,6 +575,9 @@ func (spell *Spell) dealHealingInternal(sim *Simulation, isPeriodic bool, result
 			spell.Unit.Log(sim, "%s %s %s. (Threat: %0.3f)", result.Target.LogLabel(), spell.ActionID, result.HealingString(), result.Threat)
 		}
 	}
+	if sim.CombatLog != nil {
+		sim.CombatLog.spellHit(sim, spell, result, isPeriodic, true)
+	}
 
 	if isPeriodic {
 		spell.Unit.OnPeriodicHealDealt(sim, spell, result)
@@ -0,0 +1,89 @@
+package core
+
+import (
+	"errors"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/simsignals"
+	"github.com/wowsims/classic/sim/core/stats"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+// Returns a request per step, starting with the unmodified player, along with the amount of the stat
+// added in each.
+func buildStatScalingRequests(request *proto.StatScalingRequest) ([]*proto.RaidSimRequest, []float64, error) {
+	var stat stats.UnitStat
+	switch unitStat := request.UnitStat.(type) {
+	case *proto.StatScalingRequest_Stat:
+		stat = stats.UnitStatFromStat(stats.Stat(unitStat.Stat))
+	case *proto.StatScalingRequest_PseudoStat:
+		stat = stats.UnitStatFromPseudoStat(unitStat.PseudoStat)
+	default:
+		return nil, nil, errors.New("no stat to scale")
+	}
+	if request.Player == nil || request.SimOptions == nil {
+		return nil, nil, errors.New("missing player or sim options")
+	}
+
+	step := request.Step
+	if step == 0 {
+		step = statWeightStep(stat)
+	}
+	numSteps := request.NumSteps
+	if numSteps <= 0 {
+		numSteps = 10
+	}
+
+	baseRequest := newStatWeightBaseRequest(request.Player, request.PartyBuffs, request.RaidBuffs, request.Debuffs, request.Tanks, request.Encounter, request.SimOptions)
+	simRequests := []*proto.RaidSimRequest{baseRequest}
+	amounts := []float64{0}
+	for i := int32(1); i <= numSteps; i++ {
+		amount := step * float64(i)
+		simRequest := googleProto.Clone(baseRequest).(*proto.RaidSimRequest)
+		stat.AddToStatsProto(simRequest.Raid.Parties[0].Players[0].BonusStats, amount)
+		simRequests = append(simRequests, simRequest)
+		amounts = append(amounts, amount)
+	}
+	return simRequests, amounts, nil
+}
+
+// Sims the player with increasing amounts of a stat.
+func runStatScaling(request *proto.StatScalingRequest, progress chan *proto.ProgressMetrics, signals simsignals.Signals) *proto.StatScalingResult {
+	simRequests, amounts, err := buildStatScalingRequests(request)
+	if err != nil {
+		return &proto.StatScalingResult{Error: &proto.ErrorOutcome{Message: err.Error()}}
+	}
+
+	result := &proto.StatScalingResult{}
+	if unitStat, ok := request.UnitStat.(*proto.StatScalingRequest_Stat); ok {
+		statsResult := ComputeStats(&proto.ComputeStatsRequest{
+			Raid:      googleProto.Clone(simRequests[0].Raid).(*proto.Raid),
+			Encounter: simRequests[0].Encounter,
+		})
+		if statsResult.ErrorResult != "" {
+			return &proto.StatScalingResult{Error: &proto.ErrorOutcome{Message: statsResult.ErrorResult}}
+		}
+		result.BaseValue = statsResult.RaidStats.Parties[0].Players[0].FinalStats.Stats[unitStat.Stat]
+	}
+
+	simResults, simErr := runSimsSequentially(simRequests, progress, signals)
+	if simErr != nil {
+		return &proto.StatScalingResult{Error: simErr}
+	}
+
+	basePlayer := simResults[0].RaidMetrics.Parties[0].Players[0]
+	for i, simResult := range simResults {
+		player := simResult.RaidMetrics.Parties[0].Players[0]
+		result.Points = append(result.Points, &proto.StatScalingPoint{
+			AmountAdded: amounts[i],
+			Dps:         player.Dps,
+			Tps:         player.Threat,
+			DpsDelta:    newPairedDelta(basePlayer.Dps, player.Dps),
+		})
+	}
+	for _, point := range result.Points {
+		point.Dps.AllValues = nil
+		point.Tps.AllValues = nil
+	}
+	return result
+}
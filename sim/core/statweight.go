@@ -100,48 +100,69 @@ func (swr *StatWeightsResult) ToProto() *proto.StatWeightsResult {
 	}
 }
 
-func buildStatWeightRequests(swr *proto.StatWeightsRequest) *proto.StatWeightRequestsData {
-	if swr.Player.BonusStats == nil {
-		swr.Player.BonusStats = &proto.UnitStats{}
+// Returns the request for the unmodified player, which the runs with modified stats are cloned from.
+func newStatWeightBaseRequest(player *proto.Player, partyBuffs *proto.PartyBuffs, raidBuffs *proto.RaidBuffs, debuffs *proto.Debuffs, tanks []*proto.UnitReference, encounter *proto.Encounter, simOptions *proto.SimOptions) *proto.RaidSimRequest {
+	if player.BonusStats == nil {
+		player.BonusStats = &proto.UnitStats{}
 	}
-	if swr.Player.BonusStats.Stats == nil {
-		swr.Player.BonusStats.Stats = make([]float64, stats.Len)
+	if player.BonusStats.Stats == nil {
+		player.BonusStats.Stats = make([]float64, stats.Len)
 	}
-	if swr.Player.BonusStats.PseudoStats == nil {
-		swr.Player.BonusStats.PseudoStats = make([]float64, stats.PseudoStatsLen)
+	if player.BonusStats.PseudoStats == nil {
+		player.BonusStats.PseudoStats = make([]float64, stats.PseudoStatsLen)
 	}
 
-	raidProto := SinglePlayerRaidProto(swr.Player, swr.PartyBuffs, swr.RaidBuffs, swr.Debuffs)
-	raidProto.Tanks = swr.Tanks
-
-	swr.SimOptions.SaveAllValues = true
+	raidProto := SinglePlayerRaidProto(player, partyBuffs, raidBuffs, debuffs)
+	raidProto.Tanks = tanks
 
-	// Cut in half since we're doing above and below separately.
-	// This number needs to be the same for the baseline sim too, so that RNG lines up perfectly.
-	swr.SimOptions.Iterations /= 2
+	simOptions.SaveAllValues = true
 
 	// Make sure an RNG seed is always set because it gives more consistent results.
 	// When there is no user-supplied seed it needs to be a randomly-selected seed
 	// though, so that run-run differences still exist.
-	if swr.SimOptions.RandomSeed == 0 {
-		swr.SimOptions.RandomSeed = time.Now().UnixNano()
+	if simOptions.RandomSeed == 0 {
+		simOptions.RandomSeed = time.Now().UnixNano()
 	}
 
 	// Reduce variance even more by using test-level RNG controls.
-	swr.SimOptions.UseLabeledRands = true
+	simOptions.UseLabeledRands = true
+
+	// Iterations are compared pairwise, so all runs need to do the same ones.
+	simOptions.MaxDpsStderr = 0
+	simOptions.MaxDpsRelativeStderr = 0
+
+	return &proto.RaidSimRequest{
+		Raid:       raidProto,
+		Encounter:  encounter,
+		SimOptions: simOptions,
+	}
+}
+
+const defaultStatMod = 1.0 // lowered for SoD
+
+// Amount a stat is changed by for finite differences.
+func statWeightStep(stat stats.UnitStat) float64 {
+	if stat.IsPseudoStat() {
+		return 3
+	}
+	if stat.EqualsStat(stats.Armor) || stat.EqualsStat(stats.BonusArmor) || stat.EqualsStat(stats.Mana) {
+		return defaultStatMod * 20
+	}
+	return defaultStatMod
+}
+
+func buildStatWeightRequests(swr *proto.StatWeightsRequest) *proto.StatWeightRequestsData {
+	// Cut in half since we're doing above and below separately.
+	// This number needs to be the same for the baseline sim too, so that RNG lines up perfectly.
+	swr.SimOptions.Iterations /= 2
 
 	swBaseResponse := &proto.StatWeightRequestsData{
-		BaseRequest: &proto.RaidSimRequest{
-			Raid:       raidProto,
-			Encounter:  swr.Encounter,
-			SimOptions: swr.SimOptions,
-		},
+		BaseRequest:     newStatWeightBaseRequest(swr.Player, swr.PartyBuffs, swr.RaidBuffs, swr.Debuffs, swr.Tanks, swr.Encounter, swr.SimOptions),
 		EpReferenceStat: swr.EpReferenceStat,
 		StatSimRequests: []*proto.StatWeightsStatRequestData{},
 	}
 
 	// Do half the iterations with a positive, and half with a negative value for better accuracy.
-	const defaultStatMod = 1.0 // lowered for SoD
 	statModsLow := make([]float64, stats.UnitStatsLen)
 	statModsHigh := make([]float64, stats.UnitStatsLen)
 
@@ -152,16 +173,13 @@ func buildStatWeightRequests(swr *proto.StatWeightsRequest) *proto.StatWeightReq
 	statsToWeigh := stats.ProtoArrayToStatsList(swr.StatsToWeigh)
 	for _, s := range statsToWeigh {
 		stat := stats.UnitStatFromStat(s)
-		statMod := defaultStatMod
-		if stat.EqualsStat(stats.Armor) || stat.EqualsStat(stats.BonusArmor) || stat.EqualsStat(stats.Mana) {
-			statMod = defaultStatMod * 20
-		}
+		statMod := statWeightStep(stat)
 		statModsHigh[stat] = statMod
 		statModsLow[stat] = -statMod
 	}
 	for _, s := range swr.PseudoStatsToWeigh {
 		stat := stats.UnitStatFromPseudoStat(s)
-		statMod := 3.0
+		statMod := statWeightStep(stat)
 		statModsHigh[stat] = statMod
 		statModsLow[stat] = -statMod
 	}
@@ -205,6 +223,7 @@ func computeStatWeights(swcr *proto.StatWeightsCalcRequest) *proto.StatWeightsRe
 	}
 
 	result := NewStatWeightsResult()
+	var dpsDeltas []*proto.StatWeightsDpsDelta
 	for _, statResult := range swcr.StatSimResults {
 		stat := stats.UnitStatFromIdx(int(statResult.StatData.UnitStat))
 
@@ -212,6 +231,12 @@ func computeStatWeights(swcr *proto.StatWeightsCalcRequest) *proto.StatWeightsRe
 		modPlayerLow := statResult.ResultLow.RaidMetrics.Parties[0].Players[0]
 		modPlayerHigh := statResult.ResultHigh.RaidMetrics.Parties[0].Players[0]
 
+		dpsDeltas = append(dpsDeltas, &proto.StatWeightsDpsDelta{
+			StatData: statResult.StatData,
+			Low:      newPairedDelta(baselinePlayer.Dps, modPlayerLow.Dps),
+			High:     newPairedDelta(baselinePlayer.Dps, modPlayerHigh.Dps),
+		})
+
 		// Check for hard caps. Hard caps will have results identical to the baseline because RNG is fixed.
 		// When we find a hard-capped stat, just skip it (will return 0).
 		if modPlayerHigh.Dps.Avg == baselinePlayer.Dps.Avg && modPlayerHigh.Hps.Avg == baselinePlayer.Hps.Avg && modPlayerHigh.Tmi.Avg == baselinePlayer.Tmi.Avg {
@@ -245,12 +270,20 @@ func computeStatWeights(swcr *proto.StatWeightsCalcRequest) *proto.StatWeightsRe
 		result.PDeath.WeightsStdev.AddStat(stat, 0)
 	}
 
-	referenceStat := stats.Stat(swcr.EpReferenceStat)
-
-	// Compute EP results.
+	var unitStats []stats.UnitStat
 	for _, statData := range swcr.StatSimResults {
-		stat := stats.UnitStatFromIdx(int(statData.StatData.UnitStat))
+		unitStats = append(unitStats, stats.UnitStatFromIdx(int(statData.StatData.UnitStat)))
+	}
+	result.computeEpValues(stats.Stat(swcr.EpReferenceStat), unitStats)
 
+	resultProto := result.ToProto()
+	resultProto.DpsDeltas = dpsDeltas
+	return resultProto
+}
+
+// Computes EP values from the weights of the given stats.
+func (swr *StatWeightsResult) computeEpValues(referenceStat stats.Stat, unitStats []stats.UnitStat) {
+	for _, stat := range unitStats {
 		calcEpResults := func(weightResults *StatWeightValues, refStat stats.Stat) {
 			if weightResults.Weights.Stats[refStat] == 0 {
 				return
@@ -261,30 +294,57 @@ func computeStatWeights(swcr *proto.StatWeightsCalcRequest) *proto.StatWeightsRe
 			weightResults.EpValuesStdev.AddStat(stat, stdev)
 		}
 
-		calcEpResults(&result.Dps, referenceStat)
-		calcEpResults(&result.Hps, referenceStat)
-		calcEpResults(&result.Tps, referenceStat)
-		calcEpResults(&result.Dtps, DTPSReferenceStat)
-		calcEpResults(&result.Tmi, DTPSReferenceStat)
-		calcEpResults(&result.PDeath, DTPSReferenceStat)
+		calcEpResults(&swr.Dps, referenceStat)
+		calcEpResults(&swr.Hps, referenceStat)
+		calcEpResults(&swr.Tps, referenceStat)
+		calcEpResults(&swr.Dtps, DTPSReferenceStat)
+		calcEpResults(&swr.Tmi, DTPSReferenceStat)
+		calcEpResults(&swr.PDeath, DTPSReferenceStat)
 	}
-
-	return result.ToProto()
 }
 
 // Run stat weight sims and compute weights.
 func runStatWeights(request *proto.StatWeightsRequest, progress chan *proto.ProgressMetrics, signals simsignals.Signals) *proto.StatWeightsResult {
+	if request.Regression != nil {
+		return runStatWeightsRegression(request, progress, signals)
+	}
+
 	requestData := buildStatWeightRequests(request)
 
-	var iterationsTotal int32 = requestData.BaseRequest.SimOptions.Iterations
+	simRequests := []*proto.RaidSimRequest{requestData.BaseRequest}
+	for _, reqData := range requestData.StatSimRequests {
+		simRequests = append(simRequests, reqData.RequestLow, reqData.RequestHigh)
+	}
+	results, err := runSimsSequentially(simRequests, progress, signals)
+	if err != nil {
+		return &proto.StatWeightsResult{Error: err}
+	}
+
+	statResults := []*proto.StatWeightsStatResultData{}
+	for i, reqData := range requestData.StatSimRequests {
+		statResults = append(statResults, &proto.StatWeightsStatResultData{
+			StatData:   reqData.StatData,
+			ResultLow:  results[1+2*i],
+			ResultHigh: results[2+2*i],
+		})
+	}
+
+	return computeStatWeights(&proto.StatWeightsCalcRequest{
+		BaseResult:      results[0],
+		EpReferenceStat: requestData.EpReferenceStat,
+		StatSimResults:  statResults,
+	})
+}
+
+// Runs the sims one after another, reporting progress over all of them.
+func runSimsSequentially(requests []*proto.RaidSimRequest, progress chan *proto.ProgressMetrics, signals simsignals.Signals) ([]*proto.RaidSimResult, *proto.ErrorOutcome) {
+	var iterationsTotal int32 = 0
 	var iterationsDone int32 = 0
-	var simsTotal int32 = 1
+	var simsTotal int32 = int32(len(requests))
 	var simsCompleted int32 = 0
 
-	for _, reqData := range requestData.StatSimRequests {
-		iterationsTotal += reqData.RequestLow.SimOptions.Iterations
-		iterationsTotal += reqData.RequestHigh.SimOptions.Iterations
-		simsTotal += 2
+	for _, request := range requests {
+		iterationsTotal += request.SimOptions.Iterations
 	}
 
 	waitForResult := func(srcProgressChannel chan *proto.ProgressMetrics) *proto.RaidSimResult {
@@ -312,44 +372,19 @@ func runStatWeights(request *proto.StatWeightsRequest, progress chan *proto.Prog
 
 	simFunc := runSimConcurrent
 	// Don't use go threads in wasm, it just adds more overhead and makes the worker more unresponsive.
-	if IsRunningInWasm() || request.SimOptions.IsTest {
+	if IsRunningInWasm() || requests[0].SimOptions.IsTest {
 		simFunc = RunSim
 	}
 
-	baseProgress := make(chan *proto.ProgressMetrics, 100)
-	go simFunc(requestData.BaseRequest, baseProgress, signals)
-	baselineResult := waitForResult(baseProgress)
-	if baselineResult.Error != nil {
-		return &proto.StatWeightsResult{Error: baselineResult.Error}
-	}
-
-	statResults := []*proto.StatWeightsStatResultData{}
-
-	for _, reqData := range requestData.StatSimRequests {
-		lowProgress := make(chan *proto.ProgressMetrics, 100)
-		go simFunc(reqData.RequestLow, lowProgress, signals)
-		lowRes := waitForResult(lowProgress)
-		if lowRes.Error != nil {
-			return &proto.StatWeightsResult{Error: lowRes.Error}
+	results := make([]*proto.RaidSimResult, 0, len(requests))
+	for _, request := range requests {
+		simProgress := make(chan *proto.ProgressMetrics, 100)
+		go simFunc(request, simProgress, signals)
+		result := waitForResult(simProgress)
+		if result.Error != nil {
+			return nil, result.Error
 		}
-
-		highProgress := make(chan *proto.ProgressMetrics, 100)
-		go simFunc(reqData.RequestHigh, highProgress, signals)
-		highRes := waitForResult(highProgress)
-		if highRes.Error != nil {
-			return &proto.StatWeightsResult{Error: highRes.Error}
-		}
-
-		statResults = append(statResults, &proto.StatWeightsStatResultData{
-			StatData:   reqData.StatData,
-			ResultLow:  lowRes,
-			ResultHigh: highRes,
-		})
+		results = append(results, result)
 	}
-
-	return computeStatWeights(&proto.StatWeightsCalcRequest{
-		BaseResult:      baselineResult,
-		EpReferenceStat: requestData.EpReferenceStat,
-		StatSimResults:  statResults,
-	})
+	return results, nil
 }
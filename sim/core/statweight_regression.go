@@ -0,0 +1,241 @@
+package core
+
+import (
+	"errors"
+	"math"
+	"slices"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/simsignals"
+	"github.com/wowsims/classic/sim/core/stats"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+// Finite differences only see a single step around the base stats, which is misleading near
+// breakpoints such as the hit cap. Regression instead sims many random combinations of all weighed
+// stats over a wider radius, and fits a model of the metrics to them:
+//
+//	delta = sum(weight_i * x_i) [+ sum(quadratic_i * x_i^2)]
+//
+// where x are the perturbations and delta the change from the base run. All runs use the same random
+// numbers, so the fit isn't drowned out by sim noise.
+
+type statWeightFit struct {
+	coefs    []float64
+	stderrs  []float64
+	rSquared float64
+}
+
+func runStatWeightsRegression(request *proto.StatWeightsRequest, progress chan *proto.ProgressMetrics, signals simsignals.Signals) *proto.StatWeightsResult {
+	// Reuse the finite difference setup, for the base request and the step size of each stat.
+	requestData := buildStatWeightRequests(request)
+	options := request.Regression
+
+	unitStats := make([]stats.UnitStat, len(requestData.StatSimRequests))
+	scales := make([]float64, len(requestData.StatSimRequests))
+	radius := options.Radius
+	if radius <= 0 {
+		radius = 5
+	}
+	for i, reqData := range requestData.StatSimRequests {
+		unitStats[i] = stats.UnitStatFromIdx(int(reqData.StatData.UnitStat))
+		scales[i] = reqData.StatData.ModHigh * radius
+	}
+
+	numTerms := len(unitStats)
+	if options.Quadratic {
+		numTerms *= 2
+	}
+	numSamples := int(options.Samples)
+	if numSamples <= 0 {
+		numSamples = 4 * numTerms
+	}
+	// Standard errors need more samples than terms, and samples come in mirrored pairs.
+	numSamples = max(numSamples, numTerms+2)
+	numSamples += numSamples % 2
+
+	// Each sample is mirrored, which cancels out quadratic effects in the linear terms.
+	rand := NewSplitMix(uint64(requestData.BaseRequest.SimOptions.RandomSeed))
+	perturbations := make([][]float64, 0, numSamples)
+	for len(perturbations) < numSamples {
+		x := make([]float64, len(unitStats))
+		mirrored := make([]float64, len(unitStats))
+		for i := range x {
+			x[i] = (rand.NextFloat64()*2 - 1) * scales[i]
+			mirrored[i] = -x[i]
+		}
+		perturbations = append(perturbations, x, mirrored)
+	}
+
+	simRequests := []*proto.RaidSimRequest{requestData.BaseRequest}
+	for _, x := range perturbations {
+		simRequest := googleProto.Clone(requestData.BaseRequest).(*proto.RaidSimRequest)
+		for i, stat := range unitStats {
+			stat.AddToStatsProto(simRequest.Raid.Parties[0].Players[0].BonusStats, x[i])
+		}
+		simRequests = append(simRequests, simRequest)
+	}
+	results, simErr := runSimsSequentially(simRequests, progress, signals)
+	if simErr != nil {
+		return &proto.StatWeightsResult{Error: simErr}
+	}
+
+	design := make([][]float64, len(perturbations))
+	for k, x := range perturbations {
+		design[k] = slices.Clone(x)
+		if options.Quadratic {
+			for _, v := range x {
+				design[k] = append(design[k], v*v)
+			}
+		}
+	}
+
+	result := NewStatWeightsResult()
+	basePlayer := results[0].RaidMetrics.Parties[0].Players[0]
+	var dpsFit statWeightFit
+	for _, metric := range []struct {
+		get     func(*proto.UnitMetrics) float64
+		weights *StatWeightValues
+	}{
+		{get: func(m *proto.UnitMetrics) float64 { return m.Dps.Avg }, weights: &result.Dps},
+		{get: func(m *proto.UnitMetrics) float64 { return m.Hps.Avg }, weights: &result.Hps},
+		{get: func(m *proto.UnitMetrics) float64 { return m.Threat.Avg }, weights: &result.Tps},
+		{get: func(m *proto.UnitMetrics) float64 { return m.Dtps.Avg }, weights: &result.Dtps},
+		{get: func(m *proto.UnitMetrics) float64 { return m.Tmi.Avg }, weights: &result.Tmi},
+		{get: func(m *proto.UnitMetrics) float64 { return m.ChanceOfDeath }, weights: &result.PDeath},
+	} {
+		deltas := make([]float64, len(perturbations))
+		for k := range perturbations {
+			deltas[k] = metric.get(results[k+1].RaidMetrics.Parties[0].Players[0]) - metric.get(basePlayer)
+		}
+		fit, err := fitStatWeights(design, deltas)
+		if err != nil {
+			return &proto.StatWeightsResult{Error: &proto.ErrorOutcome{Message: err.Error()}}
+		}
+		for i, stat := range unitStats {
+			metric.weights.Weights.AddStat(stat, fit.coefs[i])
+			metric.weights.WeightsStdev.AddStat(stat, fit.stderrs[i])
+		}
+		if metric.weights == &result.Dps {
+			dpsFit = fit
+		}
+	}
+	result.computeEpValues(stats.Stat(requestData.EpReferenceStat), unitStats)
+
+	resultProto := result.ToProto()
+	resultProto.RegressionRSquared = dpsFit.rSquared
+	for i, stat := range unitStats {
+		fit := &proto.StatWeightFit{
+			UnitStat:     int32(stat),
+			Weight:       dpsFit.coefs[i],
+			WeightStderr: dpsFit.stderrs[i],
+			WeightCiLow:  dpsFit.coefs[i] - confidenceZ95*dpsFit.stderrs[i],
+			WeightCiHigh: dpsFit.coefs[i] + confidenceZ95*dpsFit.stderrs[i],
+		}
+		if options.Quadratic {
+			j := len(unitStats) + i
+			fit.Quadratic = dpsFit.coefs[j]
+			fit.QuadraticStderr = dpsFit.stderrs[j]
+			fit.Nonlinear = math.Abs(fit.Quadratic) > confidenceZ95*fit.QuadraticStderr
+		}
+		resultProto.RegressionFits = append(resultProto.RegressionFits, fit)
+	}
+	return resultProto
+}
+
+// Fits y = X * coefs by ordinary least squares, without an intercept since the deltas are relative
+// to the base run.
+func fitStatWeights(x [][]float64, y []float64) (statWeightFit, error) {
+	numTerms := len(x[0])
+	if len(y) <= numTerms {
+		return statWeightFit{}, errors.New("stat weight regression needs more samples than terms")
+	}
+
+	xtx := make([][]float64, numTerms)
+	xty := make([]float64, numTerms)
+	for i := range xtx {
+		xtx[i] = make([]float64, numTerms)
+		for k := range x {
+			xty[i] += x[k][i] * y[k]
+			for j := range xtx[i] {
+				xtx[i][j] += x[k][i] * x[k][j]
+			}
+		}
+	}
+	inverse, ok := invertMatrix(xtx)
+	if !ok {
+		return statWeightFit{}, errors.New("stat weight regression samples don't determine all terms")
+	}
+
+	fit := statWeightFit{
+		coefs:   make([]float64, numTerms),
+		stderrs: make([]float64, numTerms),
+	}
+	for i := range fit.coefs {
+		for j := range xty {
+			fit.coefs[i] += inverse[i][j] * xty[j]
+		}
+	}
+
+	var rss, tss float64
+	for k := range x {
+		predicted := 0.0
+		for i, coef := range fit.coefs {
+			predicted += coef * x[k][i]
+		}
+		rss += (y[k] - predicted) * (y[k] - predicted)
+		tss += y[k] * y[k]
+	}
+	variance := rss / float64(len(y)-numTerms)
+	for i := range fit.stderrs {
+		fit.stderrs[i] = math.Sqrt(variance * inverse[i][i])
+	}
+	if tss > 0 {
+		fit.rSquared = 1 - rss/tss
+	}
+	return fit, nil
+}
+
+// Inverts a square matrix by Gauss-Jordan elimination. Returns false if it's singular.
+func invertMatrix(m [][]float64) ([][]float64, bool) {
+	n := len(m)
+	a := make([][]float64, n)
+	for i := range m {
+		a[i] = make([]float64, 2*n)
+		copy(a[i], m[i])
+		a[i][n+i] = 1
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(a[row][col]) > math.Abs(a[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-12 {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+
+		scale := 1 / a[col][col]
+		for j := range a[col] {
+			a[col][j] *= scale
+		}
+		for row := 0; row < n; row++ {
+			if row == col || a[row][col] == 0 {
+				continue
+			}
+			factor := a[row][col]
+			for j := range a[row] {
+				a[row][j] -= factor * a[col][j]
+			}
+		}
+	}
+
+	inverse := make([][]float64, n)
+	for i := range a {
+		inverse[i] = a[i][n:]
+	}
+	return inverse, true
+}
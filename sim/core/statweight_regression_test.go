@@ -0,0 +1,36 @@
+package core
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitStatWeights(t *testing.T) {
+	// y = 2*a - 0.5*b + 0.1*a^2, with mirrored samples and no noise.
+	var x [][]float64
+	var y []float64
+	for _, sample := range [][]float64{{1, 3}, {2, -1}, {-4, 2}, {3, 5}} {
+		for _, sign := range []float64{1, -1} {
+			a, b := sign*sample[0], sign*sample[1]
+			x = append(x, []float64{a, b, a * a, b * b})
+			y = append(y, 2*a-0.5*b+0.1*a*a)
+		}
+	}
+
+	fit, err := fitStatWeights(x, y)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, expected := range []float64{2, -0.5, 0.1, 0} {
+		if math.Abs(fit.coefs[i]-expected) > 1e-9 || fit.stderrs[i] > 1e-6 {
+			t.Errorf("Expected coefficient %d to be %f, got %f +/- %f", i, expected, fit.coefs[i], fit.stderrs[i])
+		}
+	}
+	if math.Abs(fit.rSquared-1) > 1e-9 {
+		t.Errorf("Expected a perfect fit, got R squared %f", fit.rSquared)
+	}
+
+	if _, err := fitStatWeights(x[:4], y[:4]); err == nil {
+		t.Errorf("Expected an error with as many samples as terms")
+	}
+}
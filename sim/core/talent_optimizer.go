@@ -0,0 +1,324 @@
+package core
+
+import (
+	"cmp"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"strings"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	goproto "google.golang.org/protobuf/proto"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/simsignals"
+)
+
+// Bulk sims only try the talent loadouts they're given. The talent optimizer instead searches the
+// valid builds around the current talents: every round it enumerates the builds that move some
+// points from one talent to another, ranks those moves by the value of each talent, and sims the
+// most promising ones. Like the gear optimizer, this is a beam search, see beamSearch.
+
+const defaultTalentPoints = 51
+
+// Points needed in earlier rows of a tree to put points in the next row.
+const talentPointsPerRow = 5
+
+// Parses talent trees in the format of the files in ui/core/talents/trees.
+func TalentTreesFromJsonString(jsonString string) ([]*proto.TalentTreeConfig, error) {
+	request := &proto.TalentOptimizerRequest{}
+	data := []byte(`{"trees":` + jsonString + `}`)
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(data, request); err != nil {
+		return nil, err
+	}
+	return request.Trees, nil
+}
+
+type talentBuild struct {
+	points [][]int32 // Indexed by tree, then by talent in talent string order.
+	key    string    // Talent string.
+}
+
+func newTalentBuild(points [][]int32) *talentBuild {
+	treeStrs := make([]string, len(points))
+	for i, treePoints := range points {
+		sb := &strings.Builder{}
+		for _, p := range treePoints {
+			sb.WriteByte(byte('0' + p))
+		}
+		treeStrs[i] = strings.TrimRight(sb.String(), "0")
+	}
+	return &talentBuild{points: points, key: strings.Join(treeStrs, "-")}
+}
+
+func (build *talentBuild) searchKey() string {
+	return build.key
+}
+func (build *talentBuild) applyTo(player *proto.Player) {
+	player.TalentsString = build.key
+}
+
+// Returns a copy of the build with points moved from one talent to another. A nil from moves
+// unspent points.
+func (build *talentBuild) moved(from *talentIndex, to talentIndex, amount int32) *talentBuild {
+	points := make([][]int32, len(build.points))
+	for i := range points {
+		points[i] = slices.Clone(build.points[i])
+	}
+	if from != nil {
+		points[from.tree][from.talent] -= amount
+	}
+	points[to.tree][to.talent] += amount
+	return newTalentBuild(points)
+}
+
+type talentIndex struct {
+	tree   int
+	talent int
+}
+
+type talentOptimizer struct {
+	request     *proto.TalentOptimizerRequest
+	baseRequest *proto.RaidSimRequest
+	signals     simsignals.Signals
+	progress    chan *proto.ProgressMetrics
+	search      *beamSearch[*talentBuild]
+
+	trees     []*proto.TalentTreeConfig
+	maxPoints int32
+	prereqs   [][]int     // Index of each talent's prerequisite in its tree, or -1.
+	values    [][]float64 // Estimated DPS per point of each talent.
+}
+
+func runTalentOptimizer(request *proto.TalentOptimizerRequest, progress chan *proto.ProgressMetrics, signals simsignals.Signals) *proto.TalentOptimizerResult {
+	opt := &talentOptimizer{
+		request:  request,
+		signals:  signals,
+		progress: progress,
+	}
+	return opt.run()
+}
+
+func (opt *talentOptimizer) run() (result *proto.TalentOptimizerResult) {
+	defer func() {
+		if err := recover(); err != nil {
+			result = &proto.TalentOptimizerResult{
+				Error: &proto.ErrorOutcome{Message: fmt.Sprintf("%v\nStack Trace:\n%s", err, string(debug.Stack()))},
+			}
+		}
+	}()
+
+	current, errorOutcome := opt.setup()
+	if errorOutcome != nil {
+		return &proto.TalentOptimizerResult{Error: errorOutcome}
+	}
+
+	fullIterations := cmp.Or(opt.request.Iterations, defaultIterationsPerCombo)
+	opt.search = newBeamSearch[*talentBuild](opt.baseRequest, opt.progress, opt.signals)
+	if errorOutcome := opt.estimateValues(current, beamStartIterations(fullIterations)); errorOutcome != nil {
+		return &proto.TalentOptimizerResult{Error: errorOutcome}
+	}
+
+	pool, errorOutcome := opt.search.run(current, opt.bestMoves, opt.request.BeamWidth, fullIterations)
+	if errorOutcome != nil {
+		return &proto.TalentOptimizerResult{Error: errorOutcome}
+	}
+
+	return opt.finalResult(current, pool)
+}
+
+// Validates the request and returns the current build.
+func (opt *talentOptimizer) setup() (*talentBuild, *proto.ErrorOutcome) {
+	var errorOutcome *proto.ErrorOutcome
+	if opt.baseRequest, errorOutcome = newOptimizerBaseRequest("talent optimizer", opt.request.BaseSettings); errorOutcome != nil {
+		return nil, errorOutcome
+	}
+	opt.trees = opt.request.Trees
+	if len(opt.trees) == 0 {
+		return nil, &proto.ErrorOutcome{Message: "talent optimizer: missing talent trees"}
+	}
+	opt.maxPoints = cmp.Or(opt.request.MaxPoints, defaultTalentPoints)
+
+	opt.prereqs = make([][]int, len(opt.trees))
+	for i, tree := range opt.trees {
+		opt.prereqs[i] = make([]int, len(tree.Talents))
+		for j, talent := range tree.Talents {
+			opt.prereqs[i][j] = -1
+			if talent.PrereqLocation == nil {
+				continue
+			}
+			opt.prereqs[i][j] = slices.IndexFunc(tree.Talents, func(other *proto.TalentConfig) bool {
+				return goproto.Equal(other.Location, talent.PrereqLocation)
+			})
+			if opt.prereqs[i][j] == -1 {
+				return nil, &proto.ErrorOutcome{Message: fmt.Sprintf("talent optimizer: no prerequisite for %s", talent.FieldName)}
+			}
+		}
+	}
+
+	talentsString := opt.baseRequest.Raid.Parties[0].Players[0].TalentsString
+	points := make([][]int32, len(opt.trees))
+	treeStrs := strings.Split(talentsString, "-")
+	if len(treeStrs) > len(opt.trees) {
+		return nil, &proto.ErrorOutcome{Message: fmt.Sprintf("talent optimizer: talents %q have more trees than the class", talentsString)}
+	}
+	for i, tree := range opt.trees {
+		points[i] = make([]int32, len(tree.Talents))
+		if i >= len(treeStrs) {
+			continue
+		}
+		if len(treeStrs[i]) > len(tree.Talents) {
+			return nil, &proto.ErrorOutcome{Message: fmt.Sprintf("talent optimizer: talents %q have too many talents in the %s tree", talentsString, tree.Name)}
+		}
+		for j, c := range treeStrs[i] {
+			if c < '0' || c > '9' {
+				return nil, &proto.ErrorOutcome{Message: fmt.Sprintf("talent optimizer: talents %q have a non-digit %q", talentsString, c)}
+			}
+			points[i][j] = c - '0'
+		}
+	}
+	current := newTalentBuild(points)
+	if !opt.isValid(current) {
+		return nil, &proto.ErrorOutcome{Message: fmt.Sprintf("talent optimizer: talents %q aren't a valid build", talentsString)}
+	}
+	return current, nil
+}
+
+// Checks the point budget, the points needed for each row and the prerequisites.
+func (opt *talentOptimizer) isValid(build *talentBuild) bool {
+	var total int32
+	for i, tree := range opt.trees {
+		for j, talent := range tree.Talents {
+			p := build.points[i][j]
+			if p == 0 {
+				continue
+			}
+			if p < 0 || p > talent.MaxPoints {
+				return false
+			}
+			total += p
+
+			var pointsBefore int32
+			for k, other := range tree.Talents {
+				if other.Location.GetRowIdx() < talent.Location.GetRowIdx() {
+					pointsBefore += build.points[i][k]
+				}
+			}
+			if pointsBefore < talentPointsPerRow*talent.Location.GetRowIdx() {
+				return false
+			}
+			if prereq := opt.prereqs[i][j]; prereq != -1 && build.points[i][prereq] != tree.Talents[prereq].MaxPoints {
+				return false
+			}
+		}
+	}
+	return total <= opt.maxPoints
+}
+
+// Estimates the DPS per point of each talent, by simming the current build with the talent
+// emptied and maxed. These builds don't need to be valid.
+func (opt *talentOptimizer) estimateValues(current *talentBuild, iterations int32) *proto.ErrorOutcome {
+	type probe struct {
+		emptied *talentBuild
+		maxed   *talentBuild
+	}
+	var probes []probe
+	var builds []*talentBuild
+	for i, tree := range opt.trees {
+		for j, talent := range tree.Talents {
+			p := current.points[i][j]
+			to := talentIndex{tree: i, talent: j}
+			probe := probe{emptied: current.moved(nil, to, -p), maxed: current.moved(nil, to, talent.MaxPoints-p)}
+			probes = append(probes, probe)
+			builds = append(builds, probe.emptied, probe.maxed)
+		}
+	}
+	if _, errorOutcome := opt.search.sim(builds, iterations); errorOutcome != nil {
+		return errorOutcome
+	}
+
+	opt.values = make([][]float64, len(opt.trees))
+	for i, tree := range opt.trees {
+		opt.values[i] = make([]float64, len(tree.Talents))
+		for j, talent := range tree.Talents {
+			probe := probes[0]
+			probes = probes[1:]
+			if talent.MaxPoints > 0 {
+				emptiedDps := opt.search.results[probe.emptied.key].unitMetrics.Dps.Avg
+				maxedDps := opt.search.results[probe.maxed.key].unitMetrics.Dps.Avg
+				opt.values[i][j] = (maxedDps - emptiedDps) / float64(talent.MaxPoints)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the valid builds that move points between two talents, or spend unspent points, with
+// the highest estimated gain.
+func (opt *talentOptimizer) bestMoves(build *talentBuild) []*talentBuild {
+	type move struct {
+		build *talentBuild
+		gain  float64
+	}
+	var moves []move
+	var spent int32
+	var sources []talentIndex
+	for i, treePoints := range build.points {
+		for j, p := range treePoints {
+			spent += p
+			if p > 0 {
+				sources = append(sources, talentIndex{tree: i, talent: j})
+			}
+		}
+	}
+
+	for i, tree := range opt.trees {
+		for j, talent := range tree.Talents {
+			to := talentIndex{tree: i, talent: j}
+			room := talent.MaxPoints - build.points[i][j]
+			for amount := int32(1); amount <= min(room, opt.maxPoints-spent); amount++ {
+				if moved := build.moved(nil, to, amount); opt.isValid(moved) {
+					moves = append(moves, move{build: moved, gain: float64(amount) * opt.values[i][j]})
+				}
+			}
+			for _, from := range sources {
+				if from == to {
+					continue
+				}
+				for amount := int32(1); amount <= min(room, build.points[from.tree][from.talent]); amount++ {
+					if moved := build.moved(&from, to, amount); opt.isValid(moved) {
+						gain := float64(amount) * (opt.values[i][j] - opt.values[from.tree][from.talent])
+						moves = append(moves, move{build: moved, gain: gain})
+					}
+				}
+			}
+		}
+	}
+
+	slices.SortStableFunc(moves, func(a, b move) int {
+		return cmp.Or(cmp.Compare(b.gain, a.gain), strings.Compare(a.build.key, b.build.key))
+	})
+	numMoves := min(int(cmp.Or(opt.request.CandidatesPerBuild, 20)), len(moves))
+	builds := make([]*talentBuild, numMoves)
+	for i := range builds {
+		builds[i] = moves[i].build
+	}
+	return builds
+}
+
+func (opt *talentOptimizer) finalResult(current *talentBuild, pool []*beamResult[*talentBuild]) *proto.TalentOptimizerResult {
+	toProto := func(buildResult *beamResult[*talentBuild], dpsDelta *proto.PairedDelta) *proto.TalentBuildResult {
+		return &proto.TalentBuildResult{
+			TalentsString: buildResult.candidate.key,
+			UnitMetrics:   buildResult.unitMetrics,
+			DpsDelta:      dpsDelta,
+		}
+	}
+
+	currentResult, results := beamFinalResults(current, pool, opt.request.TopK, opt.request.BaseSettings.GetSimOptions().GetSaveAllValues(), toProto)
+	return &proto.TalentOptimizerResult{
+		Results:              results,
+		CurrentTalentsResult: currentResult,
+		SimsRun:              opt.search.simsRun,
+	}
+}
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"slices"
 	"strconv"
 	"time"
 
@@ -11,8 +12,12 @@ import (
 type Encounter struct {
 	Duration          time.Duration
 	DurationVariation time.Duration
-	Targets           []*Target
-	TargetUnits       []*Unit
+	// Targets present in the fight, which doesn't include adds that haven't spawned or were killed.
+	Targets     []*Target
+	TargetUnits []*Unit
+	// All targets, including adds.
+	AllTargets     []*Target
+	AllTargetUnits []*Unit
 
 	ExecuteProportion_20 float64
 	ExecuteProportion_25 float64
@@ -27,6 +32,22 @@ type Encounter struct {
 
 	// Value to multiply by, for damage spells which are subject to the aoe cap.
 	aoeCapMultiplier float64
+
+	// Health triggers of all targets, by descending health percentage.
+	healthTriggers          []healthTrigger
+	nextHealthTrigger       int
+	nextHealthTriggerDamage float64
+
+	// Windows in which the targets can't be hit.
+	downtimes     []*proto.TargetDowntime
+	downtimeAuras []*Aura
+	unavailable   bool
+	availableAt   time.Duration
+	// Units whose auto attacks were stopped by the current downtime.
+	stoppedSwings []*Unit
+
+	// Whether spell ranges and totem radii are checked.
+	RangeChecks bool
 }
 
 func NewEncounter(options *proto.Encounter) Encounter {
@@ -40,11 +61,15 @@ func NewEncounter(options *proto.Encounter) Encounter {
 		ExecuteProportion_25: max(options.ExecuteProportion_25, 0),
 		ExecuteProportion_35: max(options.ExecuteProportion_35, 0),
 		Targets:              []*Target{},
+		downtimes:            options.Downtimes,
+		RangeChecks:          options.RangeChecks,
 	}
-	// If UseHealth is set, we use the sum of targets health.
+	// If UseHealth is set, we use the sum of targets health. Adds have their own health pools.
 	if options.UseHealth {
 		for _, t := range options.Targets {
-			encounter.EndFightAtHealth += t.Stats[stats.Health]
+			if t.Spawn == nil {
+				encounter.EndFightAtHealth += t.Stats[stats.Health]
+			}
 		}
 		if encounter.EndFightAtHealth == 0 {
 			encounter.EndFightAtHealth = 1 // default to something so we don't instantly end without anything.
@@ -53,16 +78,20 @@ func NewEncounter(options *proto.Encounter) Encounter {
 
 	for targetIndex, targetOptions := range options.Targets {
 		target := NewTarget(targetOptions, int32(targetIndex))
-		encounter.Targets = append(encounter.Targets, target)
-		encounter.TargetUnits = append(encounter.TargetUnits, &target.Unit)
+		encounter.AllTargets = append(encounter.AllTargets, target)
+		encounter.AllTargetUnits = append(encounter.AllTargetUnits, &target.Unit)
 	}
-	if len(encounter.Targets) == 0 {
+	if len(encounter.AllTargets) == 0 {
 		// Add a dummy target. The only case where targets aren't specified is when
 		// computing character stats, and targets won't matter there.
 		target := NewTarget(&proto.Target{}, 0)
-		encounter.Targets = append(encounter.Targets, target)
-		encounter.TargetUnits = append(encounter.TargetUnits, &target.Unit)
+		encounter.AllTargets = append(encounter.AllTargets, target)
+		encounter.AllTargetUnits = append(encounter.AllTargetUnits, &target.Unit)
+	}
+	if encounter.AllTargets[0].IsAdd() {
+		panic("The first target can't be an add!")
 	}
+	encounter.resetPresentTargets()
 
 	if encounter.EndFightAtHealth > 0 {
 		// Until we pre-sim set duration to 10m
@@ -83,19 +112,19 @@ func (encounter *Encounter) updateAOECapMultiplier() {
 }
 
 func (encounter *Encounter) doneIteration(sim *Simulation) {
-	for i := range encounter.Targets {
-		target := encounter.Targets[i]
+	for i := range encounter.AllTargets {
+		target := encounter.AllTargets[i]
 		target.doneIteration(sim)
 	}
 }
 
 func (encounter *Encounter) GetMetricsProto() *proto.EncounterMetrics {
 	metrics := &proto.EncounterMetrics{
-		Targets: make([]*proto.UnitMetrics, len(encounter.Targets)),
+		Targets: make([]*proto.UnitMetrics, len(encounter.AllTargets)),
 	}
 
 	i := 0
-	for _, target := range encounter.Targets {
+	for _, target := range encounter.AllTargets {
 		metrics.Targets[i] = target.GetMetricsProto()
 		i++
 	}
@@ -108,6 +137,16 @@ type Target struct {
 	Unit
 
 	AI TargetAI
+
+	phase          int32
+	phaseAuras     map[int32]*Aura
+	phaseCallbacks []func(sim *Simulation, phase int32)
+	timeTriggers   []timeTrigger
+
+	// Only set for adds.
+	spawn          *proto.AddSpawn
+	addDamageTaken float64
+	despawnAction  *PendingAction
 }
 
 func NewTarget(options *proto.Target, targetIndex int32) *Target {
@@ -128,6 +167,8 @@ func NewTarget(options *proto.Target, targetIndex int32) *Target {
 			PseudoStats: stats.NewPseudoStats(),
 			Metrics:     NewUnitMetrics(),
 
+			StartPosition: Vector2FromProto(options.Position),
+
 			StatDependencyManager: stats.NewStatDependencyManager(),
 		},
 	}
@@ -152,24 +193,37 @@ func NewTarget(options *proto.Target, targetIndex int32) *Target {
 	if preset != nil && preset.AI != nil {
 		target.AI = preset.AI()
 	}
+	if options.Script != nil {
+		target.AI = &ScriptedTargetAI{}
+	}
+	target.spawn = options.Spawn
 
 	return target
 }
 
 func (target *Target) Reset(sim *Simulation) {
 	target.Unit.reset(sim, nil)
+	target.phase = 0
+	if target.IsAdd() {
+		// Adds start once they spawn.
+		target.resetAdd(sim)
+		return
+	}
 	target.SetGCDTimer(sim, 0)
 	if target.AI != nil {
 		target.AI.Reset(sim)
 	}
+	target.resetPhases(sim)
 }
 
+// The next target present in the fight, or the first one if this target isn't present.
 func (target *Target) NextTarget() *Target {
-	nextIndex := target.Index + 1
-	if nextIndex >= target.Env.GetNumTargets() {
+	targets := target.Env.Encounter.Targets
+	nextIndex := slices.Index(targets, target) + 1
+	if nextIndex >= len(targets) {
 		nextIndex = 0
 	}
-	return target.Env.GetTarget(nextIndex)
+	return targets[nextIndex]
 }
 
 func (target *Target) GetMetricsProto() *proto.UnitMetrics {
@@ -181,7 +235,7 @@ func (target *Target) GetMetricsProto() *proto.UnitMetrics {
 }
 
 func (character *Character) IsTanking() bool {
-	for _, target := range character.Env.Encounter.TargetUnits {
+	for _, target := range character.Env.Encounter.AllTargetUnits {
 		if target.CurrentTarget == &character.Unit {
 			return true
 		}
@@ -0,0 +1,162 @@
+package core
+
+import (
+	"slices"
+
+	"github.com/wowsims/classic/sim/core/stats"
+)
+
+// Whether this target is an add, which is only present in the fight between spawning and despawning.
+func (target *Target) IsAdd() bool {
+	return target.spawn != nil
+}
+
+func (target *Target) initializeAdd() {
+	for _, percent := range target.spawn.AtHealthPercents {
+		target.RegisterHealthTrigger(percent, target.Spawn)
+	}
+}
+
+func (target *Target) resetAdd(sim *Simulation) {
+	target.enabled = false
+	target.addDamageTaken = 0
+	target.despawnAction = nil
+
+	for _, at := range target.spawn.AtTimes {
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt:     DurationFromSeconds(at),
+			OnAction: target.Spawn,
+		})
+	}
+}
+
+// Adds the target to the fight. Does nothing if it is already present.
+func (target *Target) Spawn(sim *Simulation) {
+	if target.enabled {
+		return
+	}
+
+	target.enabled = true
+	target.addDamageTaken = 0
+	target.Env.Encounter.addPresentTarget(target)
+
+	if sim.Log != nil {
+		target.Log(sim, "Spawned")
+	}
+
+	target.phase = 0
+	if target.AI != nil {
+		target.AI.Reset(sim)
+	}
+	target.resetPhases(sim)
+
+	target.SetGCDTimer(sim, sim.CurrentTime)
+	target.AutoAttacks.EnableAutoSwing(sim)
+
+	if target.spawn.Duration > 0 {
+		target.despawnAction = StartDelayedAction(sim, DelayedActionOptions{
+			DoAt:     sim.CurrentTime + DurationFromSeconds(target.spawn.Duration),
+			OnAction: target.Despawn,
+		})
+	}
+}
+
+// Removes the target from the fight, e.g. when it is killed. Does nothing if it isn't present.
+func (target *Target) Despawn(sim *Simulation) {
+	if !target.enabled {
+		return
+	}
+
+	target.enabled = false
+	encounter := &target.Env.Encounter
+	encounter.removePresentTarget(target)
+
+	if target.gcdAction != nil {
+		target.CancelGCDTimer(sim)
+	}
+	target.AutoAttacks.CancelAutoSwing(sim)
+	target.Hardcast.Expires = startingCDTime
+	if target.despawnAction != nil {
+		target.despawnAction.Cancel(sim)
+		target.despawnAction = nil
+	}
+
+	// Drops dots and other temporary auras, while permanent debuffs stay for a respawn.
+	target.auraTracker.expireAll(sim)
+	if aura := target.phaseAuras[target.phase]; aura != nil {
+		aura.Deactivate(sim)
+	}
+	target.phase = 0
+
+	for _, unit := range target.Env.Raid.AllUnits {
+		if unit.CurrentTarget == &target.Unit {
+			unit.CurrentTarget = encounter.TargetUnits[0]
+			unit.updateDistanceFromTarget(sim)
+		}
+	}
+
+	if sim.Log != nil {
+		target.Log(sim, "Despawned")
+	}
+}
+
+// Tracks damage done to an add, which despawns once its health pool is depleted.
+func (target *Target) takeAddDamage(sim *Simulation, damage float64) {
+	health := target.GetStat(stats.Health)
+	if !target.enabled || health <= 0 || target.addDamageTaken >= health {
+		return
+	}
+
+	target.addDamageTaken += damage
+	if target.addDamageTaken >= health {
+		// Despawn after the current action, so that the damage event can finish.
+		if target.despawnAction != nil {
+			target.despawnAction.Cancel(sim)
+		}
+		target.despawnAction = StartDelayedAction(sim, DelayedActionOptions{
+			DoAt:     sim.CurrentTime,
+			OnAction: target.Despawn,
+		})
+	}
+}
+
+func (encounter *Encounter) damageTaken(sim *Simulation, unit *Unit, damage float64) {
+	target := encounter.AllTargets[unit.Index]
+	if target.IsAdd() {
+		target.takeAddDamage(sim, damage)
+	} else {
+		encounter.DamageTaken += damage
+	}
+}
+
+func (encounter *Encounter) resetPresentTargets() {
+	encounter.Targets = nil
+	encounter.TargetUnits = nil
+	for _, target := range encounter.AllTargets {
+		if !target.IsAdd() {
+			encounter.Targets = append(encounter.Targets, target)
+			encounter.TargetUnits = append(encounter.TargetUnits, &target.Unit)
+		}
+	}
+	encounter.updateAOECapMultiplier()
+}
+
+// The present target lists are replaced rather than modified, so that spells looping over them are unaffected.
+func (encounter *Encounter) addPresentTarget(target *Target) {
+	idx, _ := slices.BinarySearchFunc(encounter.Targets, target.Index, func(t *Target, index int32) int {
+		return int(t.Index - index)
+	})
+	encounter.Targets = slices.Insert(slices.Clone(encounter.Targets), idx, target)
+	encounter.TargetUnits = slices.Insert(slices.Clone(encounter.TargetUnits), idx, &target.Unit)
+	encounter.updateAOECapMultiplier()
+}
+
+func (encounter *Encounter) removePresentTarget(target *Target) {
+	idx := slices.Index(encounter.Targets, target)
+	if idx == -1 {
+		return
+	}
+	encounter.Targets = slices.Delete(slices.Clone(encounter.Targets), idx, idx+1)
+	encounter.TargetUnits = slices.Delete(slices.Clone(encounter.TargetUnits), idx, idx+1)
+	encounter.updateAOECapMultiplier()
+}
@@ -43,10 +43,21 @@ func (target *Target) initialize(config *proto.Target) {
 		target.gcdAction = &PendingAction{
 			Priority: ActionPriorityGCD,
 			OnAction: func(sim *Simulation) {
+				if hc := &target.Hardcast; hc.Expires != startingCDTime && !target.IsCasting(sim) {
+					hc.Expires = startingCDTime
+					if hc.OnComplete != nil {
+						hc.OnComplete(sim, hc.Target)
+					}
+				}
+
 				target.Rotation.DoNextAction(sim)
 			},
 		}
 	}
+
+	if target.IsAdd() {
+		target.initializeAdd()
+	}
 }
 
 // Empty Agent interface functions.
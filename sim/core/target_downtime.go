@@ -0,0 +1,153 @@
+package core
+
+import (
+	"time"
+
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func (encounter *Encounter) initializeDowntimes() {
+	if len(encounter.downtimes) == 0 {
+		return
+	}
+
+	// Shows when the targets are unavailable in their aura metrics.
+	for _, target := range encounter.AllTargets {
+		encounter.downtimeAuras = append(encounter.downtimeAuras, target.RegisterAura(Aura{
+			Label:    "Unavailable",
+			ActionID: ActionID{OtherID: proto.OtherAction_OtherActionTargetUnavailable},
+			Duration: NeverExpires,
+		}))
+	}
+}
+
+func (encounter *Encounter) resetDowntimes(sim *Simulation) {
+	encounter.unavailable = false
+	encounter.availableAt = 0
+	encounter.stoppedSwings = encounter.stoppedSwings[:0]
+
+	for _, downtime := range encounter.downtimes {
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: DurationFromSeconds(downtime.Start),
+			OnAction: func(sim *Simulation) {
+				encounter.startDowntime(sim, downtime)
+			},
+		})
+	}
+}
+
+// Whether the targets can be hit, i.e. they aren't in a downtime window.
+func (encounter *Encounter) TargetsAvailable() bool {
+	return !encounter.unavailable
+}
+
+// Whether the target can be hit, i.e. it isn't an enemy in a downtime window or an add that isn't present.
+func (encounter *Encounter) TargetAvailable(target *Unit) bool {
+	return target.Type != EnemyUnit || (!encounter.unavailable && target.IsEnabled())
+}
+
+// The time until the current downtime window ends, or 0 if the targets can be hit.
+func (encounter *Encounter) TimeUntilTargetsAvailable(sim *Simulation) time.Duration {
+	if !encounter.unavailable {
+		return 0
+	}
+	return encounter.availableAt - sim.CurrentTime
+}
+
+func (encounter *Encounter) startDowntime(sim *Simulation, downtime *proto.TargetDowntime) {
+	duration := DurationFromSeconds(downtime.Duration)
+	if duration <= 0 {
+		return
+	}
+	wasAvailable := !encounter.unavailable
+	prevAvailableAt := sim.CurrentTime
+	if !wasAvailable {
+		prevAvailableAt = encounter.availableAt
+	}
+	encounter.unavailable = true
+	encounter.availableAt = max(encounter.availableAt, sim.CurrentTime+duration)
+	// Dots are already paused until the end of an overlapping window, so only push them back by how much it is extended.
+	extension := encounter.availableAt - prevAvailableAt
+
+	if sim.Log != nil {
+		sim.Log("Targets are unavailable for %s", duration)
+	}
+
+	for _, aura := range encounter.downtimeAuras {
+		aura.Activate(sim)
+	}
+
+	// Swings stop for both sides until the targets are back.
+	if wasAvailable {
+		for _, unit := range sim.Environment.AllUnits {
+			if unit.IsEnabled() && unit.AutoAttacks.enabled {
+				unit.AutoAttacks.CancelAutoSwing(sim)
+				encounter.stoppedSwings = append(encounter.stoppedSwings, unit)
+			}
+		}
+	}
+
+	for _, unit := range sim.Raid.AllUnits {
+		for _, spell := range unit.Spellbook {
+			if spell.dots == nil {
+				continue
+			}
+			for _, target := range encounter.AllTargetUnits {
+				dot := spell.dots.Get(target)
+				if dot == nil || !dot.IsActive() {
+					continue
+				}
+				// Channels can't be paused.
+				if downtime.DropDots || dot.isChanneled {
+					dot.Cancel(sim)
+				} else if extension > 0 {
+					dot.delay(sim, extension)
+				}
+			}
+		}
+	}
+
+	StartDelayedAction(sim, DelayedActionOptions{
+		DoAt:     sim.CurrentTime + duration,
+		OnAction: encounter.endDowntime,
+	})
+}
+
+func (encounter *Encounter) endDowntime(sim *Simulation) {
+	// Overlapping windows end with the last one.
+	if sim.CurrentTime < encounter.availableAt {
+		return
+	}
+	encounter.unavailable = false
+
+	if sim.Log != nil {
+		sim.Log("Targets are available")
+	}
+
+	for _, aura := range encounter.downtimeAuras {
+		aura.Deactivate(sim)
+	}
+
+	for _, unit := range encounter.stoppedSwings {
+		if unit.IsEnabled() {
+			unit.AutoAttacks.EnableAutoSwing(sim)
+		}
+	}
+	encounter.stoppedSwings = encounter.stoppedSwings[:0]
+}
+
+// Pushes back the remaining ticks and the expiration of the dot, so that it continues where it left off.
+func (dot *Dot) delay(sim *Simulation, delay time.Duration) {
+	dot.Aura.UpdateExpires(sim, dot.Aura.ExpiresAt()+delay)
+	dot.lastTickTime += delay
+
+	oldTickAction := dot.tickAction
+	dot.tickAction = nil // prevent tickAction.CleanUp() from ticking now
+	oldTickAction.Cancel(sim)
+
+	periodicOptions := dot.basePeriodicOptions()
+	periodicOptions.Period = dot.tickPeriod
+	dot.tickAction = NewPeriodicAction(sim, periodicOptions)
+	dot.tickAction.NextActionAt = oldTickAction.NextActionAt + delay
+	sim.AddPendingAction(dot.tickAction)
+}
@@ -0,0 +1,169 @@
+package core
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/stats"
+)
+
+type healthTrigger struct {
+	percent  float64
+	callback func(sim *Simulation)
+}
+
+type timeTrigger struct {
+	at       time.Duration
+	callback func(sim *Simulation)
+}
+
+// Changes to a target while it is in a phase.
+type TargetPhaseConfig struct {
+	Stats stats.Stats
+	// 0 means unchanged.
+	DamageTakenMultiplier float64
+	// Seconds between main hand swings, 0 means unchanged.
+	SwingSpeed float64
+}
+
+// Registers a callback for when the encounter health drops to the given percentage (0-100), once per
+// iteration. In fights that use a duration instead of health, the remaining duration is used instead.
+func (target *Target) RegisterHealthTrigger(percent float64, callback func(sim *Simulation)) {
+	if target.Env.IsFinalized() {
+		panic("Tried to add a health trigger in a finalized environment!")
+	}
+	encounter := &target.Env.Encounter
+	encounter.healthTriggers = append(encounter.healthTriggers, healthTrigger{percent: percent, callback: callback})
+	slices.SortStableFunc(encounter.healthTriggers, func(a, b healthTrigger) int {
+		return cmp.Compare(b.percent, a.percent)
+	})
+}
+
+// Registers a callback for a time after the pull, or after spawning for adds, once per iteration.
+func (target *Target) RegisterTimeTrigger(at time.Duration, callback func(sim *Simulation)) {
+	if target.Env.IsFinalized() {
+		panic("Tried to add a time trigger in a finalized environment!")
+	}
+	target.timeTriggers = append(target.timeTriggers, timeTrigger{at: at, callback: callback})
+}
+
+// Registers a callback for each phase change, including to phase 1 at the start of each iteration.
+func (target *Target) RegisterPhaseCallback(callback func(sim *Simulation, phase int32)) {
+	target.phaseCallbacks = append(target.phaseCallbacks, callback)
+}
+
+// Registers changes to the target while it is in the given phase. They are applied with an aura, so
+// phase uptimes show up in the target's aura metrics.
+func (target *Target) RegisterPhase(phase int32, config TargetPhaseConfig) *Aura {
+	if target.phaseAuras == nil {
+		target.phaseAuras = make(map[int32]*Aura)
+	}
+	if target.phaseAuras[phase] != nil {
+		panic("Phase " + strconv.Itoa(int(phase)) + " is already registered!")
+	}
+
+	var attackSpeedMultiplier float64
+	aura := target.RegisterAura(Aura{
+		Label:    "Phase " + strconv.Itoa(int(phase)),
+		ActionID: ActionID{OtherID: proto.OtherAction_OtherActionEncounterPhase, Tag: phase},
+		Duration: NeverExpires,
+		OnGain: func(aura *Aura, sim *Simulation) {
+			aura.Unit.AddStatsDynamic(sim, config.Stats)
+			if config.DamageTakenMultiplier != 0 {
+				aura.Unit.PseudoStats.DamageTakenMultiplier *= config.DamageTakenMultiplier
+			}
+			if config.SwingSpeed != 0 && aura.Unit.AutoAttacks.AutoSwingMelee {
+				attackSpeedMultiplier = aura.Unit.AutoAttacks.MH().SwingSpeed / config.SwingSpeed
+				aura.Unit.MultiplyAttackSpeed(sim, attackSpeedMultiplier)
+			}
+		},
+		OnExpire: func(aura *Aura, sim *Simulation) {
+			aura.Unit.AddStatsDynamic(sim, config.Stats.Invert())
+			if config.DamageTakenMultiplier != 0 {
+				aura.Unit.PseudoStats.DamageTakenMultiplier /= config.DamageTakenMultiplier
+			}
+			if config.SwingSpeed != 0 && aura.Unit.AutoAttacks.AutoSwingMelee {
+				aura.Unit.MultiplyAttackSpeed(sim, 1/attackSpeedMultiplier)
+			}
+		},
+	})
+	target.phaseAuras[phase] = aura
+	return aura
+}
+
+// The target's current phase, starting at 1 each iteration.
+func (target *Target) Phase() int32 {
+	return target.phase
+}
+
+func (target *Target) SetPhase(sim *Simulation, phase int32) {
+	if phase == target.phase {
+		return
+	}
+	if sim.Log != nil {
+		target.Log(sim, "Changing to phase %d", phase)
+	}
+	if aura := target.phaseAuras[target.phase]; aura != nil {
+		aura.Deactivate(sim)
+	}
+	target.phase = phase
+	if aura := target.phaseAuras[phase]; aura != nil {
+		aura.Activate(sim)
+	}
+	for _, callback := range target.phaseCallbacks {
+		callback(sim, phase)
+	}
+}
+
+func (target *Target) resetPhases(sim *Simulation) {
+	target.SetPhase(sim, 1)
+
+	for _, trigger := range target.timeTriggers {
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt:     sim.CurrentTime + trigger.at,
+			OnAction: trigger.callback,
+		})
+	}
+}
+
+func (encounter *Encounter) resetHealthTriggers(sim *Simulation) {
+	encounter.nextHealthTrigger = 0
+	encounter.nextHealthTriggerDamage = math.MaxFloat64
+	if len(encounter.healthTriggers) == 0 {
+		return
+	}
+
+	if encounter.EndFightAtHealth == 0 {
+		// Remaining health follows the duration, so the trigger times are known.
+		for _, trigger := range encounter.healthTriggers {
+			StartDelayedAction(sim, DelayedActionOptions{
+				DoAt:     time.Duration(math.Ceil((1 - trigger.percent/100) * float64(sim.Duration))),
+				OnAction: trigger.callback,
+			})
+		}
+	} else {
+		encounter.updateNextHealthTrigger()
+	}
+}
+
+func (encounter *Encounter) updateNextHealthTrigger() {
+	if encounter.nextHealthTrigger < len(encounter.healthTriggers) {
+		encounter.nextHealthTriggerDamage = (1 - encounter.healthTriggers[encounter.nextHealthTrigger].percent/100) * encounter.EndFightAtHealth
+	} else {
+		encounter.nextHealthTriggerDamage = math.MaxFloat64
+	}
+}
+
+// Runs the health triggers reached in health fights.
+func (encounter *Encounter) runHealthTriggers(sim *Simulation) {
+	for encounter.DamageTaken >= encounter.nextHealthTriggerDamage {
+		trigger := encounter.healthTriggers[encounter.nextHealthTrigger]
+		encounter.nextHealthTrigger++
+		encounter.updateNextHealthTrigger()
+		trigger.callback(sim)
+	}
+}
@@ -0,0 +1,342 @@
+package core
+
+import (
+	"fmt"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/stats"
+)
+
+// Target AI that interprets an EncounterScript, instead of being written for a specific boss.
+type ScriptedTargetAI struct {
+	Target *Target
+	script *proto.EncounterScript
+
+	spells map[string]*Spell
+
+	// Timed events of the current phase, cancelled when it changes.
+	phaseActions []*PendingAction
+	// Timed events of all phases, cancelled on reset so that respawned adds don't repeat them.
+	actions []*PendingAction
+	// Whether the health of each health triggered event has been reached, and whether the event has
+	// happened, indexed like script.Events.
+	healthReached    []bool
+	healthEventsDone []bool
+}
+
+func (ai *ScriptedTargetAI) Initialize(target *Target, config *proto.Target) {
+	ai.Target = target
+	ai.script = config.Script
+	ai.healthReached = make([]bool, len(ai.script.Events))
+	ai.healthEventsDone = make([]bool, len(ai.script.Events))
+
+	auras := make(map[string]AuraArray, len(ai.script.Auras))
+	for _, auraConfig := range ai.script.Auras {
+		if _, ok := auras[auraConfig.Name]; ok {
+			panic(fmt.Sprintf("Encounter script has more than one aura named %q", auraConfig.Name))
+		}
+		auras[auraConfig.Name] = ai.registerAura(auraConfig)
+	}
+
+	ai.spells = make(map[string]*Spell, len(ai.script.Spells))
+	for _, spellConfig := range ai.script.Spells {
+		if _, ok := ai.spells[spellConfig.Name]; ok {
+			panic(fmt.Sprintf("Encounter script has more than one spell named %q", spellConfig.Name))
+		}
+		var aura AuraArray
+		if spellConfig.Aura != "" {
+			var ok bool
+			if aura, ok = auras[spellConfig.Aura]; !ok {
+				panic(fmt.Sprintf("Encounter script spell %q uses unknown aura %q", spellConfig.Name, spellConfig.Aura))
+			}
+		}
+		ai.spells[spellConfig.Name] = ai.registerSpell(spellConfig, aura)
+	}
+
+	for i, event := range ai.script.Events {
+		for _, action := range event.Actions {
+			if name := action.GetCastSpell(); name != "" && ai.spells[name] == nil {
+				panic(fmt.Sprintf("Encounter script event casts unknown spell %q", name))
+			}
+			switch action := action.Action.(type) {
+			case *proto.EncounterAction_SpawnAdd:
+				ai.validateAdd(action.SpawnAdd)
+			case *proto.EncounterAction_DespawnAdd:
+				ai.validateAdd(action.DespawnAdd)
+			}
+		}
+		if trigger, ok := event.Trigger.(*proto.EncounterEvent_AtHealthPercent); ok {
+			target.RegisterHealthTrigger(trigger.AtHealthPercent, func(sim *Simulation) {
+				ai.healthReached[i] = true
+				ai.runHealthEvents(sim)
+			})
+		}
+	}
+
+	for _, phase := range ai.script.Phases {
+		target.RegisterPhase(phase.Phase, TargetPhaseConfig{
+			Stats:                 stats.FromFloatArray(phase.Stats),
+			DamageTakenMultiplier: phase.DamageTakenMultiplier,
+			SwingSpeed:            phase.SwingSpeed,
+		})
+	}
+	target.RegisterPhaseCallback(ai.onPhaseChange)
+}
+
+func (ai *ScriptedTargetAI) validateAdd(index int32) {
+	targets := ai.Target.Env.Encounter.AllTargets
+	if index < 0 || int(index) >= len(targets) || !targets[index].IsAdd() {
+		panic(fmt.Sprintf("Encounter script event uses target %d, which isn't an add", index))
+	}
+}
+
+// Registers the aura on the boss and all raid members.
+func (ai *ScriptedTargetAI) registerAura(config *proto.EncounterAura) AuraArray {
+	duration := DurationFromSeconds(config.Duration)
+	if duration == 0 {
+		duration = NeverExpires
+	}
+	bonusStats := stats.FromFloatArray(config.Stats)
+
+	auras := make(AuraArray, len(ai.Target.Env.AllUnits))
+	for _, unit := range append([]*Unit{&ai.Target.Unit}, ai.Target.Env.Raid.AllUnits...) {
+		auras[unit.UnitIndex] = unit.GetOrRegisterAura(Aura{
+			Label:    config.Name,
+			ActionID: ActionID{SpellID: config.SpellId},
+			Duration: duration,
+			OnGain: func(aura *Aura, sim *Simulation) {
+				aura.Unit.AddStatsDynamic(sim, bonusStats)
+				if config.DamageDealtMultiplier != 0 {
+					aura.Unit.PseudoStats.DamageDealtMultiplier *= config.DamageDealtMultiplier
+				}
+				if config.DamageTakenMultiplier != 0 {
+					aura.Unit.PseudoStats.DamageTakenMultiplier *= config.DamageTakenMultiplier
+				}
+				if config.CastSpeedMultiplier != 0 {
+					aura.Unit.MultiplyCastSpeed(config.CastSpeedMultiplier)
+				}
+				if config.AttackSpeedMultiplier != 0 {
+					aura.Unit.MultiplyAttackSpeed(sim, config.AttackSpeedMultiplier)
+				}
+			},
+			OnExpire: func(aura *Aura, sim *Simulation) {
+				aura.Unit.AddStatsDynamic(sim, bonusStats.Invert())
+				if config.DamageDealtMultiplier != 0 {
+					aura.Unit.PseudoStats.DamageDealtMultiplier /= config.DamageDealtMultiplier
+				}
+				if config.DamageTakenMultiplier != 0 {
+					aura.Unit.PseudoStats.DamageTakenMultiplier /= config.DamageTakenMultiplier
+				}
+				if config.CastSpeedMultiplier != 0 {
+					aura.Unit.MultiplyCastSpeed(1 / config.CastSpeedMultiplier)
+				}
+				if config.AttackSpeedMultiplier != 0 {
+					aura.Unit.MultiplyAttackSpeed(sim, 1/config.AttackSpeedMultiplier)
+				}
+			},
+		})
+	}
+	return auras
+}
+
+func (ai *ScriptedTargetAI) registerSpell(config *proto.EncounterSpell, aura AuraArray) *Spell {
+	school := SpellSchoolFromProto(config.School)
+	isMelee := school == SpellSchoolPhysical
+
+	spellConfig := SpellConfig{
+		ActionID:         ActionID{SpellID: config.SpellId},
+		SpellSchool:      school,
+		DefenseType:      DefenseTypeMagic,
+		ProcMask:         ProcMaskSpellDamage,
+		DamageMultiplier: 1,
+		ThreatMultiplier: 1,
+
+		Cast: CastConfig{
+			DefaultCast: Cast{
+				CastTime: DurationFromSeconds(config.CastTime),
+			},
+		},
+
+		ApplyEffects: func(sim *Simulation, target *Unit, spell *Spell) {
+			for _, unit := range ai.spellTargets(sim, config.Targets, target) {
+				landed := true
+				if config.MaxDamage > 0 {
+					baseDamage := sim.Roll(config.MinDamage, config.MaxDamage)
+					var result *SpellResult
+					if isMelee {
+						result = spell.CalcAndDealDamage(sim, unit, baseDamage, spell.OutcomeEnemyMeleeWhite)
+					} else {
+						result = spell.CalcAndDealDamage(sim, unit, baseDamage, spell.OutcomeMagicHit)
+					}
+					landed = result.Landed()
+				}
+				if aura != nil && landed {
+					aura.Get(unit).Activate(sim)
+				}
+			}
+		},
+	}
+	if isMelee {
+		spellConfig.DefenseType = DefenseTypeMelee
+		spellConfig.ProcMask = ProcMaskMeleeMHSpecial
+		spellConfig.Flags |= SpellFlagMeleeMetrics
+	}
+	if config.CastTime > 0 {
+		spellConfig.Flags |= SpellFlagResetAttackSwing
+	}
+	return ai.Target.RegisterSpell(spellConfig)
+}
+
+// The tank, or the first player for individual sims where nobody tanks the boss.
+func (ai *ScriptedTargetAI) currentTarget() *Unit {
+	if ai.Target.CurrentTarget != nil {
+		return ai.Target.CurrentTarget
+	}
+	return ai.Target.Env.Raid.AllPlayerUnits[0]
+}
+
+func (ai *ScriptedTargetAI) spellTargets(sim *Simulation, targets proto.EncounterSpellTargets, currentTarget *Unit) []*Unit {
+	switch targets {
+	case proto.EncounterSpellTargets_EncounterSpellTargetsRandomPlayer:
+		players := ai.Target.Env.Raid.AllPlayerUnits
+		return []*Unit{players[int(sim.RandomFloat("Encounter Script Target")*float64(len(players)))]}
+	case proto.EncounterSpellTargets_EncounterSpellTargetsAllPlayers:
+		return ai.Target.Env.Raid.AllPlayerUnits
+	case proto.EncounterSpellTargets_EncounterSpellTargetsSelf:
+		return []*Unit{&ai.Target.Unit}
+	default:
+		return []*Unit{currentTarget}
+	}
+}
+
+func (ai *ScriptedTargetAI) Reset(sim *Simulation) {
+	for _, pa := range ai.phaseActions {
+		pa.Cancel(sim)
+	}
+	ai.phaseActions = ai.phaseActions[:0]
+	for _, pa := range ai.actions {
+		pa.Cancel(sim)
+	}
+	ai.actions = ai.actions[:0]
+	clear(ai.healthReached)
+	clear(ai.healthEventsDone)
+
+	for _, event := range ai.script.Events {
+		if _, ok := event.Trigger.(*proto.EncounterEvent_AtTime); ok && event.Phase == 0 {
+			ai.actions = append(ai.actions, ai.scheduleEvent(sim, event))
+		}
+	}
+}
+
+// Runs the health triggered events of the current phase whose health percentage has been reached.
+func (ai *ScriptedTargetAI) runHealthEvents(sim *Simulation) {
+	for i, event := range ai.script.Events {
+		if !ai.healthReached[i] || ai.healthEventsDone[i] || (event.Phase != 0 && event.Phase != ai.Target.Phase()) {
+			continue
+		}
+		ai.healthEventsDone[i] = true
+		ai.runEvent(sim, event)
+		if event.RepeatInterval > 0 {
+			ai.scheduleRepeats(sim, event)
+		}
+	}
+}
+
+func (ai *ScriptedTargetAI) onPhaseChange(sim *Simulation, phase int32) {
+	for _, pa := range ai.phaseActions {
+		pa.Cancel(sim)
+	}
+	ai.phaseActions = ai.phaseActions[:0]
+
+	for _, event := range ai.script.Events {
+		if _, ok := event.Trigger.(*proto.EncounterEvent_AtTime); ok && event.Phase == phase {
+			ai.phaseActions = append(ai.phaseActions, ai.scheduleEvent(sim, event))
+		}
+	}
+	// Health triggers that were reached in an earlier phase happen right away.
+	ai.runHealthEvents(sim)
+}
+
+// Schedules a timed event relative to now.
+func (ai *ScriptedTargetAI) scheduleEvent(sim *Simulation, event *proto.EncounterEvent) *PendingAction {
+	return StartDelayedAction(sim, DelayedActionOptions{
+		DoAt: sim.CurrentTime + DurationFromSeconds(event.GetAtTime()),
+		OnAction: func(sim *Simulation) {
+			ai.runEvent(sim, event)
+			if event.RepeatInterval > 0 {
+				ai.scheduleRepeats(sim, event)
+			}
+		},
+	})
+}
+
+func (ai *ScriptedTargetAI) scheduleRepeats(sim *Simulation, event *proto.EncounterEvent) {
+	pa := StartPeriodicAction(sim, PeriodicActionOptions{
+		Period: DurationFromSeconds(event.RepeatInterval),
+		OnAction: func(sim *Simulation) {
+			ai.runEvent(sim, event)
+		},
+	})
+	if event.Phase != 0 {
+		ai.phaseActions = append(ai.phaseActions, pa)
+	} else {
+		ai.actions = append(ai.actions, pa)
+	}
+}
+
+func (ai *ScriptedTargetAI) runEvent(sim *Simulation, event *proto.EncounterEvent) {
+	// Adds don't act while they are despawned.
+	if !ai.Target.IsEnabled() {
+		return
+	}
+	for _, action := range event.Actions {
+		switch action := action.Action.(type) {
+		case *proto.EncounterAction_CastSpell:
+			ai.castSpell(sim, ai.spells[action.CastSpell])
+		case *proto.EncounterAction_SetPhase:
+			ai.Target.SetPhase(sim, action.SetPhase)
+		case *proto.EncounterAction_SpawnAdd:
+			ai.Target.Env.GetTarget(action.SpawnAdd).Spawn(sim)
+		case *proto.EncounterAction_DespawnAdd:
+			ai.Target.Env.GetTarget(action.DespawnAdd).Despawn(sim)
+		case *proto.EncounterAction_MoveRaid:
+			ai.moveRaid(sim, action.MoveRaid)
+		}
+	}
+}
+
+// Makes the players move, after their current cast. Pets stay with their targets.
+func (ai *ScriptedTargetAI) moveRaid(sim *Simulation, move *proto.EncounterMove) {
+	position := Vector2FromProto(move.Position)
+	for _, unit := range sim.Raid.AllPlayerUnits {
+		if !unit.IsEnabled() {
+			continue
+		}
+		if move.Relative {
+			unit.MoveToPoint(unit.Position.Add(position), sim)
+		} else {
+			unit.MoveToPoint(position, sim)
+		}
+	}
+}
+
+// Casts the spell, after the current cast if the boss is casting.
+func (ai *ScriptedTargetAI) castSpell(sim *Simulation, spell *Spell) {
+	if !ai.Target.IsEnabled() {
+		return
+	}
+	if ai.Target.IsCasting(sim) {
+		StartDelayedAction(sim, DelayedActionOptions{
+			DoAt: ai.Target.Hardcast.Expires,
+			OnAction: func(sim *Simulation) {
+				ai.castSpell(sim, spell)
+			},
+		})
+		return
+	}
+	spell.Cast(sim, ai.currentTarget())
+}
+
+// Everything happens in the scripted events.
+func (ai *ScriptedTargetAI) ExecuteCustomRotation(sim *Simulation) {
+}
@@ -61,6 +61,11 @@ type Unit struct {
 	StartDistanceFromTarget float64
 	DistanceFromTarget      float64
 
+	// Where this unit is, in yards. Kept in sync with DistanceFromTarget.
+	StartPosition    Vector2
+	Position         Vector2
+	hasStartPosition bool
+
 	MovementHandler *MovementHandler
 
 	// Environment in which this Unit exists. This will be nil until after the
@@ -176,7 +181,7 @@ func (unit *Unit) GetOpponents() []*Unit {
 	if unit.Type == EnemyUnit {
 		return unit.Env.Raid.AllUnits
 	} else {
-		return unit.Env.Encounter.TargetUnits
+		return unit.Env.Encounter.AllTargetUnits
 	}
 }
 
@@ -518,6 +523,8 @@ func (unit *Unit) reset(sim *Simulation, _ Agent) {
 	}
 
 	unit.DistanceFromTarget = unit.StartDistanceFromTarget
+	unit.Position = unit.StartPosition
+	unit.MovementHandler.moveAction = nil
 
 	unit.manaBar.reset()
 	unit.focusBar.reset(sim)
@@ -27,8 +27,7 @@ func (druid *Druid) registerSwipeBearSpell() {
 	baseDamage := SwipeBaseDamage[rank]
 
 	rageCost := 20 - float64(druid.Talents.Ferocity)
-	numHits := min(3, druid.Env.GetNumTargets())
-	results := make([]*core.SpellResult, numHits)
+	results := make([]*core.SpellResult, min(3, druid.Env.GetMaxNumTargets()))
 
 	switch druid.Ranged().ID {
 	case IdolOfBrutality:
@@ -60,6 +59,7 @@ func (druid *Druid) registerSwipeBearSpell() {
 		ThreatMultiplier: SwipeThreatMultiplier,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := results[:min(len(results), int(sim.GetNumTargets()))]
 			for idx := range results {
 				results[idx] = spell.CalcDamage(sim, target, baseDamage, spell.OutcomeMeleeSpecialHitAndCrit)
 				target = sim.Environment.NextTargetUnit(target)
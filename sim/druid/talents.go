@@ -1,6 +1,7 @@
 package druid
 
 import (
+	"slices"
 	"time"
 
 	"github.com/wowsims/classic/sim/core"
@@ -16,6 +17,7 @@ func (druid *Druid) ApplyTalents() {
 	druid.applyNaturesGrace()
 	druid.applyMoonglow()
 	druid.applyMoonfury()
+	druid.applyNaturesReach()
 
 	druid.PseudoStats.SchoolDamageDealtMultiplier[stats.SchoolIndexPhysical] *= 1 + 0.02*float64(druid.Talents.NaturalWeapons)
 
@@ -398,6 +400,21 @@ func (druid *Druid) applyVengeance() {
 	})
 }
 
+func (druid *Druid) applyNaturesReach() {
+	if druid.Talents.NaturesReach == 0 {
+		return
+	}
+
+	affectedSpellCodes := []int32{SpellCode_DruidWrath, SpellCode_DruidStarfire, SpellCode_DruidMoonfire, SpellCode_DruidFaerieFire}
+	multiplier := 1 + 0.10*float64(druid.Talents.NaturesReach)
+
+	druid.OnSpellRegistered(func(spell *core.Spell) {
+		if slices.Contains(affectedSpellCodes, spell.SpellCode) {
+			spell.MaxRange *= multiplier
+		}
+	})
+}
+
 func (druid *Druid) applyMoonglow() {
 	if druid.Talents.Moonglow == 0 {
 		return
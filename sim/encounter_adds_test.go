@@ -0,0 +1,153 @@
+package sim
+
+import (
+	"testing"
+
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/stats"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+const addHealth = 2_000
+
+func addTarget(spawn *proto.AddSpawn, health float64) *proto.Target {
+	target := googleProto.Clone(StandardTarget).(*proto.Target)
+	target.Name = "Add"
+	target.Stats[stats.Health] = health
+	target.Spawn = spawn
+	return target
+}
+
+// Rain of Fire while there is more than one target, otherwise Shadow Bolt.
+func addsTestRequest(t *testing.T, targets ...*proto.Target) *proto.RaidSimRequest {
+	rotation, err := apltext.Parse(`
+channel_spell(spell(11678, rank=4)) if number_targets > 1
+cast_spell(spell(25307, rank=9))
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := combatLogTestRequest(1, 1)
+	request.Encounter.Targets = targets
+	request.Raid.Parties[0].Players[0].Rotation = rotation
+	return request
+}
+
+func rainOfFireCastTimes(result *proto.RaidSimResult) []float64 {
+	var times []float64
+	for _, event := range result.CombatLog {
+		if _, ok := event.Event.(*proto.CombatLogEvent_CastComplete); ok && event.ActionId.GetSpellId() == 11678 {
+			times = append(times, event.Timestamp)
+		}
+	}
+	return times
+}
+
+func TestEncounterAdds(t *testing.T) {
+	request := addsTestRequest(t, StandardTarget, addTarget(&proto.AddSpawn{AtTimes: []float64{10}}, addHealth))
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	if len(result.EncounterMetrics.Targets) != 2 {
+		t.Fatalf("Expected metrics for the boss and the add, got %d targets", len(result.EncounterMetrics.Targets))
+	}
+
+	// The add only takes damage while it is alive.
+	addDamage := 0.0
+	lastHit := 0.0
+	for _, event := range result.CombatLog {
+		if hit, ok := event.Event.(*proto.CombatLogEvent_Damage); ok && event.Target.GetType() == proto.UnitReference_Target && event.Target.Index == 1 {
+			addDamage += hit.Damage.Amount
+			lastHit = event.Timestamp
+		}
+	}
+	if addDamage < addHealth || addDamage > addHealth+1_000 {
+		t.Fatalf("Expected the add to take about %d damage before dying, got %0.0f", addHealth, addDamage)
+	}
+
+	// Rain of Fire is only cast once the add spawns, and stops once it dies.
+	times := rainOfFireCastTimes(result)
+	if len(times) == 0 {
+		t.Fatalf("Expected Rain of Fire to be cast while the add is alive")
+	}
+	for _, time := range times {
+		if time < 10 || time > lastHit {
+			t.Fatalf("Expected Rain of Fire to only be cast while the add is alive, got a cast at %0.2fs", time)
+		}
+	}
+}
+
+func TestEncounterScriptSpawnsAdd(t *testing.T) {
+	boss := googleProto.Clone(StandardTarget).(*proto.Target)
+	boss.Script = &proto.EncounterScript{
+		Events: []*proto.EncounterEvent{
+			{
+				Trigger: &proto.EncounterEvent_AtTime{AtTime: 20},
+				Actions: []*proto.EncounterAction{{Action: &proto.EncounterAction_SpawnAdd{SpawnAdd: 1}}},
+			},
+		},
+	}
+	// No health pool, so the add stays until it despawns on its own.
+	request := addsTestRequest(t, boss, addTarget(&proto.AddSpawn{Duration: 10}, 0))
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	times := rainOfFireCastTimes(result)
+	if len(times) == 0 {
+		t.Fatalf("Expected Rain of Fire to be cast while the add is present")
+	}
+	for _, time := range times {
+		if time < 20 || time >= 30 {
+			t.Fatalf("Expected Rain of Fire to only be cast between 20s and 30s, got a cast at %0.2fs", time)
+		}
+	}
+}
+
+func TestEncounterAddsUntargetableWhileAbsent(t *testing.T) {
+	request := addsTestRequest(t, StandardTarget, addTarget(&proto.AddSpawn{AtTimes: []float64{10}, Duration: 20}, 0))
+	rotation, err := apltext.Parse(`
+cast_spell(spell(25307, rank=9), target(1))
+cast_spell(spell(25307, rank=9))
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request.Raid.Parties[0].Players[0].Rotation = rotation
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	// Shadow Bolts at the add start once it spawns, and any still in flight when it despawns do nothing.
+	addHits := 0
+	for _, event := range result.CombatLog {
+		if event.Target.GetType() != proto.UnitReference_Target || event.Target.Index != 1 {
+			continue
+		}
+		var timestamp float64
+		switch e := event.Event.(type) {
+		case *proto.CombatLogEvent_CastComplete:
+			timestamp = event.Timestamp
+		case *proto.CombatLogEvent_Damage:
+			if e.Damage.Amount == 0 {
+				continue
+			}
+			timestamp = event.Timestamp
+			addHits++
+		default:
+			continue
+		}
+		if timestamp < 10 || timestamp > 30 {
+			t.Fatalf("Expected the add to only be cast at and hit while present, got an event at %0.2fs", timestamp)
+		}
+	}
+	if addHits == 0 {
+		t.Fatalf("Expected the add to be hit while present")
+	}
+}
@@ -0,0 +1,133 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func downtimeTestRequest(t *testing.T, apl string, downtime *proto.TargetDowntime) *proto.RaidSimRequest {
+	request := combatLogTestRequest(1, 1)
+	request.Encounter.Downtimes = []*proto.TargetDowntime{downtime}
+	if apl != "" {
+		rotation, err := apltext.Parse(apl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		request.Raid.Parties[0].Players[0].Rotation = rotation
+	}
+	return request
+}
+
+func castTimes(result *proto.RaidSimResult, spellID int32) []float64 {
+	var times []float64
+	for _, event := range result.CombatLog {
+		if _, ok := event.Event.(*proto.CombatLogEvent_CastComplete); ok && event.ActionId.GetSpellId() == spellID {
+			times = append(times, event.Timestamp)
+		}
+	}
+	return times
+}
+
+func TestEncounterDowntime(t *testing.T) {
+	result := core.RunRaidSim(downtimeTestRequest(t, "", &proto.TargetDowntime{Start: 20, Duration: 10}))
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	for _, event := range result.CombatLog {
+		if hit, ok := event.Event.(*proto.CombatLogEvent_Damage); ok && event.Target.GetType() == proto.UnitReference_Target {
+			if event.Timestamp > 20 && event.Timestamp < 30 && (hit.Damage.Amount > 0 || hit.Damage.Outcome == proto.HitOutcome_HitOutcomeMiss) {
+				t.Fatalf("Expected no damage or misses on the target during downtime, got %v", event)
+			}
+		}
+	}
+
+	var unavailable *proto.AuraMetrics
+	for _, aura := range result.EncounterMetrics.Targets[0].Auras {
+		if aura.Id.GetOtherId() == proto.OtherAction_OtherActionTargetUnavailable {
+			unavailable = aura
+		}
+	}
+	if unavailable == nil || math.Abs(unavailable.UptimeSecondsAvg-10) > 1e-6 {
+		t.Fatalf("Expected the target to be unavailable for 10 seconds, got %v", unavailable)
+	}
+}
+
+// Corruption is recast as soon as it falls off, and nothing is cast while the target is unavailable.
+const corruptionAPL = `
+cast_spell(spell(25311, rank=7)) if target_is_available and not dot_is_active(spell(25311, rank=7))
+cast_spell(spell(25307, rank=9)) if target_is_available
+`
+
+func TestEncounterDowntimeDots(t *testing.T) {
+	// Dots pause during the downtime, so Corruption lasts 10 seconds longer.
+	paused := core.RunRaidSim(downtimeTestRequest(t, corruptionAPL, &proto.TargetDowntime{Start: 5, Duration: 10}))
+	if paused.Error != nil {
+		t.Fatalf("Sim failed: %s", paused.Error.Message)
+	}
+	if times := castTimes(paused, 25311); len(times) < 2 || times[1] < 28 {
+		t.Fatalf("Expected Corruption to be recast after it resumed, got casts at %v", times)
+	}
+	for _, event := range paused.CombatLog {
+		if _, ok := event.Event.(*proto.CombatLogEvent_Damage); ok && event.ActionId.GetSpellId() == 25311 && event.Timestamp > 5 && event.Timestamp < 15 {
+			t.Fatalf("Expected Corruption not to tick during downtime, got a tick at %0.2fs", event.Timestamp)
+		}
+	}
+
+	// Dropped dots are recast once the target is back.
+	dropped := core.RunRaidSim(downtimeTestRequest(t, corruptionAPL, &proto.TargetDowntime{Start: 5, Duration: 10, DropDots: true}))
+	if dropped.Error != nil {
+		t.Fatalf("Sim failed: %s", dropped.Error.Message)
+	}
+	if times := castTimes(dropped, 25311); len(times) < 2 || times[1] < 15 || times[1] > 17 {
+		t.Fatalf("Expected Corruption to be recast when the downtime ended, got casts at %v", times)
+	}
+}
+
+func TestEncounterOverlappingDowntimeDots(t *testing.T) {
+	// The windows overlap for 5 seconds, so Corruption is paused for 15 seconds in total.
+	request := downtimeTestRequest(t, corruptionAPL, &proto.TargetDowntime{Start: 5, Duration: 10})
+	request.Encounter.Downtimes = append(request.Encounter.Downtimes, &proto.TargetDowntime{Start: 10, Duration: 10})
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	times := castTimes(result, 25311)
+	if len(times) < 2 {
+		t.Fatalf("Expected Corruption to be recast after it resumed, got casts at %v", times)
+	}
+	lastTick := 0.0
+	for _, event := range result.CombatLog {
+		if _, ok := event.Event.(*proto.CombatLogEvent_Damage); ok && event.ActionId.GetSpellId() == 25311 && event.Timestamp < times[1] {
+			lastTick = event.Timestamp
+		}
+	}
+	if expected := times[0] + 18 + 15; math.Abs(lastTick-expected) > 0.01 {
+		t.Fatalf("Expected the last Corruption tick at %0.2fs, got %0.2fs", expected, lastTick)
+	}
+}
+
+func TestTargetAvailableAPLValues(t *testing.T) {
+	result := core.RunRaidSim(downtimeTestRequest(t, `
+cast_spell(spell(11689, rank=6)) if not target_is_available and time_until_target_available > 5s
+cast_spell(spell(25307, rank=9)) if target_is_available
+`, &proto.TargetDowntime{Start: 20, Duration: 10}))
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	times := castTimes(result, 11689)
+	if len(times) == 0 {
+		t.Fatalf("Expected Life Tap to be cast during downtime")
+	}
+	for _, time := range times {
+		if time < 20 || time >= 25 {
+			t.Fatalf("Expected Life Tap to only be cast in the first half of the downtime, got a cast at %0.2fs", time)
+		}
+	}
+}
@@ -0,0 +1,198 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+// Shadow Bolt only, which has a 30 yard range.
+func positionTestRequest(t *testing.T, target *proto.Target, playerPosition *proto.Vector2) *proto.RaidSimRequest {
+	rotation, err := apltext.Parse(`cast_spell(spell(25307, rank=9))`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := combatLogTestRequest(1, 1)
+	request.Encounter.Targets = []*proto.Target{target}
+	request.Encounter.RangeChecks = true
+	request.Raid.Parties[0].Players[0].Rotation = rotation
+	request.Raid.Parties[0].Players[0].Position = playerPosition
+	return request
+}
+
+// A warrior standing on the first target, with auto attacks and the given rotation.
+func meleeTestPlayer(rotation *proto.APLRotation) *proto.Player {
+	return &proto.Player{
+		Name:      "Player",
+		Race:      proto.Race_RaceOrc,
+		Class:     proto.Class_ClassWarrior,
+		Equipment: core.GetGearSet("../ui/warrior/gear_sets", "phase_1").GearSet,
+		Rotation:  rotation,
+		Spec:      &proto.Player_Warrior{Warrior: &proto.Warrior{Options: &proto.Warrior_Options{}}},
+		Position:  &proto.Vector2{},
+	}
+}
+
+func playerSwingTimes(result *proto.RaidSimResult) []float64 {
+	var times []float64
+	for _, event := range result.CombatLog {
+		if hit, ok := event.Event.(*proto.CombatLogEvent_Damage); ok && hit.Damage.Swing && event.Source.GetType() == proto.UnitReference_Player {
+			times = append(times, event.Timestamp)
+		}
+	}
+	return times
+}
+
+func TestSpellRange(t *testing.T) {
+	target := googleProto.Clone(StandardTarget).(*proto.Target)
+	target.Position = &proto.Vector2{X: 100, Y: 100}
+
+	inRange := core.RunRaidSim(positionTestRequest(t, target, &proto.Vector2{X: 100, Y: 125}))
+	if inRange.Error != nil {
+		t.Fatalf("Sim failed: %s", inRange.Error.Message)
+	}
+	if len(castTimes(inRange, 25307)) == 0 {
+		t.Fatalf("Expected Shadow Bolt to be cast from 25 yards")
+	}
+
+	outOfRange := core.RunRaidSim(positionTestRequest(t, target, &proto.Vector2{X: 100, Y: 135}))
+	if outOfRange.Error != nil {
+		t.Fatalf("Sim failed: %s", outOfRange.Error.Message)
+	}
+	if times := castTimes(outOfRange, 25307); len(times) != 0 {
+		t.Fatalf("Expected Shadow Bolt not to be cast from 35 yards, got casts at %v", times)
+	}
+
+	// Without range checks, only the distance matters, e.g. for travel times.
+	request := positionTestRequest(t, target, &proto.Vector2{X: 100, Y: 135})
+	request.Encounter.RangeChecks = false
+	unchecked := core.RunRaidSim(request)
+	if unchecked.Error != nil {
+		t.Fatalf("Sim failed: %s", unchecked.Error.Message)
+	}
+	if len(castTimes(unchecked, 25307)) == 0 {
+		t.Fatalf("Expected Shadow Bolt to be cast from 35 yards without range checks")
+	}
+}
+
+func TestEncounterScriptMovesRaid(t *testing.T) {
+	boss := googleProto.Clone(StandardTarget).(*proto.Target)
+	boss.Script = &proto.EncounterScript{
+		Events: []*proto.EncounterEvent{
+			{
+				Trigger: &proto.EncounterEvent_AtTime{AtTime: 10},
+				Actions: []*proto.EncounterAction{{Action: &proto.EncounterAction_MoveRaid{MoveRaid: &proto.EncounterMove{
+					Position: &proto.Vector2{X: 40},
+				}}}},
+			},
+			{
+				Trigger: &proto.EncounterEvent_AtTime{AtTime: 30},
+				Actions: []*proto.EncounterAction{{Action: &proto.EncounterAction_MoveRaid{MoveRaid: &proto.EncounterMove{
+					Position: &proto.Vector2{X: -40},
+					Relative: true,
+				}}}},
+			},
+		},
+	}
+	result := core.RunRaidSim(positionTestRequest(t, boss, nil))
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	// The player moves 40 yards out after their cast, and back once they get there.
+	times := castTimes(result, 25307)
+	resumed := false
+	for _, time := range times {
+		if time > 20 && time < 30+40/7.0 {
+			t.Fatalf("Expected Shadow Bolt not to be cast while moving or out of range, got a cast at %0.2fs", time)
+		}
+		resumed = resumed || time > 30
+	}
+	if !resumed {
+		t.Fatalf("Expected Shadow Bolt to be cast again after moving back, got casts at %v", times)
+	}
+
+	var movement *proto.AuraMetrics
+	for _, aura := range result.RaidMetrics.Parties[0].Players[0].Auras {
+		if aura.Id.GetOtherId() == proto.OtherAction_OtherActionMove {
+			movement = aura
+		}
+	}
+	// Minor Speed on the boots makes the player 8% faster.
+	expectedUptime := 80 / (7 * 1.08)
+	if movement == nil || math.Abs(movement.UptimeSecondsAvg-expectedUptime) > 0.1 {
+		t.Fatalf("Expected the player to move for %0.2f seconds, got %v", expectedUptime, movement)
+	}
+}
+
+func TestEncounterScriptMovesMeleeOutOfRange(t *testing.T) {
+	boss := googleProto.Clone(StandardTarget).(*proto.Target)
+	boss.Script = &proto.EncounterScript{
+		Events: []*proto.EncounterEvent{
+			{
+				Trigger: &proto.EncounterEvent_AtTime{AtTime: 10},
+				Actions: []*proto.EncounterAction{{Action: &proto.EncounterAction_MoveRaid{MoveRaid: &proto.EncounterMove{
+					Position: &proto.Vector2{X: 20},
+				}}}},
+			},
+			{
+				Trigger: &proto.EncounterEvent_AtTime{AtTime: 30},
+				Actions: []*proto.EncounterAction{{Action: &proto.EncounterAction_MoveRaid{MoveRaid: &proto.EncounterMove{
+					Position: &proto.Vector2{},
+				}}}},
+			},
+		},
+	}
+	request := positionTestRequest(t, boss, &proto.Vector2{})
+	request.Raid.Parties[0].Players[0] = meleeTestPlayer(&proto.APLRotation{})
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	// Swings stop once the player leaves melee range, and start again once they are back.
+	resumed := false
+	for _, time := range playerSwingTimes(result) {
+		if time > 10 && time < 30 {
+			t.Fatalf("Expected no swings while out of melee range, got a swing at %0.2fs", time)
+		}
+		resumed = resumed || time > 30
+	}
+	if !resumed {
+		t.Fatalf("Expected swings to start again after moving back")
+	}
+}
+
+func TestChangeTargetOutOfMeleeRange(t *testing.T) {
+	add := googleProto.Clone(StandardTarget).(*proto.Target)
+	add.Spawn = &proto.AddSpawn{AtTimes: []float64{0}, Duration: 15}
+	add.Position = &proto.Vector2{X: 20}
+	// Switches to the add for a while, then back to the boss once the add despawns.
+	rotation, err := apltext.Parse(`change_target(target(1)) if current_time >= 5s and current_time < 10s`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := positionTestRequest(t, StandardTarget, &proto.Vector2{})
+	request.Encounter.Targets = append(request.Encounter.Targets, add)
+	request.Raid.Parties[0].Players[0] = meleeTestPlayer(rotation)
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	// The add is out of melee range, so there are no swings until the player is back on the boss.
+	resumed := false
+	for _, time := range playerSwingTimes(result) {
+		if time > 5 && time < 15 {
+			t.Fatalf("Expected no swings while the target is out of melee range, got a swing at %0.2fs", time)
+		}
+		resumed = resumed || time > 15
+	}
+	if !resumed {
+		t.Fatalf("Expected swings to start again after changing back to the boss")
+	}
+}
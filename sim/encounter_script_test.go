@@ -0,0 +1,210 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wowsims/classic/sim/apltext"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/stats"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+var scriptedTarget = &proto.Target{
+	Stats:   stats.Stats{stats.Armor: 7684}.ToFloatArray(),
+	MobType: proto.MobType_MobTypeDemon,
+	Script: &proto.EncounterScript{
+		Spells: []*proto.EncounterSpell{
+			{
+				Name:      "Volley",
+				SpellId:   1,
+				School:    proto.SpellSchool_SpellSchoolShadow,
+				Targets:   proto.EncounterSpellTargets_EncounterSpellTargetsAllPlayers,
+				MinDamage: 100,
+				MaxDamage: 200,
+				CastTime:  2,
+				Aura:      "Weakness",
+			},
+			{
+				Name:    "Enrage",
+				SpellId: 2,
+				Targets: proto.EncounterSpellTargets_EncounterSpellTargetsSelf,
+				Aura:    "Enrage",
+			},
+		},
+		Auras: []*proto.EncounterAura{
+			{
+				Name:                  "Weakness",
+				SpellId:               3,
+				Duration:              5,
+				DamageDealtMultiplier: 0.5,
+			},
+			{
+				Name:                  "Enrage",
+				SpellId:               4,
+				DamageTakenMultiplier: 0.5,
+			},
+		},
+		Events: []*proto.EncounterEvent{
+			{
+				Trigger:        &proto.EncounterEvent_AtTime{AtTime: 5},
+				RepeatInterval: 10,
+				Phase:          1,
+				Actions:        []*proto.EncounterAction{{Action: &proto.EncounterAction_CastSpell{CastSpell: "Volley"}}},
+			},
+			{
+				Trigger: &proto.EncounterEvent_AtHealthPercent{AtHealthPercent: 50},
+				Actions: []*proto.EncounterAction{{Action: &proto.EncounterAction_SetPhase{SetPhase: 2}}},
+			},
+			{
+				Trigger: &proto.EncounterEvent_AtTime{AtTime: 0},
+				Phase:   2,
+				Actions: []*proto.EncounterAction{{Action: &proto.EncounterAction_CastSpell{CastSpell: "Enrage"}}},
+			},
+		},
+		Phases: []*proto.EncounterPhase{
+			{Phase: 2, DamageTakenMultiplier: 0.5},
+		},
+	},
+}
+
+func findAction(metrics *proto.UnitMetrics, spellID int32) *proto.ActionMetrics {
+	for _, action := range metrics.Actions {
+		if action.Id.GetSpellId() == spellID {
+			return action
+		}
+	}
+	return nil
+}
+
+func totalCasts(action *proto.ActionMetrics) int32 {
+	casts := int32(0)
+	for _, tam := range action.Targets {
+		casts += tam.Casts
+	}
+	return casts
+}
+
+func findAura(metrics *proto.UnitMetrics, spellID int32) *proto.AuraMetrics {
+	for _, aura := range metrics.Auras {
+		if aura.Id.GetSpellId() == spellID {
+			return aura
+		}
+	}
+	return nil
+}
+
+func findPhaseAura(metrics *proto.UnitMetrics, phase int32) *proto.AuraMetrics {
+	for _, aura := range metrics.Auras {
+		if aura.Id.GetOtherId() == proto.OtherAction_OtherActionEncounterPhase && aura.Id.Tag == phase {
+			return aura
+		}
+	}
+	return nil
+}
+
+func TestEncounterScript(t *testing.T) {
+	const iterations = 10
+	baseResult := core.RunRaidSim(combatLogTestRequest(iterations, 0))
+	request := combatLogTestRequest(iterations, 0)
+	request.Encounter.Targets = []*proto.Target{scriptedTarget}
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	target := result.EncounterMetrics.Targets[0]
+	player := result.RaidMetrics.Parties[0].Players[0]
+
+	// Volleys at 5, 15 and 25 seconds, then the boss changes to phase 2 at 50% and enrages.
+	volley := findAction(target, 1)
+	if volley == nil {
+		t.Fatalf("Expected the boss to cast Volley")
+	}
+	if casts := totalCasts(volley); casts != 3*iterations {
+		t.Fatalf("Expected 3 Volleys per iteration, got %d", casts)
+	}
+	if enrage := findAction(target, 2); enrage == nil || totalCasts(enrage) != iterations {
+		t.Fatalf("Expected one Enrage per iteration, got %v", enrage)
+	}
+	if aura := findAura(target, 4); aura == nil || math.Abs(aura.UptimeSecondsAvg-30) > 1e-6 {
+		t.Fatalf("Expected Enrage to be up for the second half of the fight, got %v", aura)
+	}
+	if aura := findPhaseAura(target, 2); aura == nil || math.Abs(aura.UptimeSecondsAvg-30) > 1e-6 {
+		t.Fatalf("Expected phase 2 to last for the second half of the fight, got %v", aura)
+	}
+	// Volley's cast time delays each Weakness by 2 seconds.
+	if aura := findAura(player, 3); aura == nil || aura.UptimeSecondsAvg <= 0 || aura.UptimeSecondsAvg > 15 {
+		t.Fatalf("Expected Weakness to be up for at most 15 seconds, got %v", aura)
+	}
+	if result.RaidMetrics.Dps.Avg >= baseResult.RaidMetrics.Dps.Avg {
+		t.Fatalf("Expected the script to lower DPS, got %0.1f vs %0.1f", result.RaidMetrics.Dps.Avg, baseResult.RaidMetrics.Dps.Avg)
+	}
+}
+
+func TestEncounterScriptRepeatsInAnyPhase(t *testing.T) {
+	const iterations = 10
+	target := googleProto.Clone(scriptedTarget).(*proto.Target)
+	target.Script.Events[2] = &proto.EncounterEvent{
+		Trigger:        &proto.EncounterEvent_AtTime{AtTime: 10},
+		RepeatInterval: 20,
+		Actions:        []*proto.EncounterAction{{Action: &proto.EncounterAction_CastSpell{CastSpell: "Enrage"}}},
+	}
+	request := combatLogTestRequest(iterations, 0)
+	request.Encounter.Targets = []*proto.Target{target}
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	// Enrages at 10, 30 and 50 seconds keep going after the change to phase 2 at 30 seconds.
+	if enrage := findAction(result.EncounterMetrics.Targets[0], 2); enrage == nil || totalCasts(enrage) != 3*iterations {
+		t.Fatalf("Expected 3 Enrages per iteration, got %v", enrage)
+	}
+}
+
+func TestEncounterScriptHealthFight(t *testing.T) {
+	request := combatLogTestRequest(10, 0)
+	target := googleProto.Clone(scriptedTarget).(*proto.Target)
+	target.Stats[stats.Health] = 50_000
+	request.Encounter.Targets = []*proto.Target{target}
+	request.Encounter.UseHealth = true
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	// Health triggers fire once the boss has taken half its health in damage.
+	aura := findPhaseAura(result.EncounterMetrics.Targets[0], 2)
+	if aura == nil || aura.UptimeSecondsAvg <= 0 || aura.UptimeSecondsAvg >= result.AvgIterationDuration {
+		t.Fatalf("Expected phase 2 to last for part of the fight, got %v", aura)
+	}
+}
+
+func TestTargetPhaseAPLValue(t *testing.T) {
+	rotation, err := apltext.Parse(`cast_spell(spell(25307, rank=9)) if target_phase == 2`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := combatLogTestRequest(1, 1)
+	request.Encounter.Targets = []*proto.Target{scriptedTarget}
+	request.Raid.Parties[0].Players[0].Rotation = rotation
+	result := core.RunRaidSim(request)
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+
+	numCasts := 0
+	for _, event := range result.CombatLog {
+		if _, ok := event.Event.(*proto.CombatLogEvent_CastStart); ok && event.ActionId.GetSpellId() == 25307 {
+			numCasts++
+			if event.Timestamp < 30 {
+				t.Fatalf("Expected Shadow Bolt to only be cast in phase 2, got a cast at %0.2fs", event.Timestamp)
+			}
+		}
+	}
+	if numCasts == 0 {
+		t.Fatalf("Expected Shadow Bolt to be cast in phase 2")
+	}
+}
@@ -0,0 +1,64 @@
+package sim
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func TestGearOptimizer(t *testing.T) {
+	request := combatLogTestRequest(200, 0)
+	request.Raid.Parties[0].Players[0].Equipment = core.GetGearSet("../ui/warlock/gear_sets", "prebis").GearSet
+	result := core.OptimizeGear(&proto.GearOptimizerRequest{
+		BaseSettings: request,
+		Items: []*proto.ItemSpec{
+			{Id: 18820}, // Talisman of Ephemeral Power
+			{Id: 19147}, // Ring of Spell Power
+			{Id: 18842}, // Staff of Dominance
+		},
+		Enchants: []*proto.GearOptimizerEnchants{
+			{Slot: proto.ItemSlot_ItemSlotChest, EnchantIds: []int32{1891}}, // Greater Stats
+		},
+		BeamWidth:  2,
+		TopK:       3,
+		Iterations: 200,
+	})
+	if result.Error != nil {
+		t.Fatalf("Gear optimizer failed: %s", result.Error.Message)
+	}
+	if len(result.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(result.Results))
+	}
+
+	equippedDps := result.EquippedGearResult.UnitMetrics.Dps
+	if len(equippedDps.AllValues) != 0 {
+		t.Fatalf("Expected per-iteration values to be cleared")
+	}
+	if result.Results[0].UnitMetrics.Dps.Avg < equippedDps.Avg {
+		t.Fatalf("Best set %0.2f DPS is worse than the equipped gear %0.2f", result.Results[0].UnitMetrics.Dps.Avg, equippedDps.Avg)
+	}
+
+	for i, set := range result.Results {
+		if i > 0 && set.UnitMetrics.Dps.Avg > result.Results[i-1].UnitMetrics.Dps.Avg {
+			t.Fatalf("Results aren't sorted by DPS")
+		}
+		items := set.Equipment.Items
+		mainHand := core.ItemsByID[items[proto.ItemSlot_ItemSlotMainHand].Id]
+		if mainHand.HandType == proto.HandType_HandTypeTwoHand && items[proto.ItemSlot_ItemSlotOffHand].Id != 0 {
+			t.Fatalf("Set #%d has an off hand with a two-hander", i+1)
+		}
+		if items[proto.ItemSlot_ItemSlotFinger1].Id == items[proto.ItemSlot_ItemSlotFinger2].Id {
+			t.Fatalf("Set #%d has the same ring twice", i+1)
+		}
+
+		delta := set.DpsDelta
+		if delta == nil || delta.Iterations != 200 {
+			t.Fatalf("Expected a paired delta over 200 iterations, got %v", delta)
+		}
+		if math.Abs(delta.Mean-(set.UnitMetrics.Dps.Avg-equippedDps.Avg)) > 1e-6 {
+			t.Fatalf("Paired delta %f doesn't match the difference in averages %f", delta.Mean, set.UnitMetrics.Dps.Avg-equippedDps.Avg)
+		}
+	}
+}
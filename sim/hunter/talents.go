@@ -25,7 +25,7 @@ func (hunter *Hunter) ApplyTalents() {
 
 	if hunter.Talents.MonsterSlaying+hunter.Talents.HumanoidSlaying > 0 {
 		hunter.Env.RegisterPostFinalizeEffect(func() {
-			for _, t := range hunter.Env.Encounter.Targets {
+			for _, t := range hunter.Env.Encounter.AllTargets {
 				switch t.MobType {
 				case proto.MobType_MobTypeHumanoid:
 					multiplier := []float64{1, 1.01, 1.02, 1.03}[hunter.Talents.HumanoidSlaying]
@@ -91,6 +91,7 @@ func (hunter *Hunter) ApplyTalents() {
 	hunter.applyEfficiency()
 	hunter.applyTrapMastery()
 	hunter.applyCleverTraps()
+	hunter.applyHawkEye()
 }
 
 func (hunter *Hunter) applyFrenzy() {
@@ -196,6 +197,18 @@ func (hunter *Hunter) applyCleverTraps() {
 	})
 }
 
+func (hunter *Hunter) applyHawkEye() {
+	if hunter.Talents.HawkEye == 0 {
+		return
+	}
+
+	hunter.OnSpellRegistered(func(spell *core.Spell) {
+		if spell.Flags.Matches(SpellFlagSting|SpellFlagShot) && spell.MaxRange > 0 {
+			spell.MaxRange += 2 * float64(hunter.Talents.HawkEye)
+		}
+	})
+}
+
 func (hunter *Hunter) applyEfficiency() {
 	hunter.OnSpellRegistered(func(spell *core.Spell) {
 		// applies to Stings, Shots, and Volley
@@ -58,6 +58,7 @@ func (mage *Mage) newBlizzardSpellConfig(rank int) core.SpellConfig {
 	}
 
 	return core.SpellConfig{
+		SpellCode:   SpellCode_MageBlizzard,
 		ActionID:    core.ActionID{SpellID: spellId},
 		SpellSchool: core.SpellSchoolFrost,
 		ProcMask:    core.ProcMaskSpellDamage,
@@ -18,6 +18,7 @@ const (
 	SpellCode_MageArcaneMissiles
 	SpellCode_MageArcaneMissilesTick
 	SpellCode_MageBlastWave
+	SpellCode_MageBlizzard
 	SpellCode_MageFireball
 	SpellCode_MageFireBlast
 	SpellCode_MageFlamestrike
@@ -103,6 +103,16 @@ func (mage *Mage) applyFireTalents() {
 			}
 		})
 	}
+
+	// Flame Throwing
+	if mage.Talents.FlameThrowing > 0 {
+		bonusRange := 3 * float64(mage.Talents.FlameThrowing)
+		mage.OnSpellRegistered(func(spell *core.Spell) {
+			if spell.SpellSchool.Matches(core.SpellSchoolFire) && spell.Flags.Matches(SpellFlagMage) && spell.MaxRange > 0 {
+				spell.MaxRange += bonusRange
+			}
+		})
+	}
 }
 
 func (mage *Mage) applyFrostTalents() {
@@ -143,6 +153,16 @@ func (mage *Mage) applyFrostTalents() {
 		})
 	}
 
+	// Arctic Reach
+	if mage.Talents.ArcticReach > 0 {
+		rangeMultiplier := 1 + .10*float64(mage.Talents.ArcticReach)
+		mage.OnSpellRegistered(func(spell *core.Spell) {
+			if spell.SpellCode == SpellCode_MageFrostbolt || spell.SpellCode == SpellCode_MageBlizzard {
+				spell.MaxRange *= rangeMultiplier
+			}
+		})
+	}
+
 	// Frost Channeling
 	if mage.Talents.FrostChanneling > 0 {
 		manaCostMultiplier := 5 * mage.Talents.FrostChanneling
@@ -32,6 +32,7 @@ func (priest *Priest) ApplyTalents() {
 	priest.applyInspiration()
 	priest.applyHolySpecialization()
 	priest.applySearingLight()
+	priest.applyHolyReach()
 
 	priest.PseudoStats.SchoolDamageTakenMultiplier.MultiplyMagicSchools(1 - 0.02*float64(priest.Talents.SpellWarding))
 
@@ -45,6 +46,7 @@ func (priest *Priest) ApplyTalents() {
 	priest.applySpiritTap()
 	priest.applyShadowAffinity()
 	priest.applyShadowFocus()
+	priest.applyShadowReach()
 	priest.applyShadowWeaving()
 	priest.applyDarkness()
 }
@@ -125,6 +127,18 @@ func (priest *Priest) applySearingLight() {
 	})
 }
 
+func (priest *Priest) applyHolyReach() {
+	if priest.Talents.HolyReach == 0 {
+		return
+	}
+
+	priest.OnSpellRegistered(func(spell *core.Spell) {
+		if spell.SpellCode == SpellCode_PriestSmite || spell.SpellCode == SpellCode_PriestHolyFire {
+			spell.MaxRange *= 1 + 0.10*float64(priest.Talents.HolyReach)
+		}
+	})
+}
+
 func (priest *Priest) applySpiritTap() {
 	if priest.Talents.SpiritTap == 0 {
 		return
@@ -173,6 +187,19 @@ func (priest *Priest) applyShadowFocus() {
 	})
 }
 
+func (priest *Priest) applyShadowReach() {
+	if priest.Talents.ShadowReach == 0 {
+		return
+	}
+
+	multiplier := []float64{1, 1.06, 1.13, 1.20}[priest.Talents.ShadowReach]
+	priest.OnSpellRegistered(func(spell *core.Spell) {
+		if spell.Flags.Matches(SpellFlagPriest) && spell.SpellSchool.Matches(core.SpellSchoolShadow) && spell.ProcMask.Matches(core.ProcMaskSpellDamage) {
+			spell.MaxRange *= multiplier
+		}
+	})
+}
+
 func (priest *Priest) applyShadowWeaving() {
 	if priest.Talents.ShadowWeaving == 0 {
 		return
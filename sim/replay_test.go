@@ -0,0 +1,43 @@
+package sim
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+)
+
+func TestReplaySeed(t *testing.T) {
+	result := core.RunRaidSim(combatLogTestRequest(50, 0))
+	if result.Error != nil {
+		t.Fatalf("Sim failed: %s", result.Error.Message)
+	}
+	dps := result.RaidMetrics.Dps
+
+	for _, tc := range []struct {
+		name string
+		seed int64
+		dps  float64
+	}{
+		{name: "min", seed: dps.MinSeed, dps: dps.Min},
+		{name: "max", seed: dps.MaxSeed, dps: dps.Max},
+	} {
+		request := combatLogTestRequest(50, 0)
+		request.SimOptions.ReplaySeed = tc.seed
+		replay := core.RunRaidSimConcurrent(request)
+		if replay.Error != nil {
+			t.Fatalf("Replay failed: %s", replay.Error.Message)
+		}
+
+		if replay.IterationsDone != 1 {
+			t.Fatalf("Expected 1 replayed iteration, got %d", replay.IterationsDone)
+		}
+		if math.Abs(replay.RaidMetrics.Dps.Avg-tc.dps) > 1e-6 {
+			t.Fatalf("Replaying the %s seed %d gave %f DPS, expected %f", tc.name, tc.seed, replay.RaidMetrics.Dps.Avg, tc.dps)
+		}
+		if !strings.Contains(replay.Logs, "Casting") || len(replay.CombatLog) == 0 {
+			t.Fatalf("Expected debug logs and a combat log for the replayed iteration")
+		}
+	}
+}
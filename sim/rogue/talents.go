@@ -65,7 +65,7 @@ func (rogue *Rogue) applyMurder() {
 
 	// post finalize, since attack tables need to be setup
 	rogue.Env.RegisterPostFinalizeEffect(func() {
-		for _, t := range rogue.Env.Encounter.Targets {
+		for _, t := range rogue.Env.Encounter.AllTargets {
 			switch t.MobType {
 			case proto.MobType_MobTypeHumanoid, proto.MobType_MobTypeGiant, proto.MobType_MobTypeBeast, proto.MobType_MobTypeDragonkin:
 				multiplier := []float64{1, 1.01, 1.02}[rogue.Talents.Murder]
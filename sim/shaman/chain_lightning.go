@@ -58,10 +58,11 @@ func (shaman *Shaman) newChainLightningSpellConfig(rank int, cdTimer *core.Timer
 		Duration: cooldown,
 	}
 
-	results := make([]*core.SpellResult, min(targetCount, shaman.Env.GetNumTargets()))
+	results := make([]*core.SpellResult, min(targetCount, shaman.Env.GetMaxNumTargets()))
 
 	spell.ApplyEffects = func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 		origMult := spell.DamageMultiplier
+		results := results[:min(len(results), int(sim.GetNumTargets()))]
 		for hitIndex := range results {
 			baseDamage := sim.Roll(baseDamageLow, baseDamageHigh)
 			results[hitIndex] = spell.CalcDamage(sim, target, baseDamage, spell.OutcomeMagicHitAndCrit)
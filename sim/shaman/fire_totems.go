@@ -7,6 +7,13 @@ import (
 	"github.com/wowsims/classic/sim/core"
 )
 
+// Distances from the totem, in yards.
+const (
+	SearingTotemRange   = 20
+	MagmaTotemRadius    = 8
+	FireNovaTotemRadius = 10
+)
+
 const SearingTotemRanks = 6
 
 var SearingTotemSpellId = [SearingTotemRanks + 1]int32{0, 3599, 6363, 6364, 6365, 10437, 10438}
@@ -95,7 +102,9 @@ func (shaman *Shaman) newSearingTotemSpellConfig(rank int) core.SpellConfig {
 			NumberOfTicks: int32(duration / attackInterval),
 			TickLength:    attackInterval,
 			OnTick: func(sim *core.Simulation, target *core.Unit, dot *core.Dot) {
-				attackSpell.Cast(sim, target)
+				if sim.Encounter.IsWithinRange(target.Position, shaman.FireTotemPosition, SearingTotemRange) {
+					attackSpell.Cast(sim, target)
+				}
 			},
 		},
 
@@ -103,6 +112,7 @@ func (shaman *Shaman) newSearingTotemSpellConfig(rank int) core.SpellConfig {
 			if shaman.ActiveTotems[FireTotem] != nil {
 				shaman.ActiveTotems[FireTotem].Dot(sim.GetTargetUnit(0)).Cancel(sim)
 			}
+			shaman.FireTotemPosition = shaman.Position
 			spell.Dot(sim.GetTargetUnit(0)).Apply(sim)
 			// +1 needed because of rounding issues with totem tick time.
 			shaman.TotemExpirations[FireTotem] = sim.CurrentTime + duration + 1
@@ -161,7 +171,9 @@ func (shaman *Shaman) newMagmaTotemSpellConfig(rank int) core.SpellConfig {
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
 			for _, aoeTarget := range sim.Encounter.TargetUnits {
-				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
+				if sim.Encounter.IsWithinRange(aoeTarget.Position, shaman.FireTotemPosition, MagmaTotemRadius) {
+					spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
+				}
 			}
 		},
 	})
@@ -205,6 +217,7 @@ func (shaman *Shaman) newMagmaTotemSpellConfig(rank int) core.SpellConfig {
 			if shaman.ActiveTotems[FireTotem] != nil {
 				shaman.ActiveTotems[FireTotem].Dot(sim.GetTargetUnit(0)).Cancel(sim)
 			}
+			shaman.FireTotemPosition = shaman.Position
 			spell.Dot(sim.GetTargetUnit(0)).Apply(sim)
 			// +1 needed because of rounding issues with totem tick time.
 			shaman.TotemExpirations[FireTotem] = sim.CurrentTime + duration + 1
@@ -266,7 +279,9 @@ func (shaman *Shaman) newFireNovaTotemSpellConfig(rank int) core.SpellConfig {
 		ApplyEffects: func(sim *core.Simulation, _ *core.Unit, spell *core.Spell) {
 			baseDamage := sim.Roll(baseDamageLow, baseDamageHigh)
 			for _, aoeTarget := range sim.Encounter.TargetUnits {
-				spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
+				if sim.Encounter.IsWithinRange(aoeTarget.Position, shaman.FireTotemPosition, FireNovaTotemRadius) {
+					spell.CalcAndDealDamage(sim, aoeTarget, baseDamage, spell.OutcomeMagicHitAndCrit)
+				}
 			}
 		},
 	})
@@ -314,6 +329,7 @@ func (shaman *Shaman) newFireNovaTotemSpellConfig(rank int) core.SpellConfig {
 			if shaman.ActiveTotems[FireTotem] != nil {
 				shaman.ActiveTotems[FireTotem].Dot(sim.GetTargetUnit(0)).Cancel(sim)
 			}
+			shaman.FireTotemPosition = shaman.Position
 			spell.Dot(sim.GetTargetUnit(0)).Apply(sim)
 			// +1 needed because of rounding issues with totem tick time.
 			shaman.TotemExpirations[FireTotem] = sim.CurrentTime + duration + 1
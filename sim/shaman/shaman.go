@@ -117,9 +117,10 @@ type Shaman struct {
 	LightningShieldAuras []*core.Aura
 
 	// Totems
-	ActiveTotems     [4]*core.Spell
-	ActiveTotemBuffs [4]*core.Aura
-	TotemExpirations [4]time.Duration // The expiration time of each totem (earth, air, fire, water).
+	ActiveTotems      [4]*core.Spell
+	ActiveTotemBuffs  [4]*core.Aura
+	TotemExpirations  [4]time.Duration // The expiration time of each totem (earth, air, fire, water).
+	FireTotemPosition core.Vector2     // Where the active fire totem was dropped, for its range.
 
 	EarthTotems []*core.Spell
 	FireTotems  []*core.Spell
@@ -16,6 +16,7 @@ func (shaman *Shaman) ApplyTalents() {
 	shaman.applyElementalDevastation()
 	shaman.applyImprovedFireTotems()
 	shaman.applyElementalFury()
+	shaman.applyStormReach()
 	shaman.registerElementalMasteryCD()
 
 	// Enhancement Talents
@@ -211,6 +212,18 @@ func (shaman *Shaman) applyImprovedFireTotems() {
 	})
 }
 
+func (shaman *Shaman) applyStormReach() {
+	if shaman.Talents.StormReach == 0 {
+		return
+	}
+
+	shaman.OnSpellRegistered(func(spell *core.Spell) {
+		if spell.SpellCode == SpellCode_ShamanLightningBolt || spell.SpellCode == SpellCode_ShamanChainLightning {
+			spell.MaxRange += 3 * float64(shaman.Talents.StormReach)
+		}
+	})
+}
+
 func (shaman *Shaman) applyElementalFury() {
 	if !shaman.Talents.ElementalFury {
 		return
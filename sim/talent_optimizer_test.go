@@ -0,0 +1,86 @@
+package sim
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+)
+
+func TestTalentOptimizer(t *testing.T) {
+	data, err := os.ReadFile("../ui/core/talents/trees/warlock.json")
+	if err != nil {
+		t.Fatalf("Failed to read talent trees: %s", err)
+	}
+	trees, err := core.TalentTreesFromJsonString(string(data))
+	if err != nil {
+		t.Fatalf("Failed to parse talent trees: %s", err)
+	}
+
+	request := combatLogTestRequest(100, 0)
+	request.Raid.Parties[0].Players[0].TalentsString = "25002-2050300152201-52500051020001"
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	core.OptimizeTalentsAsync(&proto.TalentOptimizerRequest{
+		BaseSettings:       request,
+		Trees:              trees,
+		CandidatesPerBuild: 5,
+		BeamWidth:          2,
+		TopK:               3,
+		Iterations:         100,
+	}, reporter, "test-talent-optimizer")
+
+	// Progress adds up over all rounds.
+	var result *proto.TalentOptimizerResult
+	var last *proto.ProgressMetrics
+	for progress := range reporter {
+		if progress.FinalTalentResult != nil {
+			result = progress.FinalTalentResult
+			break
+		}
+		if progress.CompletedIterations > progress.TotalIterations || (last != nil && (progress.CompletedIterations < last.CompletedIterations || progress.CompletedSims < last.CompletedSims)) {
+			t.Fatalf("Expected cumulative progress, got %v after %v", progress, last)
+		}
+		last = progress
+	}
+	if result.Error != nil {
+		t.Fatalf("Talent optimizer failed: %s", result.Error.Message)
+	}
+	if len(result.Results) != 3 {
+		t.Fatalf("Expected 3 results, got %d", len(result.Results))
+	}
+
+	currentDps := result.CurrentTalentsResult.UnitMetrics.Dps
+	if result.CurrentTalentsResult.TalentsString != request.Raid.Parties[0].Players[0].TalentsString {
+		t.Fatalf("Expected current talents %q, got %q", request.Raid.Parties[0].Players[0].TalentsString, result.CurrentTalentsResult.TalentsString)
+	}
+	if result.Results[0].UnitMetrics.Dps.Avg < currentDps.Avg {
+		t.Fatalf("Best build %0.2f DPS is worse than the current talents %0.2f", result.Results[0].UnitMetrics.Dps.Avg, currentDps.Avg)
+	}
+
+	for i, build := range result.Results {
+		points := 0
+		for _, c := range strings.ReplaceAll(build.TalentsString, "-", "") {
+			points += int(c - '0')
+		}
+		if points > 51 {
+			t.Fatalf("Build %q spends %d points", build.TalentsString, points)
+		}
+
+		delta := build.DpsDelta
+		if delta == nil || delta.Iterations != 100 {
+			t.Fatalf("Expected a paired delta over 100 iterations, got %v", delta)
+		}
+		if math.Abs(delta.Mean-(build.UnitMetrics.Dps.Avg-currentDps.Avg)) > 1e-6 {
+			t.Fatalf("Build #%d paired delta %f doesn't match the difference in averages %f", i+1, delta.Mean, build.UnitMetrics.Dps.Avg-currentDps.Avg)
+		}
+	}
+
+	request.Raid.Parties[0].Players[0].TalentsString = "25002-205030015220x-52500051020001"
+	result = core.OptimizeTalents(&proto.TalentOptimizerRequest{BaseSettings: request, Trees: trees})
+	if result.Error == nil || !strings.Contains(result.Error.Message, "non-digit") {
+		t.Fatalf("Expected an error for a non-digit talent, got %v", result.Error)
+	}
+}
@@ -136,6 +136,7 @@ func (warlock *Warlock) registerCurseOfRecklessnessSpell() {
 		SpellSchool: core.SpellSchoolShadow,
 		ProcMask:    core.ProcMaskEmpty,
 		Flags:       core.SpellFlagAPL | WarlockFlagAffliction,
+		MaxRange:    core.DefaultSpellRange,
 		Rank:        rank,
 
 		ManaCost: core.ManaCostOptions{
@@ -198,6 +199,7 @@ func (warlock *Warlock) registerCurseOfElementsSpell() {
 		SpellSchool: core.SpellSchoolShadow,
 		ProcMask:    core.ProcMaskEmpty,
 		Flags:       core.SpellFlagAPL | WarlockFlagAffliction,
+		MaxRange:    core.DefaultSpellRange,
 		Rank:        rank,
 
 		ManaCost: core.ManaCostOptions{
@@ -257,6 +259,7 @@ func (warlock *Warlock) registerCurseOfShadowSpell() {
 		SpellSchool: core.SpellSchoolShadow,
 		ProcMask:    core.ProcMaskEmpty,
 		Flags:       core.SpellFlagAPL | WarlockFlagAffliction,
+		MaxRange:    core.DefaultSpellRange,
 		Rank:        rank,
 
 		ManaCost: core.ManaCostOptions{
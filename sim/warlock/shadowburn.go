@@ -23,6 +23,7 @@ func (warlock *Warlock) registerShadowBurnBaseConfig(rank int) core.SpellConfig
 		DefenseType:   core.DefenseTypeMagic,
 		ProcMask:      core.ProcMaskSpellDamage,
 		Flags:         core.SpellFlagAPL | core.SpellFlagResetAttackSwing | core.SpellFlagBinary | WarlockFlagDestruction,
+		MaxRange:      20,
 		RequiredLevel: level,
 		Rank:          rank,
 
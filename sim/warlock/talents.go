@@ -14,6 +14,7 @@ func (warlock *Warlock) ApplyTalents() {
 
 	// Affliction
 	warlock.applySuppression()
+	warlock.applyGrimReach()
 	warlock.applyNightfall()
 	warlock.applyShadowMastery()
 
@@ -30,6 +31,7 @@ func (warlock *Warlock) ApplyTalents() {
 	// Destruction
 	warlock.applyImprovedShadowBolt()
 	warlock.applyCataclysm()
+	warlock.applyDestructiveReach()
 	warlock.applyBane()
 	warlock.applyDevastation()
 	warlock.applyRuin()
@@ -146,6 +148,19 @@ func (warlock *Warlock) applySuppression() {
 	})
 }
 
+func (warlock *Warlock) applyGrimReach() {
+	if warlock.Talents.GrimReach == 0 {
+		return
+	}
+
+	multiplier := 1 + 0.1*float64(warlock.Talents.GrimReach)
+	warlock.OnSpellRegistered(func(spell *core.Spell) {
+		if spell.Flags.Matches(WarlockFlagAffliction) {
+			spell.MaxRange *= multiplier
+		}
+	})
+}
+
 func (warlock *Warlock) applyNightfall() {
 	if warlock.Talents.Nightfall <= 0 {
 		return
@@ -603,6 +618,19 @@ func (warlock *Warlock) applyCataclysm() {
 	})
 }
 
+func (warlock *Warlock) applyDestructiveReach() {
+	if warlock.Talents.DestructiveReach == 0 {
+		return
+	}
+
+	multiplier := 1 + 0.1*float64(warlock.Talents.DestructiveReach)
+	warlock.OnSpellRegistered(func(spell *core.Spell) {
+		if spell.Flags.Matches(WarlockFlagDestruction) {
+			spell.MaxRange *= multiplier
+		}
+	})
+}
+
 func (warlock *Warlock) applyBane() {
 	if warlock.Talents.Bane == 0 {
 		return
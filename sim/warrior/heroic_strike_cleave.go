@@ -53,7 +53,7 @@ func (warrior *Warrior) registerCleaveSpell(realismICD *core.Cooldown) {
 
 	flatDamageBonus *= []float64{1, 1.4, 1.8, 2.2}[warrior.Talents.ImprovedCleave]
 
-	results := make([]*core.SpellResult, min(int32(2), warrior.Env.GetNumTargets()))
+	results := make([]*core.SpellResult, min(int32(2), warrior.Env.GetMaxNumTargets()))
 
 	warrior.Cleave = warrior.RegisterSpell(AnyStance, core.SpellConfig{
 		ActionID:    core.ActionID{SpellID: spellID},
@@ -74,6 +74,7 @@ func (warrior *Warrior) registerCleaveSpell(realismICD *core.Cooldown) {
 		BonusCoefficient: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := results[:min(len(results), int(sim.GetNumTargets()))]
 			for idx := range results {
 				baseDamage := flatDamageBonus + spell.Unit.MHWeaponDamage(sim, spell.MeleeAttackPower(target))
 				results[idx] = spell.CalcDamage(sim, target, baseDamage, spell.OutcomeMeleeWeaponSpecialHitAndCrit)
@@ -11,8 +11,6 @@ func (warrior *Warrior) registerSweepingStrikesCD() {
 		return
 	}
 
-	numTargets := min(2, warrior.Env.GetNumTargets())
-
 	// Procs from auto attacks and most abilities https://www.wowhead.com/classic/spell=12723/sweeping-strikes
 	var curDmg float64
 	hitSchoolDamagWithValue := warrior.RegisterSpell(AnyStance, core.SpellConfig{
@@ -71,7 +69,7 @@ func (warrior *Warrior) registerSweepingStrikesCD() {
 				spellToUse = hitSchoolDamagWithValue
 			}
 
-			if numTargets > 1 {
+			if sim.GetNumTargets() > 1 {
 				target := warrior.Env.NextTargetUnit(result.Target)
 				spellToUse.Cast(sim, target)
 				spellToUse.SpellMetrics[target.UnitIndex].Casts--
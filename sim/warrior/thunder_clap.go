@@ -17,7 +17,7 @@ func (warrior *Warrior) registerThunderClapSpell() {
 		return core.ThunderClapAura(target, spellID, attackSpeedReduction)
 	})
 
-	results := make([]*core.SpellResult, min(4, warrior.Env.GetNumTargets()))
+	results := make([]*core.SpellResult, min(4, warrior.Env.GetMaxNumTargets()))
 
 	warrior.ThunderClap = warrior.RegisterSpell(stanceMask, core.SpellConfig{
 		ActionID:    core.ActionID{SpellID: spellID},
@@ -46,6 +46,7 @@ func (warrior *Warrior) registerThunderClapSpell() {
 		ThreatMultiplier: 2.5,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := results[:min(len(results), int(sim.GetNumTargets()))]
 			for idx := range results {
 				results[idx] = spell.CalcDamage(sim, target, baseDamage, spell.OutcomeMagicHitAndCrit)
 				target = sim.Environment.NextTargetUnit(target)
@@ -7,7 +7,7 @@ import (
 )
 
 func (warrior *Warrior) registerWhirlwindSpell() {
-	results := make([]*core.SpellResult, min(4, warrior.Env.GetNumTargets()))
+	results := make([]*core.SpellResult, min(4, warrior.Env.GetMaxNumTargets()))
 
 	warrior.Whirlwind = warrior.RegisterSpell(BerserkerStance, core.SpellConfig{
 		SpellCode:   SpellCode_WarriorWhirlwind,
@@ -37,6 +37,7 @@ func (warrior *Warrior) registerWhirlwindSpell() {
 		BonusCoefficient: 1,
 
 		ApplyEffects: func(sim *core.Simulation, target *core.Unit, spell *core.Spell) {
+			results := results[:min(len(results), int(sim.GetNumTargets()))]
 			for idx := range results {
 				baseDamage := spell.Unit.MHNormalizedWeaponDamage(sim, spell.MeleeAttackPower(target))
 				results[idx] = spell.CalcDamage(sim, target, baseDamage, spell.OutcomeMeleeWeaponSpecialHitAndCrit)
@@ -0,0 +1,382 @@
+// Package jobs runs queued sims on a bounded pool of workers and keeps their results in a Store,
+// so they can be listed and fetched again after the client that submitted them is gone.
+package jobs
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	uuid "github.com/google/uuid"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/simsignals"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+var (
+	ErrClosed         = errors.New("job manager is shut down")
+	ErrEmptyRequest   = errors.New("job has no sim request")
+	ErrUnknownRequest = errors.New("job has an unknown sim request type")
+	ErrJobActive      = errors.New("job is still queued or running")
+)
+
+type Manager struct {
+	store Store
+
+	mut     sync.Mutex
+	cond    *sync.Cond
+	pending []string              // Ids of queued jobs, in submission order.
+	active  map[string]*proto.Job // Latest state of all queued and running jobs.
+	aborted map[string]bool       // Running jobs that were aborted, possibly before their sim registered its signals.
+	closed  bool
+
+	workers sync.WaitGroup
+}
+
+// NewManager starts numWorkers workers pulling jobs from the queue. Jobs that were still queued
+// or running when the store was last used are queued again.
+func NewManager(store Store, numWorkers int) (*Manager, error) {
+	m := &Manager{
+		store:   store,
+		active:  map[string]*proto.Job{},
+		aborted: map[string]bool{},
+	}
+	m.cond = sync.NewCond(&m.mut)
+
+	stored, err := store.List()
+	if err != nil {
+		return nil, fmt.Errorf("cannot load jobs: %w", err)
+	}
+	sortBySubmission(stored)
+	for i := len(stored) - 1; i >= 0; i-- {
+		job := stored[i]
+		if job.Status != proto.JobStatus_JobStatusQueued && job.Status != proto.JobStatus_JobStatusRunning {
+			continue
+		}
+		job.Status = proto.JobStatus_JobStatusQueued
+		job.StartedAtMs = 0
+		job.Progress = nil
+		if err := store.Put(job); err != nil {
+			return nil, err
+		}
+		m.active[job.Id] = job
+		m.pending = append(m.pending, job.Id)
+	}
+
+	for i := 0; i < max(numWorkers, 1); i++ {
+		m.workers.Add(1)
+		go m.work()
+	}
+	return m, nil
+}
+
+// Submit queues a new job, returning its initial state.
+func (m *Manager) Submit(request *proto.SubmitJobRequest) (*proto.Job, error) {
+	if request.Request == nil {
+		// Fields this server doesn't know are most likely a request type from a newer client.
+		if len(request.ProtoReflect().GetUnknown()) > 0 {
+			return nil, ErrUnknownRequest
+		}
+		return nil, ErrEmptyRequest
+	}
+	if asyncAPI(request) == nil {
+		return nil, ErrUnknownRequest
+	}
+
+	job := &proto.Job{
+		Id:            uuid.NewString(),
+		Label:         request.Label,
+		Status:        proto.JobStatus_JobStatusQueued,
+		SubmittedAtMs: time.Now().UnixMilli(),
+		Request:       googleProto.Clone(request).(*proto.SubmitJobRequest),
+	}
+
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if m.closed {
+		return nil, ErrClosed
+	}
+	if err := m.store.Put(job); err != nil {
+		return nil, err
+	}
+	m.active[job.Id] = job
+	m.pending = append(m.pending, job.Id)
+	m.cond.Signal()
+	return googleProto.Clone(job).(*proto.Job), nil
+}
+
+// Get returns the full state of a job, including its request and result.
+func (m *Manager) Get(id string) (*proto.Job, error) {
+	m.mut.Lock()
+	if job, ok := m.active[id]; ok {
+		defer m.mut.Unlock()
+		return googleProto.Clone(job).(*proto.Job), nil
+	}
+	m.mut.Unlock()
+	return m.store.Get(id)
+}
+
+// List returns summaries of all jobs matching the request, most recently submitted first.
+func (m *Manager) List(request *proto.ListJobsRequest) ([]*proto.Job, error) {
+	stored, err := m.store.List()
+	if err != nil {
+		return nil, err
+	}
+
+	m.mut.Lock()
+	for i, job := range stored {
+		if activeJob, ok := m.active[job.Id]; ok {
+			stored[i] = googleProto.Clone(activeJob).(*proto.Job)
+		}
+	}
+	m.mut.Unlock()
+
+	jobs := make([]*proto.Job, 0, len(stored))
+	for _, job := range stored {
+		if request.Status != proto.JobStatus_JobStatusUnknown && job.Status != request.Status {
+			continue
+		}
+		jobs = append(jobs, summarize(job))
+	}
+	sortBySubmission(jobs)
+	if request.Limit > 0 && len(jobs) > int(request.Limit) {
+		jobs = jobs[:request.Limit]
+	}
+	return jobs, nil
+}
+
+// Abort cancels a queued job, or signals a running one to stop. Finished jobs are returned unchanged.
+func (m *Manager) Abort(id string) (*proto.Job, error) {
+	m.mut.Lock()
+	job, ok := m.active[id]
+	if !ok {
+		m.mut.Unlock()
+		return m.store.Get(id)
+	}
+
+	if job.Status == proto.JobStatus_JobStatusQueued {
+		defer m.mut.Unlock()
+		m.pending = slices.DeleteFunc(m.pending, func(pendingId string) bool { return pendingId == id })
+		delete(m.active, id)
+		job.Status = proto.JobStatus_JobStatusAborted
+		job.FinishedAtMs = time.Now().UnixMilli()
+		return googleProto.Clone(job).(*proto.Job), m.store.Put(job)
+	}
+
+	// The worker running this job records the aborted result.
+	defer m.mut.Unlock()
+	m.aborted[id] = true
+	simsignals.AbortById(id)
+	return googleProto.Clone(job).(*proto.Job), nil
+}
+
+// Delete removes a finished job from the store.
+func (m *Manager) Delete(id string) (*proto.Job, error) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	if _, ok := m.active[id]; ok {
+		return nil, ErrJobActive
+	}
+	job, err := m.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	return summarize(job), m.store.Delete(id)
+}
+
+// Close stops all workers and closes the store. Running jobs are aborted, but stay queued in the
+// store so they are picked up again by the next Manager using it.
+func (m *Manager) Close() error {
+	m.mut.Lock()
+	m.closed = true
+	for id, job := range m.active {
+		if job.Status == proto.JobStatus_JobStatusRunning {
+			simsignals.AbortById(id)
+		}
+	}
+	m.cond.Broadcast()
+	m.mut.Unlock()
+
+	m.workers.Wait()
+	return m.store.Close()
+}
+
+func (m *Manager) work() {
+	defer m.workers.Done()
+	for {
+		job := m.next()
+		if job == nil {
+			return
+		}
+		m.run(job)
+	}
+}
+
+// Blocks until a job is ready to run and marks it as running, or returns nil once closed.
+func (m *Manager) next() *proto.Job {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	for len(m.pending) == 0 && !m.closed {
+		m.cond.Wait()
+	}
+	if m.closed {
+		return nil
+	}
+
+	id := m.pending[0]
+	m.pending = m.pending[1:]
+	job := m.active[id]
+	job.Status = proto.JobStatus_JobStatusRunning
+	job.StartedAtMs = time.Now().UnixMilli()
+	if err := m.store.Put(job); err != nil {
+		log.Printf("Failed to store job %s: %s", id, err)
+	}
+	return googleProto.Clone(job).(*proto.Job)
+}
+
+func (m *Manager) run(job *proto.Job) {
+	// The core APIs modify their requests, so hand them a copy to keep the stored one intact.
+	request := googleProto.Clone(job.Request).(*proto.SubmitJobRequest)
+	start := asyncAPI(request)
+	if start == nil {
+		m.finish(job.Id, nil, &proto.ErrorOutcome{Message: ErrUnknownRequest.Error()})
+		return
+	}
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	start(reporter, job.Id)
+
+	// Catch aborts that came in before the sim registered its signals.
+	m.mut.Lock()
+	if m.aborted[job.Id] || m.closed {
+		simsignals.AbortById(job.Id)
+	}
+	m.mut.Unlock()
+
+	var final *proto.ProgressMetrics
+	for progress := range reporter {
+		if core.IsFinalProgress(progress) {
+			final = progress
+			break
+		}
+		m.mut.Lock()
+		m.active[job.Id].Progress = progress
+		m.mut.Unlock()
+	}
+	m.finish(job.Id, final, nil)
+}
+
+// Returns the core async API running the request, or nil if the request type is unknown.
+func asyncAPI(request *proto.SubmitJobRequest) func(reporter chan *proto.ProgressMetrics, id string) {
+	switch req := request.Request.(type) {
+	case *proto.SubmitJobRequest_RaidSim:
+		return func(reporter chan *proto.ProgressMetrics, id string) {
+			core.RunRaidSimConcurrentAsync(req.RaidSim, reporter, id)
+		}
+	case *proto.SubmitJobRequest_StatWeights:
+		return func(reporter chan *proto.ProgressMetrics, id string) {
+			core.StatWeightsAsync(req.StatWeights, reporter, id)
+		}
+	case *proto.SubmitJobRequest_BulkSim:
+		return func(reporter chan *proto.ProgressMetrics, id string) {
+			core.RunBulkSimAsync(req.BulkSim, reporter, id)
+		}
+	case *proto.SubmitJobRequest_StatScaling:
+		return func(reporter chan *proto.ProgressMetrics, id string) {
+			core.StatScalingAsync(req.StatScaling, reporter, id)
+		}
+	case *proto.SubmitJobRequest_GearOptimizer:
+		return func(reporter chan *proto.ProgressMetrics, id string) {
+			core.OptimizeGearAsync(req.GearOptimizer, reporter, id)
+		}
+	case *proto.SubmitJobRequest_TalentOptimizer:
+		return func(reporter chan *proto.ProgressMetrics, id string) {
+			core.OptimizeTalentsAsync(req.TalentOptimizer, reporter, id)
+		}
+	case *proto.SubmitJobRequest_BuffValues:
+		return func(reporter chan *proto.ProgressMetrics, id string) {
+			core.BuffValuesAsync(req.BuffValues, reporter, id)
+		}
+	default:
+		return nil
+	}
+}
+
+// Stores the outcome of a job that is no longer running, with either its final progress or an
+// error that kept it from running.
+func (m *Manager) finish(id string, final *proto.ProgressMetrics, err *proto.ErrorOutcome) {
+	m.mut.Lock()
+	defer m.mut.Unlock()
+	job := m.active[id]
+	delete(m.active, id)
+	delete(m.aborted, id)
+
+	if final == nil {
+		final = &proto.ProgressMetrics{}
+	}
+	job.Progress = final
+	job.Error = err
+	job.FinishedAtMs = time.Now().UnixMilli()
+	job.Status = proto.JobStatus_JobStatusDone
+	if finalError(final).GetType() == proto.ErrorOutcomeType_ErrorOutcomeAborted {
+		job.Status = proto.JobStatus_JobStatusAborted
+		if m.closed {
+			// Interrupted by shutdown rather than by a user, so run it again next time.
+			job.Status = proto.JobStatus_JobStatusQueued
+			job.Progress = nil
+			job.StartedAtMs = 0
+			job.FinishedAtMs = 0
+		}
+	}
+	if err := m.store.Put(job); err != nil {
+		log.Printf("Failed to store job %s: %s", job.Id, err)
+	}
+}
+
+func finalError(progress *proto.ProgressMetrics) *proto.ErrorOutcome {
+	switch {
+	case progress.FinalRaidResult != nil:
+		return progress.FinalRaidResult.Error
+	case progress.FinalWeightResult != nil:
+		return progress.FinalWeightResult.Error
+	case progress.FinalBulkResult != nil:
+		return progress.FinalBulkResult.Error
+	case progress.FinalScalingResult != nil:
+		return progress.FinalScalingResult.Error
+	case progress.FinalGearResult != nil:
+		return progress.FinalGearResult.Error
+	case progress.FinalTalentResult != nil:
+		return progress.FinalTalentResult.Error
+	case progress.FinalBuffValuesResult != nil:
+		return progress.FinalBuffValuesResult.Error
+	}
+	return nil
+}
+
+// Strips the request and results from a job, for listing.
+func summarize(job *proto.Job) *proto.Job {
+	summary := googleProto.Clone(job).(*proto.Job)
+	summary.Request = nil
+	if summary.Progress != nil {
+		summary.Progress.FinalRaidResult = nil
+		summary.Progress.FinalWeightResult = nil
+		summary.Progress.FinalBulkResult = nil
+		summary.Progress.FinalScalingResult = nil
+		summary.Progress.FinalGearResult = nil
+		summary.Progress.FinalTalentResult = nil
+		summary.Progress.FinalBuffValuesResult = nil
+	}
+	return summary
+}
+
+// Sorts most recently submitted first.
+func sortBySubmission(jobs []*proto.Job) {
+	slices.SortStableFunc(jobs, func(a, b *proto.Job) int {
+		return cmp.Compare(b.SubmittedAtMs, a.SubmittedAtMs)
+	})
+}
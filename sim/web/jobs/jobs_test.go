@@ -0,0 +1,231 @@
+package jobs
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/wowsims/classic/sim"
+	"github.com/wowsims/classic/sim/core"
+	"github.com/wowsims/classic/sim/core/proto"
+	"google.golang.org/protobuf/encoding/protowire"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+func init() {
+	sim.RegisterAll()
+}
+
+func raidSimJob(label string, iterations int32) *proto.SubmitJobRequest {
+	return &proto.SubmitJobRequest{
+		Label: label,
+		Request: &proto.SubmitJobRequest_RaidSim{
+			RaidSim: &proto.RaidSimRequest{
+				Raid: core.SinglePlayerRaidProto(
+					&proto.Player{
+						Race:      proto.Race_RaceTroll,
+						Class:     proto.Class_ClassShaman,
+						Equipment: &proto.EquipmentSpec{},
+						Spec: &proto.Player_ElementalShaman{
+							ElementalShaman: &proto.ElementalShaman{Options: &proto.ElementalShaman_Options{}},
+						},
+					},
+					&proto.PartyBuffs{},
+					&proto.RaidBuffs{},
+					&proto.Debuffs{},
+				),
+				Encounter: &proto.Encounter{
+					Duration: 60,
+					Targets:  []*proto.Target{{}},
+				},
+				SimOptions: &proto.SimOptions{
+					Iterations: iterations,
+					RandomSeed: 101,
+				},
+			},
+		},
+	}
+}
+
+func waitForStatus(t *testing.T, m *Manager, id string, status proto.JobStatus) *proto.Job {
+	t.Helper()
+	deadline := time.Now().Add(time.Minute)
+	for time.Now().Before(deadline) {
+		job, err := m.Get(id)
+		if err != nil {
+			t.Fatalf("Get(%s): %s", id, err)
+		}
+		if job.Status == status {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("Job %s never reached status %s", id, status)
+	return nil
+}
+
+func TestJobResultsArePersisted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+
+	store, err := OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := NewManager(store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := m.Submit(raidSimJob("persisted", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != proto.JobStatus_JobStatusQueued {
+		t.Fatalf("Expected new job to be queued, got %s", job.Status)
+	}
+	done := waitForStatus(t, m, job.Id, proto.JobStatus_JobStatusDone)
+	if done.Progress.GetFinalRaidResult() == nil {
+		t.Fatalf("Expected finished job to hold a raid result")
+	}
+	if err := m.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	store, err = OpenBoltStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err = NewManager(store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	reloaded, err := m.Get(job.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reloaded.Status != proto.JobStatus_JobStatusDone || reloaded.Label != "persisted" {
+		t.Fatalf("Unexpected reloaded job: %s %s", reloaded.Status, reloaded.Label)
+	}
+	if reloaded.Progress.GetFinalRaidResult().GetError() != nil {
+		t.Fatalf("Reloaded job has error: %s", reloaded.Progress.FinalRaidResult.Error.Message)
+	}
+	if reloaded.Request.GetRaidSim() == nil {
+		t.Fatalf("Expected reloaded job to keep its request")
+	}
+
+	list, err := m.List(&proto.ListJobsRequest{Status: proto.JobStatus_JobStatusDone})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Id != job.Id {
+		t.Fatalf("Expected to list the finished job, got %v", list)
+	}
+	if list[0].Request != nil || list[0].Progress.GetFinalRaidResult() != nil {
+		t.Fatalf("Expected listed jobs to be summaries")
+	}
+
+	if _, err := m.Delete(job.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Get(job.Id); err != ErrNotFound {
+		t.Fatalf("Expected ErrNotFound after delete, got %v", err)
+	}
+}
+
+func TestAbortJobs(t *testing.T) {
+	m, err := NewManager(NewMemoryStore(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	running, err := m.Submit(raidSimJob("running", 1_000_000))
+	if err != nil {
+		t.Fatal(err)
+	}
+	queued, err := m.Submit(raidSimJob("queued", 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The single worker is busy with the first job, so the second one is still waiting.
+	aborted, err := m.Abort(queued.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if aborted.Status != proto.JobStatus_JobStatusAborted {
+		t.Fatalf("Expected queued job to be aborted right away, got %s", aborted.Status)
+	}
+
+	waitForStatus(t, m, running.Id, proto.JobStatus_JobStatusRunning)
+	if _, err := m.Delete(running.Id); err != ErrJobActive {
+		t.Fatalf("Expected ErrJobActive when deleting a running job, got %v", err)
+	}
+	if _, err := m.Abort(running.Id); err != nil {
+		t.Fatal(err)
+	}
+	waitForStatus(t, m, running.Id, proto.JobStatus_JobStatusAborted)
+
+	list, err := m.List(&proto.ListJobsRequest{Limit: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 {
+		t.Fatalf("Expected the limit to apply, got %d jobs", len(list))
+	}
+}
+
+func TestSubmitEmptyRequest(t *testing.T) {
+	m, err := NewManager(NewMemoryStore(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+
+	if _, err := m.Submit(&proto.SubmitJobRequest{}); err != ErrEmptyRequest {
+		t.Fatalf("Expected ErrEmptyRequest, got %v", err)
+	}
+}
+
+func TestUnknownRequestType(t *testing.T) {
+	// A request type from a newer client, which this server only sees as an unknown field.
+	data := protowire.AppendTag(nil, 99, protowire.BytesType)
+	data = protowire.AppendBytes(data, nil)
+	request := &proto.SubmitJobRequest{}
+	if err := googleProto.Unmarshal(data, request); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewManager(NewMemoryStore(), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	if _, err := m.Submit(request); err != ErrUnknownRequest {
+		t.Fatalf("Expected ErrUnknownRequest, got %v", err)
+	}
+}
+
+func TestStoredUnknownRequestType(t *testing.T) {
+	// Queued by a server that knows more request types.
+	store := NewMemoryStore()
+	if err := store.Put(&proto.Job{
+		Id:      "unknown",
+		Status:  proto.JobStatus_JobStatusQueued,
+		Request: &proto.SubmitJobRequest{},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := NewManager(store, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	job := waitForStatus(t, m, "unknown", proto.JobStatus_JobStatusDone)
+	if job.Error == nil {
+		t.Fatalf("Expected the job to finish with an error")
+	}
+}
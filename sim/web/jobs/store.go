@@ -0,0 +1,152 @@
+package jobs
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/wowsims/classic/sim/core/proto"
+	bolt "go.etcd.io/bbolt"
+	googleProto "google.golang.org/protobuf/proto"
+)
+
+var ErrNotFound = errors.New("job not found")
+
+// Store persists jobs, including their requests and final results.
+type Store interface {
+	Put(job *proto.Job) error
+	Get(id string) (*proto.Job, error)
+	// All stored jobs, in no particular order.
+	List() ([]*proto.Job, error)
+	Delete(id string) error
+	Close() error
+}
+
+var jobsBucket = []byte("jobs")
+
+// BoltStore keeps jobs in a single BoltDB file, so they survive server restarts.
+type BoltStore struct {
+	db *bolt.DB
+}
+
+func OpenBoltStore(path string) (*BoltStore, error) {
+	db, err := bolt.Open(path, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("cannot open job store %q: %w", path, err)
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(jobsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("cannot initialize job store %q: %w", path, err)
+	}
+	return &BoltStore{db: db}, nil
+}
+
+func (bs *BoltStore) Put(job *proto.Job) error {
+	data, err := googleProto.Marshal(job)
+	if err != nil {
+		return err
+	}
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).Put([]byte(job.Id), data)
+	})
+}
+
+func (bs *BoltStore) Get(id string) (*proto.Job, error) {
+	job := &proto.Job{}
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(jobsBucket).Get([]byte(id))
+		if data == nil {
+			return ErrNotFound
+		}
+		return googleProto.Unmarshal(data, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (bs *BoltStore) List() ([]*proto.Job, error) {
+	var jobs []*proto.Job
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(jobsBucket).ForEach(func(_, data []byte) error {
+			job := &proto.Job{}
+			if err := googleProto.Unmarshal(data, job); err != nil {
+				return err
+			}
+			jobs = append(jobs, job)
+			return nil
+		})
+	})
+	return jobs, err
+}
+
+func (bs *BoltStore) Delete(id string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(jobsBucket)
+		if bucket.Get([]byte(id)) == nil {
+			return ErrNotFound
+		}
+		return bucket.Delete([]byte(id))
+	})
+}
+
+func (bs *BoltStore) Close() error {
+	return bs.db.Close()
+}
+
+// MemoryStore is a Store for when no job file is configured. Jobs are lost on restart.
+type MemoryStore struct {
+	mut  sync.RWMutex
+	jobs map[string]*proto.Job
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{jobs: map[string]*proto.Job{}}
+}
+
+func (ms *MemoryStore) Put(job *proto.Job) error {
+	ms.mut.Lock()
+	defer ms.mut.Unlock()
+	ms.jobs[job.Id] = googleProto.Clone(job).(*proto.Job)
+	return nil
+}
+
+func (ms *MemoryStore) Get(id string) (*proto.Job, error) {
+	ms.mut.RLock()
+	defer ms.mut.RUnlock()
+	job, ok := ms.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return googleProto.Clone(job).(*proto.Job), nil
+}
+
+func (ms *MemoryStore) List() ([]*proto.Job, error) {
+	ms.mut.RLock()
+	defer ms.mut.RUnlock()
+	jobs := make([]*proto.Job, 0, len(ms.jobs))
+	for _, job := range ms.jobs {
+		jobs = append(jobs, googleProto.Clone(job).(*proto.Job))
+	}
+	return jobs, nil
+}
+
+func (ms *MemoryStore) Delete(id string) error {
+	ms.mut.Lock()
+	defer ms.mut.Unlock()
+	if _, ok := ms.jobs[id]; !ok {
+		return ErrNotFound
+	}
+	delete(ms.jobs, id)
+	return nil
+}
+
+func (ms *MemoryStore) Close() error {
+	return nil
+}
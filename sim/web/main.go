@@ -24,7 +24,11 @@ import (
 	"github.com/wowsims/classic/sim"
 	"github.com/wowsims/classic/sim/core"
 	proto "github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/proto/protoconnect"
 	"github.com/wowsims/classic/sim/core/simsignals"
+	"github.com/wowsims/classic/sim/web/jobs"
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
 
 	googleProto "google.golang.org/protobuf/proto"
 )
@@ -48,6 +52,8 @@ func main() {
 	var host = flag.String("host", "localhost:3333", "URL to host the interface on.")
 	var launch = flag.Bool("launch", true, "auto launch browser")
 	var skipVersionCheck = flag.Bool("nvc", false, "set true to skip version check")
+	var jobFile = flag.String("jobfile", "", "File to persist queued jobs and their results in. Jobs are only kept in memory if not set.")
+	var jobWorkers = flag.Int("jobworkers", 1, "Number of jobs to run at the same time.")
 
 	flag.Parse()
 
@@ -82,9 +88,23 @@ func main() {
 		}()
 	}
 
+	var jobStore jobs.Store = jobs.NewMemoryStore()
+	if *jobFile != "" {
+		boltStore, err := jobs.OpenBoltStore(*jobFile)
+		if err != nil {
+			log.Fatalf("Failed to open job file: %s", err)
+		}
+		jobStore = boltStore
+	}
+	jobManager, err := jobs.NewManager(jobStore, *jobWorkers)
+	if err != nil {
+		log.Fatalf("Failed to start job manager: %s", err)
+	}
+
 	s := &server{
 		progMut:         sync.RWMutex{},
 		asyncProgresses: map[string]*asyncProgress{},
+		jobs:            jobManager,
 	}
 	s.runServer(*useFS, *host, *launch, *simName, *wasm, bufio.NewReader(os.Stdin))
 }
@@ -103,6 +123,18 @@ var handlers = map[string]apiHandler{
 	"/statWeightCompute": {msg: func() googleProto.Message { return &proto.StatWeightsCalcRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
 		return core.StatWeightCompute(msg.(*proto.StatWeightsCalcRequest))
 	}},
+	"/statScaling": {msg: func() googleProto.Message { return &proto.StatScalingRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
+		return core.StatScaling(msg.(*proto.StatScalingRequest))
+	}},
+	"/gearOptimizer": {msg: func() googleProto.Message { return &proto.GearOptimizerRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
+		return core.OptimizeGear(msg.(*proto.GearOptimizerRequest))
+	}},
+	"/talentOptimizer": {msg: func() googleProto.Message { return &proto.TalentOptimizerRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
+		return core.OptimizeTalents(msg.(*proto.TalentOptimizerRequest))
+	}},
+	"/buffValues": {msg: func() googleProto.Message { return &proto.BuffValuesRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
+		return core.BuffValues(msg.(*proto.BuffValuesRequest))
+	}},
 	"/computeStats": {msg: func() googleProto.Message { return &proto.ComputeStatsRequest{} }, handle: func(msg googleProto.Message) googleProto.Message {
 		return core.ComputeStats(msg.(*proto.ComputeStatsRequest))
 	}},
@@ -120,6 +152,18 @@ var asyncAPIHandlers = map[string]asyncAPIHandler{
 	"/statWeightsAsync": {msg: func() googleProto.Message { return &proto.StatWeightsRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics, requestId string) {
 		core.StatWeightsAsync(msg.(*proto.StatWeightsRequest), reporter, requestId)
 	}},
+	"/statScalingAsync": {msg: func() googleProto.Message { return &proto.StatScalingRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics, requestId string) {
+		core.StatScalingAsync(msg.(*proto.StatScalingRequest), reporter, requestId)
+	}},
+	"/gearOptimizerAsync": {msg: func() googleProto.Message { return &proto.GearOptimizerRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics, requestId string) {
+		core.OptimizeGearAsync(msg.(*proto.GearOptimizerRequest), reporter, requestId)
+	}},
+	"/talentOptimizerAsync": {msg: func() googleProto.Message { return &proto.TalentOptimizerRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics, requestId string) {
+		core.OptimizeTalentsAsync(msg.(*proto.TalentOptimizerRequest), reporter, requestId)
+	}},
+	"/buffValuesAsync": {msg: func() googleProto.Message { return &proto.BuffValuesRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics, requestId string) {
+		core.BuffValuesAsync(msg.(*proto.BuffValuesRequest), reporter, requestId)
+	}},
 	"/bulkSimAsync": {msg: func() googleProto.Message { return &proto.BulkSimRequest{} }, handle: func(msg googleProto.Message, reporter chan *proto.ProgressMetrics, requestId string) {
 		core.RunBulkSimAsync(msg.(*proto.BulkSimRequest), reporter, requestId)
 	}},
@@ -128,6 +172,9 @@ var asyncAPIHandlers = map[string]asyncAPIHandler{
 type server struct {
 	progMut         sync.RWMutex
 	asyncProgresses map[string]*asyncProgress
+
+	// Queued sims submitted through SimService, see proto/sim_service.proto.
+	jobs *jobs.Manager
 }
 
 type apiHandler struct {
@@ -203,7 +250,7 @@ func (s *server) handleAsyncAPI(w http.ResponseWriter, r *http.Request) {
 					return
 				}
 				simProgress.latestProgress.Store(progMetric)
-				if progMetric.FinalRaidResult != nil || progMetric.FinalWeightResult != nil || progMetric.FinalBulkResult != nil {
+				if core.IsFinalProgress(progMetric) {
 					return
 				}
 			}
@@ -261,7 +308,7 @@ func (s *server) setupAsyncServer() {
 		}
 
 		// If this was the last result, delete the cache for this simulation.
-		if latest.FinalRaidResult != nil || latest.FinalWeightResult != nil || latest.FinalBulkResult != nil {
+		if core.IsFinalProgress(latest) {
 			s.progMut.Lock()
 			delete(s.asyncProgresses, msg.ProgressId)
 			s.progMut.Unlock()
@@ -298,6 +345,10 @@ func (s *server) runServer(useFS bool, host string, launchBrowser bool, simName
 		http.Handle(route, corsMiddleware(http.HandlerFunc(handleAPI)))
 	}
 
+	// Streaming gRPC/Connect API, see proto/sim_service.proto.
+	servicePath, serviceHandler := protoconnect.NewSimServiceHandler(&simService{jobs: s.jobs})
+	http.Handle(servicePath, corsMiddleware(serviceHandler))
+
 	http.HandleFunc("/version", func(resp http.ResponseWriter, req *http.Request) {
 		msg := fmt.Sprintf(`{"version": "%s", "outdated": %d}`, Version, outdated)
 		resp.Write([]byte(msg))
@@ -338,8 +389,8 @@ func (s *server) runServer(useFS bool, host string, launchBrowser bool, simName
 	}
 
 	go func() {
-		// Launch server!
-		if err := http.ListenAndServe(host, nil); err != nil {
+		// Launch server! h2c lets gRPC clients talk HTTP/2 to us without TLS.
+		if err := http.ListenAndServe(host, h2c.NewHandler(http.DefaultServeMux, &http2.Server{})); err != nil {
 			log.Printf("Failed to shutdown server: %s", err)
 			os.Exit(1)
 		}
@@ -354,6 +405,10 @@ func (s *server) runServer(useFS bool, host string, launchBrowser bool, simName
 	go func() {
 		<-c
 		log.Printf("Shutting down")
+		// Puts running jobs back in the queue, so they are resumed on the next start.
+		if err := s.jobs.Close(); err != nil {
+			log.Printf("Failed to close job store: %s", err)
+		}
 		os.Exit(0)
 	}()
 	fmt.Printf("Enter Command... '?' for list\n")
@@ -394,10 +449,20 @@ func (s *server) runServer(useFS bool, host string, launchBrowser bool, simName
 				fmt.Printf("Process: %s (%d sims)\n\t  Progress: %d/%d\n", v.id, latest.TotalSims, latest.CompletedIterations, latest.TotalIterations)
 			}
 			s.progMut.RUnlock()
+		case "jobs":
+			jobList, err := s.jobs.List(&proto.ListJobsRequest{})
+			if err != nil {
+				fmt.Printf("Failed to list jobs: %s\n", err)
+				break
+			}
+			fmt.Printf("Total Jobs: %d\n", len(jobList))
+			for _, job := range jobList {
+				fmt.Printf("Job: %s %s (%s)\n\t  Progress: %d/%d\n", job.Id, job.Label, job.Status, job.Progress.GetCompletedIterations(), job.Progress.GetTotalIterations())
+			}
 		case "quit":
 			os.Exit(1)
 		case "?":
-			fmt.Printf("Commands:\n\tsims - Lists all active async sims running currently.\n\tprofile - start a CPU profile for debugging performance\n\tquit - exits\n\n")
+			fmt.Printf("Commands:\n\tsims - Lists all active async sims running currently.\n\tjobs - Lists all queued, running and stored jobs.\n\tprofile - start a CPU profile for debugging performance\n\tquit - exits\n\n")
 		case "":
 			// nothing.
 		default:
@@ -3,6 +3,7 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"io"
 	"log"
 	"net/http"
@@ -10,9 +11,12 @@ import (
 	"testing"
 	"time"
 
+	"connectrpc.com/connect"
 	_ "github.com/wowsims/classic/sim/common"
 	"github.com/wowsims/classic/sim/core"
 	"github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/proto/protoconnect"
+	"github.com/wowsims/classic/sim/web/jobs"
 	googleProto "google.golang.org/protobuf/proto"
 )
 
@@ -45,9 +49,14 @@ var p1Equip = &proto.EquipmentSpec{
 }
 
 func init() {
+	jobManager, err := jobs.NewManager(jobs.NewMemoryStore(), 1)
+	if err != nil {
+		panic(err)
+	}
 	s := &server{
 		progMut:         sync.RWMutex{},
 		asyncProgresses: map[string]*asyncProgress{},
+		jobs:            jobManager,
 	}
 	go func() {
 		s.runServer(true, "localhost:3339", false, "", false, bufio.NewReader(bytes.NewBuffer([]byte{})))
@@ -108,3 +117,58 @@ func TestIndividualSim(t *testing.T) {
 
 	log.Printf("RESULT: %#v", rsr)
 }
+
+// TestStreamingRaidSim is a smoke test for the streaming SimService.
+func TestStreamingRaidSim(t *testing.T) {
+	req := &proto.RaidSimRequest{
+		Raid: core.SinglePlayerRaidProto(
+			&proto.Player{
+				Race:      proto.Race_RaceTroll,
+				Class:     proto.Class_ClassShaman,
+				Equipment: &proto.EquipmentSpec{},
+				Spec:      basicSpec,
+			},
+			&proto.PartyBuffs{},
+			&proto.RaidBuffs{},
+			&proto.Debuffs{}),
+		Encounter: &proto.Encounter{
+			Duration: 120,
+			Targets: []*proto.Target{
+				{},
+			},
+		},
+		SimOptions: &proto.SimOptions{
+			Iterations: 1000,
+			RandomSeed: 1,
+		},
+	}
+
+	client := protoconnect.NewSimServiceClient(http.DefaultClient, "http://localhost:3339")
+	stream, err := client.RaidSim(context.Background(), connect.NewRequest(req))
+	if err != nil {
+		t.Fatalf("Failed to start stream: %s", err.Error())
+	}
+	defer stream.Close()
+
+	var final *proto.RaidSimResult
+	for stream.Receive() {
+		if result := stream.Msg().FinalRaidResult; result != nil {
+			final = result
+		}
+	}
+	if err := stream.Err(); err != nil {
+		t.Fatalf("Stream failed: %s", err.Error())
+	}
+	if stream.ResponseHeader().Get(requestIdHeader) == "" {
+		t.Fatalf("Missing %s response header", requestIdHeader)
+	}
+	if final == nil {
+		t.Fatalf("Stream ended without a final result")
+	}
+	if final.Error != nil {
+		t.Fatalf("Sim failed: %s", final.Error.Message)
+	}
+	if final.IterationsDone != req.SimOptions.Iterations {
+		t.Fatalf("Expected %d iterations, got %d", req.SimOptions.Iterations, final.IterationsDone)
+	}
+}
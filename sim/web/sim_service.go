@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"connectrpc.com/connect"
+	uuid "github.com/google/uuid"
+	"github.com/wowsims/classic/sim/core"
+	proto "github.com/wowsims/classic/sim/core/proto"
+	"github.com/wowsims/classic/sim/core/proto/protoconnect"
+	"github.com/wowsims/classic/sim/core/simsignals"
+	"github.com/wowsims/classic/sim/web/jobs"
+)
+
+// Header used to pass the simsignals request id of a streaming call, see proto/sim_service.proto.
+const requestIdHeader = "Sim-Request-Id"
+
+// simService implements the streaming SimService on top of the same async core APIs used by the
+// /xxxAsync endpoints, but pushes every progress report to the client instead of having it poll.
+type simService struct {
+	jobs *jobs.Manager
+}
+
+var _ protoconnect.SimServiceHandler = (*simService)(nil)
+
+func (s *simService) RaidSim(ctx context.Context, req *connect.Request[proto.RaidSimRequest], stream *connect.ServerStream[proto.ProgressMetrics]) error {
+	return streamProgress(ctx, req.Header().Get(requestIdHeader), stream, func(reporter chan *proto.ProgressMetrics, requestId string) {
+		core.RunRaidSimConcurrentAsync(req.Msg, reporter, requestId)
+	})
+}
+
+func (s *simService) StatWeights(ctx context.Context, req *connect.Request[proto.StatWeightsRequest], stream *connect.ServerStream[proto.ProgressMetrics]) error {
+	return streamProgress(ctx, req.Header().Get(requestIdHeader), stream, func(reporter chan *proto.ProgressMetrics, requestId string) {
+		core.StatWeightsAsync(req.Msg, reporter, requestId)
+	})
+}
+
+func (s *simService) BulkSim(ctx context.Context, req *connect.Request[proto.BulkSimRequest], stream *connect.ServerStream[proto.ProgressMetrics]) error {
+	return streamProgress(ctx, req.Header().Get(requestIdHeader), stream, func(reporter chan *proto.ProgressMetrics, requestId string) {
+		core.RunBulkSimAsync(req.Msg, reporter, requestId)
+	})
+}
+
+func (s *simService) StatScaling(ctx context.Context, req *connect.Request[proto.StatScalingRequest], stream *connect.ServerStream[proto.ProgressMetrics]) error {
+	return streamProgress(ctx, req.Header().Get(requestIdHeader), stream, func(reporter chan *proto.ProgressMetrics, requestId string) {
+		core.StatScalingAsync(req.Msg, reporter, requestId)
+	})
+}
+
+func (s *simService) GearOptimizer(ctx context.Context, req *connect.Request[proto.GearOptimizerRequest], stream *connect.ServerStream[proto.ProgressMetrics]) error {
+	return streamProgress(ctx, req.Header().Get(requestIdHeader), stream, func(reporter chan *proto.ProgressMetrics, requestId string) {
+		core.OptimizeGearAsync(req.Msg, reporter, requestId)
+	})
+}
+
+func (s *simService) TalentOptimizer(ctx context.Context, req *connect.Request[proto.TalentOptimizerRequest], stream *connect.ServerStream[proto.ProgressMetrics]) error {
+	return streamProgress(ctx, req.Header().Get(requestIdHeader), stream, func(reporter chan *proto.ProgressMetrics, requestId string) {
+		core.OptimizeTalentsAsync(req.Msg, reporter, requestId)
+	})
+}
+
+func (s *simService) BuffValues(ctx context.Context, req *connect.Request[proto.BuffValuesRequest], stream *connect.ServerStream[proto.ProgressMetrics]) error {
+	return streamProgress(ctx, req.Header().Get(requestIdHeader), stream, func(reporter chan *proto.ProgressMetrics, requestId string) {
+		core.BuffValuesAsync(req.Msg, reporter, requestId)
+	})
+}
+
+func (s *simService) Abort(ctx context.Context, req *connect.Request[proto.AbortRequest]) (*connect.Response[proto.AbortResponse], error) {
+	requestId := req.Msg.RequestId
+	triggered := simsignals.AbortById(requestId)
+	return connect.NewResponse(&proto.AbortResponse{RequestId: requestId, WasTriggered: triggered}), nil
+}
+
+func (s *simService) SubmitJob(ctx context.Context, req *connect.Request[proto.SubmitJobRequest]) (*connect.Response[proto.Job], error) {
+	job, err := s.jobs.Submit(req.Msg)
+	return jobResponse(job, err)
+}
+
+func (s *simService) GetJob(ctx context.Context, req *connect.Request[proto.GetJobRequest]) (*connect.Response[proto.Job], error) {
+	job, err := s.jobs.Get(req.Msg.Id)
+	return jobResponse(job, err)
+}
+
+func (s *simService) ListJobs(ctx context.Context, req *connect.Request[proto.ListJobsRequest]) (*connect.Response[proto.ListJobsResult], error) {
+	list, err := s.jobs.List(req.Msg)
+	if err != nil {
+		return nil, jobError(err)
+	}
+	return connect.NewResponse(&proto.ListJobsResult{Jobs: list}), nil
+}
+
+func (s *simService) AbortJob(ctx context.Context, req *connect.Request[proto.AbortJobRequest]) (*connect.Response[proto.Job], error) {
+	job, err := s.jobs.Abort(req.Msg.Id)
+	return jobResponse(job, err)
+}
+
+func (s *simService) DeleteJob(ctx context.Context, req *connect.Request[proto.DeleteJobRequest]) (*connect.Response[proto.Job], error) {
+	job, err := s.jobs.Delete(req.Msg.Id)
+	return jobResponse(job, err)
+}
+
+func jobResponse(job *proto.Job, err error) (*connect.Response[proto.Job], error) {
+	if err != nil {
+		return nil, jobError(err)
+	}
+	return connect.NewResponse(job), nil
+}
+
+func jobError(err error) error {
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		return connect.NewError(connect.CodeNotFound, err)
+	case errors.Is(err, jobs.ErrEmptyRequest), errors.Is(err, jobs.ErrUnknownRequest):
+		return connect.NewError(connect.CodeInvalidArgument, err)
+	case errors.Is(err, jobs.ErrJobActive):
+		return connect.NewError(connect.CodeFailedPrecondition, err)
+	case errors.Is(err, jobs.ErrClosed):
+		return connect.NewError(connect.CodeUnavailable, err)
+	}
+	return connect.NewError(connect.CodeInternal, err)
+}
+
+// streamProgress starts a sim through run and forwards its progress reports to the stream until the
+// final result has been sent. If the client goes away first, the sim is aborted via simsignals.
+func streamProgress(ctx context.Context, requestId string, stream *connect.ServerStream[proto.ProgressMetrics], run func(chan *proto.ProgressMetrics, string)) error {
+	if requestId == "" {
+		requestId = uuid.NewString()
+	}
+	stream.ResponseHeader().Set(requestIdHeader, requestId)
+
+	reporter := make(chan *proto.ProgressMetrics, 100)
+	run(reporter, requestId)
+
+	for {
+		select {
+		case <-ctx.Done():
+			abortAndDrain(requestId, reporter)
+			return connect.NewError(connect.CodeCanceled, ctx.Err())
+		case progress, ok := <-reporter:
+			if !ok {
+				return nil
+			}
+			if err := stream.Send(progress); err != nil {
+				abortAndDrain(requestId, reporter)
+				return err
+			}
+			if core.IsFinalProgress(progress) {
+				return nil
+			}
+		}
+	}
+}
+
+// abortAndDrain aborts the sim registered under requestId, and keeps consuming its reporter channel
+// in the background so that the sim goroutines don't block on a channel nobody reads anymore.
+func abortAndDrain(requestId string, reporter chan *proto.ProgressMetrics) {
+	simsignals.AbortById(requestId)
+	go func() {
+		for progress := range reporter {
+			if core.IsFinalProgress(progress) {
+				return
+			}
+		}
+	}()
+}
@@ -6,6 +6,7 @@ import {
 	APLActionActivateAuraWithStacks,
 	APLActionAddComboPoints,
 	APLActionAutocastOtherCooldowns,
+	APLActionCallActionList,
 	APLActionCancelAura,
 	APLActionCastPaladinPrimarySeal,
 	APLActionCastSpell,
@@ -19,8 +20,10 @@ import {
 	APLActionMultidot,
 	APLActionMultishield,
 	APLActionResetSequence,
+	APLActionRunActionList,
 	APLActionSchedule,
 	APLActionSequence,
+	APLActionSetVariable,
 	APLActionStrictSequence,
 	APLActionTriggerICD,
 	APLActionWait,
@@ -531,6 +534,38 @@ const actionKindFactories: { [f in NonNullable<APLActionKind>]: ActionKindConfig
 		newValue: APLActionStrictSequence.create,
 		fields: [actionListFieldConfig('actions')],
 	}),
+	['callActionList']: inputBuilder({
+		label: 'Call Action List',
+		submenu: ['Action Lists'],
+		shortDescription: 'Performs the first ready action of the named action list.',
+		fullDescription: `
+			<p>If none of the list's actions are ready, continues with the actions after this one.</p>
+		`,
+		includeIf: (player: Player<any>, isPrepull: boolean) => !isPrepull,
+		newValue: APLActionCallActionList.create,
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
+	['runActionList']: inputBuilder({
+		label: 'Run Action List',
+		submenu: ['Action Lists'],
+		shortDescription: 'Switches to the named action list, and performs its first ready action.',
+		fullDescription: `
+			<p>Unlike <b>Call Action List</b>, the actions after this one are skipped even if none of the list's actions are ready.</p>
+		`,
+		includeIf: (player: Player<any>, isPrepull: boolean) => !isPrepull,
+		newValue: APLActionRunActionList.create,
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
+	['setVariable']: inputBuilder({
+		label: 'Set Variable',
+		submenu: ['Variables'],
+		shortDescription: 'Stores a value, which can be read with the <b>Variable</b> value.',
+		fullDescription: `
+			<p>Only counts as performed when the stored value changes, so the actions after it are always evaluated.</p>
+		`,
+		newValue: APLActionSetVariable.create,
+		fields: [AplHelpers.stringFieldConfig('name'), AplValues.valueFieldConfig('value')],
+	}),
 	['changeTarget']: inputBuilder({
 		label: 'Change Target',
 		submenu: ['Misc'],
@@ -96,7 +96,10 @@ class APLPrepullActionPicker extends Input<Player<any>, APLPrepullAction> {
 		this.player = player;
 
 		const itemHeaderElem = ListPicker.getItemHeaderElem(this);
-		makeListItemWarnings(itemHeaderElem, player, player => player.getCurrentStats().rotationStats?.prepullActions[index]?.warnings || []);
+		makeListItemWarnings(itemHeaderElem, player, player => [
+			...(player.getCurrentStats().rotationStats?.prepullActions[index]?.warnings || []),
+			...getItemDiagnostics(player, true, index),
+		]);
 
 		this.hidePicker = new HidePicker(itemHeaderElem, player, {
 			changedEvent: () => this.player.rotationChangeEmitter,
@@ -196,7 +199,10 @@ class APLListItemPicker extends Input<Player<any>, APLListItem> {
 		this.player = player;
 
 		const itemHeaderElem = ListPicker.getItemHeaderElem(this);
-		makeListItemWarnings(itemHeaderElem, player, player => player.getCurrentStats().rotationStats?.priorityList[index]?.warnings || []);
+		makeListItemWarnings(itemHeaderElem, player, player => [
+			...(player.getCurrentStats().rotationStats?.priorityList[index]?.warnings || []),
+			...getItemDiagnostics(player, false, index),
+		]);
 
 		this.hidePicker = new HidePicker(itemHeaderElem, player, {
 			changedEvent: () => this.player.rotationChangeEmitter,
@@ -239,6 +245,13 @@ class APLListItemPicker extends Input<Player<any>, APLListItem> {
 	}
 }
 
+// Messages of the static analysis diagnostics for an item of the prepull or main priority list.
+function getItemDiagnostics(player: Player<any>, prepull: boolean, index: number): Array<string> {
+	return (player.getCurrentStats().rotationStats?.diagnostics || [])
+		.filter(diagnostic => diagnostic.prepull === prepull && !diagnostic.actionList && diagnostic.itemIndex === index)
+		.map(diagnostic => diagnostic.message);
+}
+
 function makeListItemWarnings(itemHeaderElem: HTMLElement, player: Player<any>, getWarnings: (player: Player<any>) => Array<string>) {
 	const warningsElem = ListPicker.makeActionElem('apl-warnings', 'fa-exclamation-triangle');
 	warningsElem.classList.add('warning', 'link-warning');
@@ -63,8 +63,12 @@ import {
 	APLValueSpellIsReady,
 	APLValueSpellTimeToReady,
 	APLValueSpellTravelTime,
+	APLValueTargetIsAvailable,
+	APLValueTargetPhase,
 	APLValueTimeToEnergyTick,
+	APLValueTimeUntilTargetAvailable,
 	APLValueTotemRemainingTime,
+	APLValueVariable,
 	APLValueWarlockCurrentPetMana,
 	APLValueWarlockCurrentPetManaPercent,
 	APLValueWarlockPetIsActive,
@@ -591,6 +595,27 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		newValue: APLValueNumberTargets.create,
 		fields: [],
 	}),
+	targetPhase: inputBuilder({
+		label: 'Target Phase',
+		submenu: ['Encounter'],
+		shortDescription: 'Current phase of the target, starting at 1.',
+		newValue: APLValueTargetPhase.create,
+		fields: [AplHelpers.unitFieldConfig('targetUnit', 'targets')],
+	}),
+	targetIsAvailable: inputBuilder({
+		label: 'Target Is Available',
+		submenu: ['Encounter'],
+		shortDescription: '<b>True</b> if the targets can be hit, i.e. they are not in an encounter downtime window.',
+		newValue: APLValueTargetIsAvailable.create,
+		fields: [],
+	}),
+	timeUntilTargetAvailable: inputBuilder({
+		label: 'Time Until Target Available',
+		submenu: ['Encounter'],
+		shortDescription: 'Time until the current encounter downtime window ends, or 0 if the targets can be hit.',
+		newValue: APLValueTimeUntilTargetAvailable.create,
+		fields: [],
+	}),
 	frontOfTarget: inputBuilder({
 		label: 'Front of Target',
 		submenu: ['Encounter'],
@@ -948,6 +973,18 @@ const valueKindFactories: { [f in NonNullable<APLValueKind>]: ValueKindConfig<AP
 		fields: [AplHelpers.stringFieldConfig('sequenceName')],
 	}),
 
+	// Variables
+	variable: inputBuilder({
+		label: 'Variable',
+		submenu: ['Variables'],
+		shortDescription: 'Returns the value stored by the <b>Set Variable</b> actions with the same name.',
+		fullDescription: `
+			<p>Variables have the type of the value they were first set to, and are reset at the start of each iteration.</p>
+		`,
+		newValue: APLValueVariable.create,
+		fields: [AplHelpers.stringFieldConfig('name')],
+	}),
+
 	// Class/spec specific values
 	totemRemainingTime: inputBuilder({
 		label: 'Totem Remaining Time',
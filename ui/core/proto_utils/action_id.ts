@@ -132,6 +132,14 @@ export class ActionId {
 				baseName = 'Defensive Equipment';
 				iconUrl = 'https://wow.zamimg.com/images/wow/icons/large/inv_trinket_naxxramas05.jpg';
 				break;
+			case OtherAction.OtherActionEncounterPhase:
+				name = `Phase ${tag}`;
+				iconUrl = 'https://wow.zamimg.com/images/wow/icons/large/spell_nature_timestop.jpg';
+				break;
+			case OtherAction.OtherActionTargetUnavailable:
+				baseName = 'Unavailable';
+				iconUrl = 'https://wow.zamimg.com/images/wow/icons/large/spell_shadow_teleport.jpg';
+				break;
 		}
 		this.baseName = baseName;
 		this.name = name || baseName;